@@ -0,0 +1,59 @@
+package cmd
+
+import (
+	"fmt"
+	"log"
+
+	"github.com/spf13/cobra"
+	"github.com/willschroeder/fingerprint/pkg/db"
+	"github.com/willschroeder/fingerprint/pkg/server"
+)
+
+var callersCmd = &cobra.Command{
+	Use:   "callers",
+	Short: "Manage the internal services allowed to call the gRPC API",
+}
+
+var createCallerCmd = &cobra.Command{
+	Use:   "create NAME",
+	Short: "Register a caller and print its API key",
+	Args:  cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		methods, _ := cmd.Flags().GetStringSlice("methods")
+		scopes, _ := cmd.Flags().GetStringSlice("scopes")
+
+		dao := db.ConnectToDatabase(loadConfig(databaseSections).Database)
+		defer dao.Conn.Close()
+
+		apiKey, err := server.CreateCaller(server.NewStore(dao), args[0], methods, scopes)
+		if err != nil {
+			log.Fatalf("failed to create caller: %v", err)
+		}
+		fmt.Println("API key, it won't be shown again:")
+		fmt.Println(apiKey)
+	},
+}
+
+var revokeCallerCmd = &cobra.Command{
+	Use:   "revoke NAME",
+	Short: "Revoke a caller's API key",
+	Args:  cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		dao := db.ConnectToDatabase(loadConfig(databaseSections).Database)
+		defer dao.Conn.Close()
+
+		err := server.NewStore(dao).RevokeCaller(args[0])
+		if err != nil {
+			log.Fatalf("failed to revoke caller: %v", err)
+		}
+		fmt.Printf("Revoked %s\n", args[0])
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(callersCmd)
+	callersCmd.AddCommand(createCallerCmd, revokeCallerCmd)
+
+	createCallerCmd.Flags().StringSlice("methods", nil, "RPCs the caller may use, e.g. CreateSession,GetSession, * for all")
+	createCallerCmd.Flags().StringSlice("scopes", nil, "scopes the caller may grant to sessions, * for any")
+}
@@ -16,35 +16,66 @@ package cmd
 
 import (
 	"fmt"
+	"github.com/willschroeder/fingerprint/pkg/db"
+	"github.com/willschroeder/fingerprint/pkg/server"
+	"log"
+	"time"
 
 	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
 )
 
-// cleanCmd represents the clean command
 var cleanCmd = &cobra.Command{
 	Use:   "clean",
-	Short: "A brief description of your command",
-	Long: `A longer description that spans multiple lines and likely contains examples
-and usage of using your command. For example:
-
-Cobra is a CLI library for Go that empowers applications.
-This application is a tool to generate the needed files
-to quickly create a Cobra application.`,
+	Short: "Remove expired sessions, stale guests and old revocations",
+	Long: `Removes sessions and scope groupings past their expiration, guests older than --guest-ttl
+without live sessions, password reset tokens older than --reset-token-ttl, and revoked sessions,
+spent refresh tokens and revoked callers older than --revocation-retention, and job runs older
+than --job-run-retention. Rows are removed in
+batches of --batch-size. serve can run the same clean with --clean-interval.`,
 	Run: func(cmd *cobra.Command, args []string) {
-		fmt.Println("clean called")
+		dryRun, _ := cmd.Flags().GetBool("dry-run")
+		cfg := loadConfig(cleanSections)
+
+		dao := db.ConnectToDatabase(cfg.Database)
+		defer dao.Conn.Close()
+
+		options := server.NewCleanOptions(cfg.Clean)
+		options.DryRun = dryRun
+		results, err := server.Clean(server.NewStore(dao), options, time.Now())
+		verb := "Removed"
+		if dryRun {
+			verb = "Would remove"
+		}
+		for _, result := range results {
+			fmt.Printf("%s %d %s\n", verb, result.Rows, result.Name)
+		}
+		if err != nil {
+			log.Fatalf("failed to clean: %v", err)
+		}
 	},
 }
 
+// cleanFlags maps each flag to the config key it overrides
+var cleanFlags = map[string]string{
+	"batch-size":           "clean.batch_size",
+	"guest-ttl":            "clean.guest_ttl",
+	"reset-token-ttl":      "clean.reset_token_ttl",
+	"revocation-retention": "clean.revocation_retention",
+	"job-run-retention":    "clean.job_run_retention",
+}
+
 func init() {
 	rootCmd.AddCommand(cleanCmd)
 
-	// Here you will define your flags and configuration settings.
-
-	// Cobra supports Persistent Flags which will work for this command
-	// and all subcommands, e.g.:
-	// cleanCmd.PersistentFlags().String("foo", "", "A help for foo")
+	cleanCmd.Flags().Bool("dry-run", false, "count what would be removed without removing anything")
+	cleanCmd.Flags().Int("batch-size", 1000, "most rows removed by one statement")
+	cleanCmd.Flags().Duration("guest-ttl", 30*24*time.Hour, "remove guests this old that have no live sessions, 0 keeps them")
+	cleanCmd.Flags().Duration("reset-token-ttl", 24*time.Hour, "expire password reset tokens requested this long ago, 0 keeps them")
+	cleanCmd.Flags().Duration("revocation-retention", 30*24*time.Hour, "remove revoked sessions, spent refresh tokens and revoked callers this old, 0 keeps them")
+	cleanCmd.Flags().Duration("job-run-retention", 30*24*time.Hour, "remove job run history this old, 0 keeps it")
 
-	// Cobra supports local flags which will only run when this command
-	// is called directly, e.g.:
-	// cleanCmd.Flags().BoolP("toggle", "t", false, "Help message for toggle")
+	for flag, key := range cleanFlags {
+		viper.BindPFlag(key, cleanCmd.Flags().Lookup(flag))
+	}
 }
@@ -2,6 +2,8 @@ package cmd
 
 import (
 	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+	"github.com/willschroeder/fingerprint/pkg/client"
 )
 
 var clientCmd = &cobra.Command{
@@ -16,11 +18,16 @@ var testCmd = &cobra.Command{
 	Use:   "test",
 	Short: "Runs a new client",
 	Run: func(cmd *cobra.Command, args []string) {
-		println("client test")
+		client.NewClient(loadConfig(clientSections).Client)
 	},
 }
 
 func init() {
 	rootCmd.AddCommand(clientCmd)
 	clientCmd.AddCommand(testCmd)
+
+	clientCmd.PersistentFlags().String("address", "localhost:50051", "server to connect to")
+	clientCmd.PersistentFlags().String("ca", "", "PEM CA bundle the server is verified against")
+	viper.BindPFlag("client.address", clientCmd.PersistentFlags().Lookup("address"))
+	viper.BindPFlag("client.ca", clientCmd.PersistentFlags().Lookup("ca"))
 }
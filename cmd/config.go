@@ -0,0 +1,70 @@
+package cmd
+
+import (
+	"fmt"
+	"log"
+	"os"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+	"github.com/willschroeder/fingerprint/pkg/config"
+	"gopkg.in/yaml.v3"
+)
+
+var configCmd = &cobra.Command{
+	Use:   "config",
+	Short: "Inspect the configuration from the config file, environment and flags",
+}
+
+var printConfigCmd = &cobra.Command{
+	Use:   "print",
+	Short: "Print the effective configuration as YAML with secrets redacted",
+	Run: func(cmd *cobra.Command, args []string) {
+		cfg, err := config.Read(viper.GetViper())
+		if err != nil {
+			log.Fatalf("failed to read config: %v", err)
+		}
+		out, err := yaml.Marshal(cfg.Settings())
+		if err != nil {
+			log.Fatalf("failed to print config: %v", err)
+		}
+		fmt.Print(string(out))
+	},
+}
+
+// commandSections lets config validate check a config for a single command
+var commandSections = map[string][]config.Validator{
+	"db":      databaseSections,
+	"callers": databaseSections,
+	"clean":   cleanSections,
+	"client":  clientSections,
+	"serve":   serveSections,
+}
+
+var validateConfigCmd = &cobra.Command{
+	Use:       "validate [db|callers|clean|client|serve]",
+	Short:     "Check the configuration, exits non-zero when it's invalid",
+	Long:      "Check the configuration, exits non-zero when it's invalid. Given a command only the sections it reads are checked, otherwise every section is.",
+	Args:      cobra.MatchAll(cobra.MaximumNArgs(1), cobra.OnlyValidArgs),
+	ValidArgs: []string{"db", "callers", "clean", "client", "serve"},
+	Run: func(cmd *cobra.Command, args []string) {
+		var sections []config.Validator
+		if len(args) == 1 {
+			sections = commandSections[args[0]]
+		}
+		cfg, err := config.Load(viper.GetViper(), sections...)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "invalid config:\n%v\n", err)
+			os.Exit(1)
+		}
+		for _, warning := range cfg.Warnings() {
+			fmt.Println("warning:", warning)
+		}
+		fmt.Println("config is valid")
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(configCmd)
+	configCmd.AddCommand(printConfigCmd, validateConfigCmd)
+}
@@ -16,66 +16,177 @@ package cmd
 
 import (
 	"fmt"
-	_ "github.com/lib/pq"
-	"github.com/rubenv/sql-migrate"
+	"github.com/willschroeder/fingerprint/pkg/config"
 	"github.com/willschroeder/fingerprint/pkg/db"
-	"path/filepath"
+	"log"
+	"os"
+	"text/tabwriter"
+	"time"
 
 	"github.com/spf13/cobra"
 )
 
-// dbCmd represents the db command
 var dbCmd = &cobra.Command{
 	Use:   "db",
-	Short: "A brief description of your command",
+	Short: "Create, migrate and inspect the database",
+}
+
+var statusCmd = &cobra.Command{
+	Use:   "status",
+	Short: "List applied and pending migrations",
 	Run: func(cmd *cobra.Command, args []string) {
-		fmt.Println("db called")
+		dao := db.ConnectToDatabase(loadConfig(databaseSections).Database)
+		defer dao.Conn.Close()
+
+		printMigrationStatus(dao)
 	},
 }
 
 var migrateCmd = &cobra.Command{
 	Use:   "migrate",
-	Short: "A brief description of your command",
+	Short: "Apply pending migrations, or migrate to the version given with --to",
 	Run: func(cmd *cobra.Command, args []string) {
-		absPath, _ := filepath.Abs("./migrations")
+		dao := db.ConnectToDatabase(loadConfig(databaseSections).Database)
+		defer dao.Conn.Close()
 
-		migrations := &migrate.FileMigrationSource{
-			Dir: absPath,
+		var n int
+		var err error
+		if cmd.Flags().Changed("to") {
+			to, _ := cmd.Flags().GetInt64("to")
+			n, err = dao.MigrateTo(to)
+		} else {
+			n, err = dao.MigrateUp()
 		}
-
-		db := db.ConnectToDatabase()
-		defer db.Conn.Close()
-
-		n, err := migrate.Exec(db.Conn, "postgres", migrations, migrate.Up)
 		if err != nil {
-			panic(err)
+			log.Fatalf("failed to migrate: %v", err)
 		}
-		fmt.Printf("Applied %d migrations\n", n)
+		fmt.Printf("Ran %d migrations\n", n)
+		printMigrationStatus(dao)
 	},
 }
 
 var rollbackCmd = &cobra.Command{
 	Use:   "rollback",
-	Short: "A brief description of your command",
+	Short: "Roll back the latest migrations",
 	Run: func(cmd *cobra.Command, args []string) {
-		absPath, _ := filepath.Abs("./migrations")
+		steps, _ := cmd.Flags().GetInt("steps")
 
-		migrations := &migrate.FileMigrationSource{
-			Dir: absPath,
+		dao := db.ConnectToDatabase(loadConfig(databaseSections).Database)
+		defer dao.Conn.Close()
+
+		n, err := dao.Rollback(steps)
+		if err != nil {
+			log.Fatalf("failed to roll back: %v", err)
 		}
+		fmt.Printf("Rolled back %d migrations\n", n)
+		printMigrationStatus(dao)
+	},
+}
 
-		db := db.ConnectToDatabase()
-		defer db.Conn.Close()
+var createDBCmd = &cobra.Command{
+	Use:   "create",
+	Short: "Create the database",
+	Run: func(cmd *cobra.Command, args []string) {
+		createDatabase(loadConfig(databaseSections).Database)
+	},
+}
 
-		n, err := migrate.Exec(db.Conn, "postgres", migrations, migrate.Down)
+var dropDBCmd = &cobra.Command{
+	Use:   "drop",
+	Short: "Drop the database and everything in it",
+	Run: func(cmd *cobra.Command, args []string) {
+		cfg := loadConfig(databaseSections).Database
+		requireYes(cmd, cfg)
+		dropDatabase(cfg)
+	},
+}
+
+var resetDBCmd = &cobra.Command{
+	Use:   "reset",
+	Short: "Drop, create and migrate the database",
+	Run: func(cmd *cobra.Command, args []string) {
+		cfg := loadConfig(databaseSections).Database
+		requireYes(cmd, cfg)
+		dropDatabase(cfg)
+		createDatabase(cfg)
+
+		dao := db.ConnectToDatabase(cfg)
+		defer dao.Conn.Close()
+
+		n, err := dao.MigrateUp()
 		if err != nil {
-			panic(err)
+			log.Fatalf("failed to migrate: %v", err)
 		}
-		fmt.Printf("Rolled back %d migrations\n", n)
+		fmt.Printf("Ran %d migrations\n", n)
 	},
 }
 
+func createDatabase(cfg config.Database) {
+	created, err := db.CreateDatabase(cfg)
+	if err != nil {
+		log.Fatalf("failed to create the database: %v", err)
+	}
+	if created {
+		fmt.Printf("Created %s\n", databaseName(cfg))
+	} else {
+		fmt.Printf("%s already exists\n", databaseName(cfg))
+	}
+}
+
+// requireYes stops commands that drop the database unless --yes confirms it
+func requireYes(cmd *cobra.Command, cfg config.Database) {
+	yes, _ := cmd.Flags().GetBool("yes")
+	if !yes {
+		log.Fatalf("%s drops %s and everything in it, run it again with --yes to go ahead", cmd.CommandPath(), databaseName(cfg))
+	}
+}
+
+func dropDatabase(cfg config.Database) {
+	err := db.DropDatabase(cfg)
+	if err != nil {
+		log.Fatalf("failed to drop the database: %v", err)
+	}
+	fmt.Printf("Dropped %s\n", databaseName(cfg))
+}
+
+func databaseName(cfg config.Database) string {
+	if cfg.Driver == config.DriverSQLite {
+		return cfg.Path
+	}
+	return cfg.Name
+}
+
+func printMigrationStatus(dao *db.DAO) {
+	statuses, err := dao.MigrationStatus()
+	if err != nil {
+		log.Fatalf("failed to read migrations: %v", err)
+	}
+
+	pending := 0
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "MIGRATION\tAPPLIED")
+	for _, status := range statuses {
+		applied := "pending"
+		if status.Applied {
+			applied = status.AppliedAt.Local().Format(time.RFC3339)
+		} else {
+			pending++
+		}
+		if status.Unknown {
+			applied += " (not in this binary)"
+		}
+		fmt.Fprintf(w, "%s\t%s\n", status.ID, applied)
+	}
+	w.Flush()
+	fmt.Printf("%d applied, %d pending\n", len(statuses)-pending, pending)
+}
+
 func init() {
 	rootCmd.AddCommand(dbCmd)
-	dbCmd.AddCommand(migrateCmd, rollbackCmd)
+	dbCmd.AddCommand(statusCmd, migrateCmd, rollbackCmd, createDBCmd, dropDBCmd, resetDBCmd)
+
+	migrateCmd.Flags().Int64("to", 0, "migration version to end at, e.g. 1543387200, rolling back newer ones; 0 rolls back everything")
+	rollbackCmd.Flags().Int("steps", 1, "how many migrations to roll back")
+	dropDBCmd.Flags().Bool("yes", false, "confirm the database should be dropped")
+	resetDBCmd.Flags().Bool("yes", false, "confirm the database should be dropped")
 }
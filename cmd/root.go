@@ -21,6 +21,7 @@ import (
 	homedir "github.com/mitchellh/go-homedir"
 	"github.com/spf13/cobra"
 	"github.com/spf13/viper"
+	"github.com/willschroeder/fingerprint/pkg/config"
 )
 
 var cfgFile string
@@ -80,10 +81,43 @@ func initConfig() {
 		viper.SetConfigName(".fingerprint")
 	}
 
-	viper.AutomaticEnv() // read in environment variables that match
+	// Registers every setting and reads environment variables that match, e.g. FINGERPRINT_DATABASE_HOST
+	config.SetDefaults(viper.GetViper())
 
 	// If a config file is found, read it in.
-	if err := viper.ReadInConfig(); err == nil {
-		fmt.Println("Using config file:", viper.ConfigFileUsed())
+	err := viper.ReadInConfig()
+	if err == nil {
+		fmt.Fprintln(os.Stderr, "Using config file:", viper.ConfigFileUsed())
+	} else if cfgFile != "" {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+}
+
+// The config sections each command reads, a command only refuses to start over problems in its own
+// sections so e.g. db commands run without token keys
+var (
+	databaseSections = []config.Validator{(*config.Config).ValidateDatabase}
+	cleanSections    = []config.Validator{(*config.Config).ValidateDatabase, (*config.Config).ValidateClean}
+	clientSections   = []config.Validator{(*config.Config).ValidateClient}
+	serveSections    = []config.Validator{
+		(*config.Config).ValidateDatabase,
+		(*config.Config).ValidateServer,
+		(*config.Config).ValidateTokens,
+		(*config.Config).ValidatePasswords,
+		(*config.Config).ValidateClean,
+	}
+)
+
+// loadConfig exits when the sections a command reads are invalid, commands shouldn't start half configured
+func loadConfig(sections []config.Validator) *config.Config {
+	cfg, err := config.Load(viper.GetViper(), sections...)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "invalid config:\n%v\n", err)
+		os.Exit(1)
+	}
+	for _, warning := range cfg.Warnings() {
+		fmt.Fprintln(os.Stderr, "warning:", warning)
 	}
+	return cfg
 }
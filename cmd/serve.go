@@ -1,18 +1,74 @@
 package cmd
 
 import (
+	"time"
+
 	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
 	"github.com/willschroeder/fingerprint/pkg/server"
+	"github.com/willschroeder/fingerprint/pkg/session_representations"
 )
 
 var serveCmd = &cobra.Command{
 	Use:   "serve",
 	Short: "Run the fingerprint server",
 	Run: func(cmd *cobra.Command, args []string) {
-		server.NewServer()
+		server.NewServer(loadConfig(serveSections))
 	},
 }
 
+// serveFlags maps each flag to the config key it overrides
+var serveFlags = map[string]string{
+	"grpc-address":             "server.grpc_address",
+	"http-address":             "server.http_address",
+	"token-format":             "tokens.format",
+	"token-development":        "tokens.development",
+	"clock-skew":               "tokens.clock_skew",
+	"access-token-ttl":         "sessions.access_token_ttl",
+	"idle-timeout":             "sessions.idle_timeout",
+	"max-session-lifetime":     "sessions.max_lifetime",
+	"last-seen-flush-interval": "sessions.last_seen_flush_interval",
+	"introspection-clients":    "http.introspection_clients",
+	"session-cookie":           "http.session_cookie",
+	"cors-allowed-origins":     "http.cors_allowed_origins",
+	"tls-cert":                 "tls.cert",
+	"tls-key":                  "tls.key",
+	"tls-client-ca":            "tls.client_ca",
+	"tls-require-client-cert":  "tls.require_client_cert",
+	"tls-admin-subjects":       "tls.admin_subjects",
+	"require-api-keys":         "callers.require_api_keys",
+	"caller-cache-ttl":         "callers.cache_ttl",
+	"clean-interval":           "clean.interval",
+}
+
 func init() {
 	rootCmd.AddCommand(serveCmd)
-}
\ No newline at end of file
+
+	// Token keys are only read from the config file or environment
+	// so they don't end up in process listings
+	serveCmd.Flags().String("grpc-address", ":50051", "address the gRPC service listens on")
+	serveCmd.Flags().String("http-address", ":8080", "address the REST gateway and HTTP endpoints listen on")
+	serveCmd.Flags().String("token-format", "paseto.v2.local", "paseto.v2.local, paseto.v2.public, paseto.v4.local, paseto.v4.public, jwt.eddsa or jwt.es256")
+	serveCmd.Flags().Bool("token-development", false, "issue local tokens with the built in development secret when tokens.keys is empty, never use it in production")
+	serveCmd.Flags().Duration("clock-skew", session_representations.DefaultClockSkew, "how far past an expiration a session is still accepted")
+	serveCmd.Flags().Duration("access-token-ttl", 0, "how long a token is accepted before it must be refreshed, 0 keeps the session's lifetime")
+	serveCmd.Flags().Duration("idle-timeout", 0, "end sessions that go this long without being validated, 0 never idles them out")
+	serveCmd.Flags().Duration("max-session-lifetime", 0, "how long activity can keep a session alive, 0 for no limit")
+	serveCmd.Flags().Duration("last-seen-flush-interval", 10*time.Second, "how often session activity is written to the database")
+	serveCmd.Flags().StringToString("introspection-clients", nil, "client_id=secret pairs allowed to call /introspect, introspection is off without any")
+	serveCmd.Flags().String("session-cookie", server.DefaultSessionCookie, "cookie /forward-auth reads the token from when there's no Authorization header")
+	serveCmd.Flags().StringSlice("cors-allowed-origins", nil, "origins allowed to call gRPC-Web from a browser, * allows any")
+	serveCmd.Flags().String("tls-cert", "", "PEM certificate for the gRPC listener, serves plaintext without one")
+	serveCmd.Flags().String("tls-key", "", "PEM private key for --tls-cert")
+	serveCmd.Flags().String("tls-client-ca", "", "PEM CA bundle client certificates are verified against")
+	serveCmd.Flags().Bool("tls-require-client-cert", false, "refuse clients that don't present a certificate signed by --tls-client-ca")
+	serveCmd.Flags().StringSlice("tls-admin-subjects", nil, "client certificate CNs, DNs or URI SANs allowed to call admin RPCs, everyone can without any")
+	serveCmd.Flags().Bool("require-api-keys", false, "only answer callers with an API key from `fingerprint callers create`")
+	serveCmd.Flags().Duration("caller-cache-ttl", server.DefaultCallerCacheTTL, "how long API key lookups are cached, revoked callers can keep calling for this long")
+
+	serveCmd.Flags().Duration("clean-interval", 0, "run the clean command this often inside the server, 0 never does")
+
+	for flag, key := range serveFlags {
+		viper.BindPFlag(key, serveCmd.Flags().Lookup(flag))
+	}
+}
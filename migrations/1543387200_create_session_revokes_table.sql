@@ -0,0 +1,12 @@
+-- +migrate Up
+CREATE TABLE session_revokes (
+                     id SERIAL PRIMARY KEY,
+                     uuid uuid NOT NULL UNIQUE,
+                     session_id INTEGER REFERENCES sessions(id) ON DELETE CASCADE NOT NULL UNIQUE,
+                     reason TEXT NOT NULL,
+                     created_at TIMESTAMPTZ NOT NULL
+);
+CREATE INDEX session_revokes_uuid ON session_revokes (uuid);
+
+-- +migrate Down
+DROP TABLE session_revokes;
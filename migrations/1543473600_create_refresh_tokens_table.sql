@@ -0,0 +1,16 @@
+-- +migrate Up
+CREATE TABLE refresh_tokens (
+                     id SERIAL PRIMARY KEY,
+                     uuid uuid NOT NULL UNIQUE,
+                     session_id INTEGER REFERENCES sessions(id) ON DELETE CASCADE NOT NULL,
+                     family_uuid uuid NOT NULL,
+                     token_hash TEXT NOT NULL UNIQUE,
+                     used_at TIMESTAMPTZ,
+                     revoked_at TIMESTAMPTZ,
+                     created_at TIMESTAMPTZ NOT NULL
+);
+CREATE INDEX refresh_tokens_uuid ON refresh_tokens (uuid);
+CREATE INDEX refresh_tokens_family_uuid ON refresh_tokens (family_uuid);
+
+-- +migrate Down
+DROP TABLE refresh_tokens;
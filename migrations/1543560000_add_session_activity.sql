@@ -0,0 +1,8 @@
+-- +migrate Up
+ALTER TABLE sessions ADD COLUMN last_seen_at TIMESTAMPTZ;
+ALTER TABLE sessions ADD COLUMN idle_timeout_seconds INTEGER;
+UPDATE sessions SET last_seen_at = created_at;
+
+-- +migrate Down
+ALTER TABLE sessions DROP COLUMN idle_timeout_seconds;
+ALTER TABLE sessions DROP COLUMN last_seen_at;
@@ -0,0 +1,14 @@
+-- +migrate Up
+CREATE TABLE callers (
+                     id SERIAL PRIMARY KEY,
+                     uuid uuid NOT NULL UNIQUE,
+                     name TEXT NOT NULL UNIQUE,
+                     api_key_hash TEXT NOT NULL UNIQUE,
+                     allowed_methods TEXT[] NOT NULL DEFAULT '{}',
+                     allowed_scopes TEXT[] NOT NULL DEFAULT '{}',
+                     created_at TIMESTAMPTZ NOT NULL,
+                     revoked_at TIMESTAMPTZ
+);
+
+-- +migrate Down
+DROP TABLE callers;
@@ -0,0 +1,19 @@
+-- +migrate Up
+-- scheduled_at is the tick a scheduled run was for, it's unique per job so replicas can't run a tick twice.
+-- Manual runs leave it NULL.
+CREATE TABLE job_runs (
+                     id SERIAL PRIMARY KEY,
+                     uuid uuid NOT NULL UNIQUE,
+                     job_name TEXT NOT NULL,
+                     triggered_by TEXT NOT NULL,
+                     runner TEXT NOT NULL,
+                     scheduled_at TIMESTAMPTZ,
+                     started_at TIMESTAMPTZ NOT NULL,
+                     finished_at TIMESTAMPTZ,
+                     error TEXT
+);
+CREATE UNIQUE INDEX job_runs_job_name_scheduled_at ON job_runs (job_name, scheduled_at);
+CREATE INDEX job_runs_job_name_started_at ON job_runs (job_name, started_at);
+
+-- +migrate Down
+DROP TABLE job_runs;
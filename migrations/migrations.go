@@ -0,0 +1,9 @@
+// Package migrations embeds the SQL migrations so the binary can run them from any directory
+package migrations
+
+import "embed"
+
+// FS has the Postgres migrations at its root and the SQLite ones in sqlite
+//
+//go:embed *.sql sqlite/*.sql
+var FS embed.FS
@@ -0,0 +1,36 @@
+-- +migrate Up
+CREATE TABLE users (
+                     id INTEGER PRIMARY KEY AUTOINCREMENT,
+                     uuid TEXT NOT NULL UNIQUE,
+                     email TEXT NOT NULL UNIQUE,
+                     is_guest BOOLEAN NOT NULL,
+                     password_reset_token TEXT NOT NULL UNIQUE,
+                     encrypted_password TEXT NOT NULL,
+                     created_at TIMESTAMP NOT NULL
+);
+CREATE INDEX users_uuid ON users (uuid);
+CREATE TABLE sessions (
+                    id INTEGER PRIMARY KEY AUTOINCREMENT,
+                    uuid TEXT NOT NULL UNIQUE,
+                    user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+                    token TEXT NOT NULL,
+                    expiration TIMESTAMP NOT NULL,
+                    created_at TIMESTAMP NOT NULL
+);
+CREATE INDEX sessions_uuid ON sessions (uuid);
+CREATE INDEX sessions_token ON sessions (token);
+-- scopes is a JSON array of strings, SQLite has no array type
+CREATE TABLE scope_groupings (
+                    id INTEGER PRIMARY KEY AUTOINCREMENT,
+                    uuid TEXT NOT NULL UNIQUE,
+                    session_id INTEGER NOT NULL REFERENCES sessions(id) ON DELETE CASCADE,
+                    scopes TEXT NOT NULL,
+                    expiration TIMESTAMP NOT NULL,
+                    created_at TIMESTAMP NOT NULL
+);
+CREATE INDEX scope_groupings_uuid ON scope_groupings (uuid);
+
+-- +migrate Down
+DROP TABLE scope_groupings;
+DROP TABLE sessions;
+DROP TABLE users;
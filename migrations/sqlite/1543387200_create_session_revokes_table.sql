@@ -0,0 +1,12 @@
+-- +migrate Up
+CREATE TABLE session_revokes (
+                     id INTEGER PRIMARY KEY AUTOINCREMENT,
+                     uuid TEXT NOT NULL UNIQUE,
+                     session_id INTEGER NOT NULL UNIQUE REFERENCES sessions(id) ON DELETE CASCADE,
+                     reason TEXT NOT NULL,
+                     created_at TIMESTAMP NOT NULL
+);
+CREATE INDEX session_revokes_uuid ON session_revokes (uuid);
+
+-- +migrate Down
+DROP TABLE session_revokes;
@@ -0,0 +1,16 @@
+-- +migrate Up
+CREATE TABLE refresh_tokens (
+                     id INTEGER PRIMARY KEY AUTOINCREMENT,
+                     uuid TEXT NOT NULL UNIQUE,
+                     session_id INTEGER NOT NULL REFERENCES sessions(id) ON DELETE CASCADE,
+                     family_uuid TEXT NOT NULL,
+                     token_hash TEXT NOT NULL UNIQUE,
+                     used_at TIMESTAMP,
+                     revoked_at TIMESTAMP,
+                     created_at TIMESTAMP NOT NULL
+);
+CREATE INDEX refresh_tokens_uuid ON refresh_tokens (uuid);
+CREATE INDEX refresh_tokens_family_uuid ON refresh_tokens (family_uuid);
+
+-- +migrate Down
+DROP TABLE refresh_tokens;
@@ -0,0 +1,15 @@
+-- +migrate Up
+-- allowed_methods and allowed_scopes are JSON arrays of strings
+CREATE TABLE callers (
+                     id INTEGER PRIMARY KEY AUTOINCREMENT,
+                     uuid TEXT NOT NULL UNIQUE,
+                     name TEXT NOT NULL UNIQUE,
+                     api_key_hash TEXT NOT NULL UNIQUE,
+                     allowed_methods TEXT NOT NULL DEFAULT '[]',
+                     allowed_scopes TEXT NOT NULL DEFAULT '[]',
+                     created_at TIMESTAMP NOT NULL,
+                     revoked_at TIMESTAMP
+);
+
+-- +migrate Down
+DROP TABLE callers;
@@ -0,0 +1,5 @@
+-- +migrate Up
+ALTER TABLE users ADD COLUMN password_reset_requested_at TIMESTAMP;
+
+-- +migrate Down
+ALTER TABLE users DROP COLUMN password_reset_requested_at;
@@ -0,0 +1,152 @@
+package certificates
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"io/ioutil"
+	"log"
+	"os"
+	"sync"
+	"time"
+)
+
+var ErrNoCertificates = errors.New("no certificates found in CA file")
+
+// checkInterval keeps handshakes from statting the files more than once a second
+const checkInterval = time.Second
+
+// Reloader serves a certificate, and optionally a CA pool, from files on disk and picks up
+// replacements without a restart. A failed reload keeps the previous certificate.
+type Reloader struct {
+	certFile string
+	keyFile  string
+	caFile   string
+
+	mu          sync.Mutex
+	certificate *tls.Certificate
+	pool        *x509.CertPool
+	modTimes    map[string]time.Time
+	checkedAt   time.Time
+}
+
+// NewReloader loads the files once up front so misconfiguration fails at startup. Either the
+// certificate pair or the CA file may be left empty.
+func NewReloader(certFile string, keyFile string, caFile string) (*Reloader, error) {
+	r := &Reloader{certFile: certFile, keyFile: keyFile, caFile: caFile}
+	err := r.load()
+	if err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+func (r *Reloader) files() []string {
+	var files []string
+	for _, f := range []string{r.certFile, r.keyFile, r.caFile} {
+		if f != "" {
+			files = append(files, f)
+		}
+	}
+	return files
+}
+
+func (r *Reloader) load() error {
+	modTimes := map[string]time.Time{}
+	for _, f := range r.files() {
+		info, err := os.Stat(f)
+		if err != nil {
+			return err
+		}
+		modTimes[f] = info.ModTime()
+	}
+
+	var certificate *tls.Certificate
+	if r.certFile != "" {
+		pair, err := tls.LoadX509KeyPair(r.certFile, r.keyFile)
+		if err != nil {
+			return err
+		}
+		certificate = &pair
+	}
+
+	var pool *x509.CertPool
+	if r.caFile != "" {
+		pem, err := ioutil.ReadFile(r.caFile)
+		if err != nil {
+			return err
+		}
+		pool = x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(pem) {
+			return ErrNoCertificates
+		}
+	}
+
+	r.certificate, r.pool, r.modTimes = certificate, pool, modTimes
+	return nil
+}
+
+// current reloads when any of the files has changed since it was last read
+func (r *Reloader) current() (*tls.Certificate, *x509.CertPool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if time.Since(r.checkedAt) < checkInterval {
+		return r.certificate, r.pool
+	}
+	r.checkedAt = time.Now()
+
+	for _, f := range r.files() {
+		info, err := os.Stat(f)
+		if err != nil || info.ModTime().Equal(r.modTimes[f]) {
+			continue
+		}
+		err = r.load()
+		if err != nil {
+			log.Printf("failed to reload certificates, keeping the previous ones: %v", err)
+		}
+		break
+	}
+	return r.certificate, r.pool
+}
+
+func (r *Reloader) GetCertificate(*tls.ClientHelloInfo) (*tls.Certificate, error) {
+	certificate, _ := r.current()
+	return certificate, nil
+}
+
+func (r *Reloader) GetClientCertificate(*tls.CertificateRequestInfo) (*tls.Certificate, error) {
+	certificate, _ := r.current()
+	if certificate == nil {
+		// An empty certificate tells the server we don't have one
+		return &tls.Certificate{}, nil
+	}
+	return certificate, nil
+}
+
+// CertPool is the CA pool from the CA file, nil when there isn't one
+func (r *Reloader) CertPool() *x509.CertPool {
+	_, pool := r.current()
+	return pool
+}
+
+// ServerConfig serves the certificate and verifies client certificates against the CA file, when
+// there is one. requireClientCert refuses clients without one, otherwise they are only checked if sent.
+func (r *Reloader) ServerConfig(requireClientCert bool) *tls.Config {
+	base := &tls.Config{MinVersion: tls.VersionTLS12, GetCertificate: r.GetCertificate}
+	if r.caFile == "" {
+		return base
+	}
+
+	base.GetConfigForClient = func(*tls.ClientHelloInfo) (*tls.Config, error) {
+		config := base.Clone()
+		config.GetConfigForClient = nil
+		config.ClientCAs = r.CertPool()
+		config.ClientAuth = tls.VerifyClientCertIfGiven
+		if requireClientCert {
+			config.ClientAuth = tls.RequireAndVerifyClientCert
+		}
+		return config, nil
+	}
+	return base
+}
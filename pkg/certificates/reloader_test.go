@@ -0,0 +1,105 @@
+package certificates
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"io/ioutil"
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func writeCertificate(t *testing.T, dir string, commonName string) (string, string) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(time.Now().UnixNano()),
+		Subject:               pkix.Name{CommonName: commonName},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	keyDer, _ := x509.MarshalECPrivateKey(key)
+
+	certFile := filepath.Join(dir, "cert.pem")
+	keyFile := filepath.Join(dir, "key.pem")
+	ioutil.WriteFile(certFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0600)
+	ioutil.WriteFile(keyFile, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDer}), 0600)
+	return certFile, keyFile
+}
+
+func commonName(t *testing.T, r *Reloader) string {
+	certificate, err := r.GetCertificate(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	leaf, err := x509.ParseCertificate(certificate.Certificate[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	return leaf.Subject.CommonName
+}
+
+func TestReloaderPicksUpNewCertificates(t *testing.T) {
+	dir, _ := ioutil.TempDir("", "certificates")
+	defer os.RemoveAll(dir)
+
+	certFile, keyFile := writeCertificate(t, dir, "first")
+	r, err := NewReloader(certFile, keyFile, certFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if name := commonName(t, r); name != "first" {
+		t.Errorf("Expected first, got %s", name)
+	}
+	if r.CertPool() == nil {
+		t.Errorf("Expected a CA pool")
+	}
+
+	writeCertificate(t, dir, "second")
+	later := time.Now().Add(time.Minute)
+	os.Chtimes(certFile, later, later)
+	r.checkedAt = time.Time{}
+	if name := commonName(t, r); name != "second" {
+		t.Errorf("Expected the replaced certificate to be served, got %s", name)
+	}
+}
+
+func TestReloaderKeepsCertificateOnBadReload(t *testing.T) {
+	dir, _ := ioutil.TempDir("", "certificates")
+	defer os.RemoveAll(dir)
+
+	certFile, keyFile := writeCertificate(t, dir, "first")
+	r, err := NewReloader(certFile, keyFile, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ioutil.WriteFile(certFile, []byte("not a certificate"), 0600)
+	later := time.Now().Add(time.Minute)
+	os.Chtimes(certFile, later, later)
+	r.checkedAt = time.Time{}
+	if name := commonName(t, r); name != "first" {
+		t.Errorf("Expected the previous certificate to be kept, got %s", name)
+	}
+}
+
+func TestNewReloaderFailsOnMissingFiles(t *testing.T) {
+	_, err := NewReloader("/does/not/exist.pem", "/does/not/exist.key", "")
+	if err == nil {
+		t.Errorf("Expected missing files to fail at startup")
+	}
+}
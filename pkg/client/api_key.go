@@ -0,0 +1,26 @@
+package client
+
+import (
+	"context"
+
+	"google.golang.org/grpc"
+)
+
+// apiKeyMetadata matches the metadata key the server reads callers' API keys from
+const apiKeyMetadata = "x-api-key"
+
+type apiKeyCredentials string
+
+func (k apiKeyCredentials) GetRequestMetadata(context.Context, ...string) (map[string]string, error) {
+	return map[string]string{apiKeyMetadata: string(k)}, nil
+}
+
+// RequireTransportSecurity is off so keys also work against plaintext development servers
+func (k apiKeyCredentials) RequireTransportSecurity() bool {
+	return false
+}
+
+// WithAPIKey sends the caller's API key from `fingerprint callers create` with every call
+func WithAPIKey(apiKey string) grpc.DialOption {
+	return grpc.WithPerRPCCredentials(apiKeyCredentials(apiKey))
+}
@@ -5,17 +5,29 @@ import (
 	"log"
 	"time"
 
+	"github.com/willschroeder/fingerprint/pkg/config"
 	"github.com/willschroeder/fingerprint/pkg/proto"
 	"google.golang.org/grpc"
 )
 
-const (
-	address = "localhost:50051"
-)
+// DialConfig connects to the configured server, sending the API key with every call when there is one
+func DialConfig(cfg config.Client) (*grpc.ClientConn, error) {
+	var opts []grpc.DialOption
+	if cfg.APIKey != "" {
+		opts = append(opts, WithAPIKey(cfg.APIKey))
+	}
+	return Dial(cfg.Address, TLSOptions{
+		Enabled:    cfg.TLS,
+		CAFile:     cfg.CA,
+		CertFile:   cfg.Cert,
+		KeyFile:    cfg.Key,
+		ServerName: cfg.ServerName,
+	}, opts...)
+}
 
-func NewClient() {
+func NewClient(cfg config.Client) {
 	// Set up a connection to the server.
-	conn, err := grpc.Dial(address, grpc.WithInsecure())
+	conn, err := DialConfig(cfg)
 	if err != nil {
 		log.Fatalf("did not connect: %v", err)
 	}
@@ -0,0 +1,123 @@
+package client
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/golang/protobuf/ptypes"
+	"github.com/willschroeder/fingerprint/pkg/proto"
+	"github.com/willschroeder/fingerprint/pkg/session_representations"
+)
+
+const (
+	DefaultKeyRefreshInterval    = 5 * time.Minute
+	DefaultMinKeyRefreshInterval = 10 * time.Second
+)
+
+// VerificationKeyCache fetches the server's public token keys and verifies tokens offline with them.
+// Keys are refreshed once they are older than RefreshInterval, or when a token names a kid the cache
+// hasn't seen yet, which is how keys published ahead of a rotation get picked up. Those refreshes wait
+// MinRefreshInterval since the last fetch, so tokens with made up kids can't make every verifier call
+// the server.
+type VerificationKeyCache struct {
+	client             proto.FingerprintServiceClient
+	RefreshInterval    time.Duration
+	MinRefreshInterval time.Duration
+
+	mu        sync.Mutex
+	keyring   *session_representations.Keyring
+	fetchedAt time.Time
+}
+
+func NewVerificationKeyCache(client proto.FingerprintServiceClient) *VerificationKeyCache {
+	return &VerificationKeyCache{client: client, RefreshInterval: DefaultKeyRefreshInterval, MinRefreshInterval: DefaultMinKeyRefreshInterval}
+}
+
+// Keyring returns the cached keys, fetching them when they are stale
+func (c *VerificationKeyCache) Keyring(ctx context.Context) (*session_representations.Keyring, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.keyring != nil && time.Since(c.fetchedAt) < c.RefreshInterval {
+		return c.keyring, nil
+	}
+	return c.refresh(ctx)
+}
+
+// Refresh fetches the keys regardless of how old the cache is
+func (c *VerificationKeyCache) Refresh(ctx context.Context) (*session_representations.Keyring, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.refresh(ctx)
+}
+
+// refreshForUnknownKid refreshes unless the keys were fetched less than MinRefreshInterval ago. Callers
+// queue on the lock behind a refresh in flight and then find the keys fresh, so it's only made once.
+func (c *VerificationKeyCache) refreshForUnknownKid(ctx context.Context) (*session_representations.Keyring, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.keyring != nil && time.Since(c.fetchedAt) < c.MinRefreshInterval {
+		return c.keyring, nil
+	}
+	return c.refresh(ctx)
+}
+
+func (c *VerificationKeyCache) refresh(ctx context.Context) (*session_representations.Keyring, error) {
+	res, err := c.client.GetVerificationKeys(ctx, &proto.GetVerificationKeysRequest{})
+	if err != nil {
+		return nil, err
+	}
+
+	vks := make([]*session_representations.VerificationKey, 0, len(res.Keys))
+	for _, key := range res.Keys {
+		vk, err := convertVerificationKey(key)
+		if err != nil {
+			return nil, err
+		}
+		vks = append(vks, vk)
+	}
+
+	c.keyring = session_representations.KeyringFromVerificationKeys(vks)
+	c.fetchedAt = time.Now()
+	return c.keyring, nil
+}
+
+// DecodeToken verifies a public token offline and returns its json
+func (c *VerificationKeyCache) DecodeToken(ctx context.Context, token string) (string, error) {
+	keyring, err := c.Keyring(ctx)
+	if err != nil {
+		return "", err
+	}
+
+	json, err := session_representations.DecodeTokenToJson(keyring, token)
+	if err == session_representations.ErrKeyNotFound {
+		keyring, err = c.refreshForUnknownKid(ctx)
+		if err != nil {
+			return "", err
+		}
+		return session_representations.DecodeTokenToJson(keyring, token)
+	}
+	return json, err
+}
+
+func convertVerificationKey(key *proto.VerificationKey) (*session_representations.VerificationKey, error) {
+	publicKey, err := session_representations.ParsePublicKey(key.Algorithm, key.PublicKey)
+	if err != nil {
+		return nil, err
+	}
+
+	vk := &session_representations.VerificationKey{ID: key.Kid, PublicKey: publicKey, Primary: key.Primary}
+	if key.NotBefore != nil {
+		vk.NotBefore, err = ptypes.Timestamp(key.NotBefore)
+		if err != nil {
+			return nil, err
+		}
+	}
+	if key.NotAfter != nil {
+		vk.NotAfter, err = ptypes.Timestamp(key.NotAfter)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return vk, nil
+}
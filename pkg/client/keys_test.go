@@ -0,0 +1,68 @@
+package client
+
+import (
+	"context"
+	"crypto/ed25519"
+	"crypto/rand"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"google.golang.org/grpc"
+
+	"github.com/willschroeder/fingerprint/pkg/proto"
+	"github.com/willschroeder/fingerprint/pkg/session_representations"
+)
+
+// keysClient only answers GetVerificationKeys, counting the calls
+type keysClient struct {
+	proto.FingerprintServiceClient
+	keys  []*proto.VerificationKey
+	calls int32
+}
+
+func (c *keysClient) GetVerificationKeys(context.Context, *proto.GetVerificationKeysRequest, ...grpc.CallOption) (*proto.GetVerificationKeysResponse, error) {
+	atomic.AddInt32(&c.calls, 1)
+	return &proto.GetVerificationKeysResponse{Keys: c.keys}, nil
+}
+
+func TestUnknownKidRefreshesAreLimited(t *testing.T) {
+	_, private, _ := ed25519.GenerateKey(rand.Reader)
+	issuer := session_representations.NewPublicKeyring(0)
+	issuer.AddSigningKey("current", private)
+	public := issuer.VerificationKeys()[0]
+
+	client := &keysClient{keys: []*proto.VerificationKey{{Kid: public.ID, Algorithm: public.Algorithm(), PublicKey: public.EncodedPublicKey()}}}
+	cache := NewVerificationKeyCache(client)
+
+	_, other, _ := ed25519.GenerateKey(rand.Reader)
+	stranger := session_representations.NewPublicKeyring(0)
+	stranger.AddSigningKey("made-up", other)
+	factory := session_representations.NewTokenFactory(stranger, "111", "222")
+	factory.AddScopeGrouping([]string{"read"}, time.Now().Add(time.Hour))
+	session, err := factory.GenerateSession()
+	if err != nil {
+		t.Fatal(err)
+	}
+	token := session.Token
+
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			cache.DecodeToken(context.Background(), token)
+		}()
+	}
+	wg.Wait()
+	if calls := atomic.LoadInt32(&client.calls); calls != 1 {
+		t.Errorf("Expected unknown kids inside the minimum interval to reuse the first fetch, fetched %d times", calls)
+	}
+
+	cache.MinRefreshInterval = 0
+	cache.DecodeToken(context.Background(), token)
+	if calls := atomic.LoadInt32(&client.calls); calls != 2 {
+		t.Errorf("Expected an unknown kid to refresh once the interval passed, fetched %d times", calls)
+	}
+}
@@ -0,0 +1,61 @@
+package client
+
+import (
+	"crypto/tls"
+	"errors"
+
+	"github.com/willschroeder/fingerprint/pkg/certificates"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/credentials/insecure"
+)
+
+// TLSOptions mirror the server's, leave everything empty to dial without TLS
+type TLSOptions struct {
+	// CAFile verifies the server, the system roots are used when it's empty
+	CAFile string
+	// CertFile and KeyFile are presented to servers that check client certificates, they are
+	// read again whenever the files change
+	CertFile string
+	KeyFile  string
+	// ServerName overrides the name checked against the server's certificate
+	ServerName string
+	// Enabled turns TLS on without any files, the server is then verified against the system roots
+	Enabled bool
+}
+
+func (o TLSOptions) enabled() bool {
+	return o.Enabled || o.CAFile != "" || o.CertFile != "" || o.ServerName != ""
+}
+
+// DialOption builds the transport credentials for the options
+func (o TLSOptions) DialOption() (grpc.DialOption, error) {
+	if !o.enabled() {
+		return grpc.WithTransportCredentials(insecure.NewCredentials()), nil
+	}
+	if (o.CertFile == "") != (o.KeyFile == "") {
+		return nil, errors.New("a client certificate needs both a cert and a key file")
+	}
+
+	config := &tls.Config{MinVersion: tls.VersionTLS12, ServerName: o.ServerName}
+	if o.CertFile != "" || o.CAFile != "" {
+		reloader, err := certificates.NewReloader(o.CertFile, o.KeyFile, o.CAFile)
+		if err != nil {
+			return nil, err
+		}
+		config.RootCAs = reloader.CertPool()
+		if o.CertFile != "" {
+			config.GetClientCertificate = reloader.GetClientCertificate
+		}
+	}
+	return grpc.WithTransportCredentials(credentials.NewTLS(config)), nil
+}
+
+// Dial connects to a fingerprint server, opts are passed on to grpc.Dial, e.g. WithAPIKey
+func Dial(address string, options TLSOptions, opts ...grpc.DialOption) (*grpc.ClientConn, error) {
+	transport, err := options.DialOption()
+	if err != nil {
+		return nil, err
+	}
+	return grpc.Dial(address, append([]grpc.DialOption{transport}, opts...)...)
+}
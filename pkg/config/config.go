@@ -0,0 +1,460 @@
+package config
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+	"time"
+
+	"github.com/mitchellh/mapstructure"
+	"github.com/spf13/viper"
+	"github.com/willschroeder/fingerprint/pkg/passwords"
+	"github.com/willschroeder/fingerprint/pkg/schedule"
+)
+
+// EnvPrefix namespaces environment variables, database.host is read from FINGERPRINT_DATABASE_HOST
+const EnvPrefix = "FINGERPRINT"
+
+// redacted replaces secrets in printed configs
+const redacted = "[redacted]"
+
+// Config is everything fingerprint can be configured with. It is read from the config file, then
+// environment variables, then flags, later sources winning. Fields tagged secret are never printed.
+type Config struct {
+	Database  Database  `yaml:"database"`
+	Server    Server    `yaml:"server"`
+	Tokens    Tokens    `yaml:"tokens"`
+	Sessions  Sessions  `yaml:"sessions"`
+	Passwords Passwords `yaml:"passwords"`
+	HTTP      HTTP      `yaml:"http"`
+	TLS       TLS       `yaml:"tls"`
+	Callers   Callers   `yaml:"callers"`
+	Clean     Clean     `yaml:"clean"`
+	Jobs      Jobs      `yaml:"jobs"`
+	Client    Client    `yaml:"client"`
+}
+
+// JobOff is the schedule that leaves a job unscheduled
+const JobOff = "off"
+
+// Database drivers fingerprint can store its data in
+const (
+	DriverPostgres = "postgres"
+	DriverSQLite   = "sqlite"
+)
+
+type Database struct {
+	Driver   string `yaml:"driver"`
+	Path     string `yaml:"path"` // the SQLite file, the other fields are for Postgres
+	Host     string `yaml:"host"`
+	Port     int    `yaml:"port"`
+	User     string `yaml:"user"`
+	Password string `yaml:"password" secret:"true"`
+	Name     string `yaml:"name"`
+	SSLMode  string `yaml:"sslmode"`
+}
+
+type Server struct {
+	GRPCAddress string `yaml:"grpc_address"`
+	HTTPAddress string `yaml:"http_address"`
+}
+
+type Tokens struct {
+	Format string `yaml:"format"`
+	// Keys issue and decode tokens, the primary key issues new ones
+	Keys []TokenKey `yaml:"keys"`
+	// Development issues local tokens with the built in development secret when there are no keys
+	Development bool          `yaml:"development"`
+	ClockSkew   time.Duration `yaml:"clock_skew"`
+}
+
+// TokenKey is one of tokens.keys, it's written into token footers as the kid. Retired keys still decode
+// tokens until RetiredAt and keys only become valid at NotBefore, so rotations can be rolled out by
+// loading the next key ahead of time and keeping the previous one until its tokens have expired.
+type TokenKey struct {
+	ID string `yaml:"id"`
+	// Secret is the base64 32 byte key for local formats
+	Secret string `yaml:"secret" secret:"true"`
+	// SigningKey is a base64 Ed25519 seed or DER ECDSA P-256 key for public formats
+	SigningKey string    `yaml:"signing_key" secret:"true"`
+	Primary    bool      `yaml:"primary"`
+	NotBefore  time.Time `yaml:"not_before"`
+	RetiredAt  time.Time `yaml:"retired_at"`
+}
+
+type Sessions struct {
+	AccessTokenTTL        time.Duration `yaml:"access_token_ttl"`
+	IdleTimeout           time.Duration `yaml:"idle_timeout"`
+	MaxLifetime           time.Duration `yaml:"max_lifetime"`
+	LastSeenFlushInterval time.Duration `yaml:"last_seen_flush_interval"`
+}
+
+// Passwords is how new passwords are hashed
+type Passwords struct {
+	// Algorithm is argon2id, bcrypt or scrypt
+	Algorithm           string `yaml:"algorithm"`
+	BcryptCost          int    `yaml:"bcrypt_cost"`
+	Argon2idMemory      uint32 `yaml:"argon2id_memory"` // KiB
+	Argon2idIterations  uint32 `yaml:"argon2id_iterations"`
+	Argon2idParallelism uint8  `yaml:"argon2id_parallelism"`
+	ScryptLogN          uint8  `yaml:"scrypt_log_n"` // N = 2^ScryptLogN
+}
+
+type HTTP struct {
+	IntrospectionClients map[string]string `yaml:"introspection_clients" secret:"true"`
+	SessionCookie        string            `yaml:"session_cookie"`
+	CORSAllowedOrigins   []string          `yaml:"cors_allowed_origins"`
+}
+
+type TLS struct {
+	Cert              string   `yaml:"cert"`
+	Key               string   `yaml:"key"`
+	ClientCA          string   `yaml:"client_ca"`
+	RequireClientCert bool     `yaml:"require_client_cert"`
+	AdminSubjects     []string `yaml:"admin_subjects"`
+}
+
+type Callers struct {
+	RequireAPIKeys bool          `yaml:"require_api_keys"`
+	CacheTTL       time.Duration `yaml:"cache_ttl"`
+}
+
+// Clean is what `fingerprint clean` removes, and how often serve runs it
+type Clean struct {
+	// Interval schedules the clean job inside serve, 0 leaves it to `fingerprint clean` and RunJob
+	Interval            time.Duration `yaml:"interval"`
+	BatchSize           int           `yaml:"batch_size"`
+	GuestTTL            time.Duration `yaml:"guest_ttl"`
+	ResetTokenTTL       time.Duration `yaml:"reset_token_ttl"`
+	RevocationRetention time.Duration `yaml:"revocation_retention"`
+	JobRunRetention     time.Duration `yaml:"job_run_retention"`
+}
+
+// Jobs is the periodic work serve schedules. Every replica runs the scheduler, a lock in the database
+// decides which one runs each job.
+type Jobs struct {
+	// Schedules sets a job's schedule by name as a cron expression, a descriptor like @hourly or
+	// @every <duration>. off only runs the job when asked to with RunJob. Schedules are in UTC.
+	Schedules map[string]string `yaml:"schedules"`
+}
+
+type Client struct {
+	Address string `yaml:"address"`
+	// TLS verifies the server against the system roots when no CA is given
+	TLS        bool   `yaml:"tls"`
+	CA         string `yaml:"ca"`
+	Cert       string `yaml:"cert"`
+	Key        string `yaml:"key"`
+	ServerName string `yaml:"server_name"`
+	APIKey     string `yaml:"api_key" secret:"true"`
+}
+
+// Default is a local development setup
+func Default() *Config {
+	return &Config{
+		Database: Database{Driver: DriverPostgres, Path: "fingerprint.db", Host: "localhost", Port: 5432, User: "postgres", Password: "postgres", Name: "fingerprint_development", SSLMode: "disable"},
+		Server:   Server{GRPCAddress: ":50051", HTTPAddress: ":8080"},
+		Tokens:   Tokens{Format: "paseto.v2.local", ClockSkew: 30 * time.Second},
+		Sessions: Sessions{LastSeenFlushInterval: 10 * time.Second},
+		Passwords: Passwords{
+			Algorithm:           "argon2id",
+			BcryptCost:          passwords.DefaultBcryptCost,
+			Argon2idMemory:      passwords.DefaultArgon2idParams.Memory,
+			Argon2idIterations:  passwords.DefaultArgon2idParams.Iterations,
+			Argon2idParallelism: passwords.DefaultArgon2idParams.Parallelism,
+			ScryptLogN:          passwords.DefaultScryptParams.LogN,
+		},
+		HTTP:    HTTP{SessionCookie: "fingerprint_session"},
+		Callers: Callers{CacheTTL: 30 * time.Second},
+		Clean:   Clean{BatchSize: 1000, GuestTTL: 30 * 24 * time.Hour, ResetTokenTTL: 24 * time.Hour, RevocationRetention: 30 * 24 * time.Hour, JobRunRetention: 30 * 24 * time.Hour},
+		Client:  Client{Address: "localhost:50051"},
+	}
+}
+
+// SetDefaults registers every key with viper, which is also what lets environment variables reach
+// keys that aren't in the config file
+func SetDefaults(v *viper.Viper) {
+	v.SetEnvPrefix(EnvPrefix)
+	v.SetEnvKeyReplacer(strings.NewReplacer(".", "_"))
+	v.AutomaticEnv()
+
+	walk(Default(), func(key string, _ reflect.StructField, value reflect.Value) {
+		v.SetDefault(key, value.Interface())
+	})
+}
+
+// Read decodes the config without checking it
+func Read(v *viper.Viper) (*Config, error) {
+	c := &Config{}
+	err := v.Unmarshal(c, func(dc *mapstructure.DecoderConfig) {
+		dc.TagName = "yaml"
+		dc.DecodeHook = mapstructure.ComposeDecodeHookFunc(
+			mapstructure.StringToTimeDurationHookFunc(),
+			mapstructure.StringToTimeHookFunc(time.RFC3339),
+			stringToTokenKeysHookFunc(),
+			mapstructure.StringToSliceHookFunc(","),
+			stringToMapHookFunc(),
+		)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+// Validator checks one part of the config, commands only validate the parts they read
+type Validator func(c *Config) error
+
+// Load decodes the config and validates it with validators, or every section when none are given
+func Load(v *viper.Viper, validators ...Validator) (*Config, error) {
+	c, err := Read(v)
+	if err != nil {
+		return nil, err
+	}
+	if len(validators) == 0 {
+		validators = []Validator{(*Config).Validate}
+	}
+	var p problems
+	for _, validate := range validators {
+		err = validate(c)
+		p.check(err == nil, fmt.Sprint(err))
+	}
+	err = p.err()
+	if err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+// stringToTokenKeysHookFunc reads tokens.keys from an environment variable as a JSON list
+func stringToTokenKeysHookFunc() mapstructure.DecodeHookFuncType {
+	return func(from reflect.Type, to reflect.Type, data interface{}) (interface{}, error) {
+		if from.Kind() != reflect.String || to != reflect.TypeOf([]TokenKey{}) {
+			return data, nil
+		}
+		var keys []map[string]interface{}
+		err := json.Unmarshal([]byte(data.(string)), &keys)
+		if err != nil {
+			return nil, fmt.Errorf("tokens.keys must be a JSON list: %v", err)
+		}
+		return keys, nil
+	}
+}
+
+// stringToMapHookFunc reads maps from environment variables as key=value,key=value
+func stringToMapHookFunc() mapstructure.DecodeHookFuncType {
+	return func(from reflect.Type, to reflect.Type, data interface{}) (interface{}, error) {
+		if from.Kind() != reflect.String || to != reflect.TypeOf(map[string]string{}) {
+			return data, nil
+		}
+		m := map[string]string{}
+		raw := strings.Trim(data.(string), "[]")
+		if raw == "" {
+			return m, nil
+		}
+		for _, pair := range strings.Split(raw, ",") {
+			kv := strings.SplitN(pair, "=", 2)
+			if len(kv) != 2 {
+				return nil, fmt.Errorf("%q isn't a key=value pair", pair)
+			}
+			m[kv[0]] = kv[1]
+		}
+		return m, nil
+	}
+}
+
+// problems collects everything wrong with the config so it can be reported at once rather than
+// stopping at the first
+type problems []string
+
+func (p *problems) check(ok bool, problem string) {
+	if !ok {
+		*p = append(*p, problem)
+	}
+}
+
+// durations refuses negative durations anywhere in section
+func (p *problems) durations(prefix string, section interface{}) {
+	walkStruct(prefix+".", reflect.ValueOf(section), func(key string, _ reflect.StructField, value reflect.Value) {
+		if d, ok := value.Interface().(time.Duration); ok {
+			p.check(d >= 0, key+" can't be negative")
+		}
+	})
+}
+
+func (p problems) err() error {
+	if len(p) > 0 {
+		return errors.New(strings.Join(p, "\n"))
+	}
+	return nil
+}
+
+// Validate checks every section
+func (c *Config) Validate() error {
+	var p problems
+	for _, validate := range []Validator{(*Config).ValidateDatabase, (*Config).ValidateServer, (*Config).ValidateTokens, (*Config).ValidatePasswords, (*Config).ValidateClean, (*Config).ValidateClient} {
+		err := validate(c)
+		p.check(err == nil, fmt.Sprint(err))
+	}
+	return p.err()
+}
+
+// ValidateDatabase checks the connection settings every command that touches the database reads
+func (c *Config) ValidateDatabase() error {
+	var p problems
+	switch c.Database.Driver {
+	case DriverPostgres:
+		p.check(c.Database.Host != "", "database.host is required")
+		p.check(c.Database.Port > 0 && c.Database.Port < 65536, "database.port must be between 1 and 65535")
+		p.check(c.Database.Name != "", "database.name is required")
+	case DriverSQLite:
+		p.check(c.Database.Path != "", "database.path is required")
+	default:
+		p.check(false, fmt.Sprintf("database.driver must be %s or %s", DriverPostgres, DriverSQLite))
+	}
+	p.durations("database", c.Database)
+	return p.err()
+}
+
+// ValidateServer checks the listeners and the sections only the server reads
+func (c *Config) ValidateServer() error {
+	var p problems
+	p.check(c.Server.GRPCAddress != "", "server.grpc_address is required")
+	p.check(c.Server.HTTPAddress != "", "server.http_address is required")
+	p.check((c.TLS.Cert == "") == (c.TLS.Key == ""), "tls.cert and tls.key must be set together")
+	p.check(c.TLS.Cert != "" || (c.TLS.ClientCA == "" && len(c.TLS.AdminSubjects) == 0), "tls.client_ca and tls.admin_subjects need tls.cert")
+	p.check(len(c.TLS.AdminSubjects) == 0 || c.TLS.ClientCA != "", "tls.admin_subjects need tls.client_ca to verify them against")
+	p.check(!c.TLS.RequireClientCert || c.TLS.ClientCA != "", "tls.require_client_cert needs tls.client_ca")
+	for name, spec := range c.Jobs.Schedules {
+		if spec != JobOff {
+			_, err := schedule.Parse(spec)
+			p.check(err == nil, fmt.Sprintf("jobs.schedules.%s: %v", name, err))
+		}
+	}
+	p.durations("server", c.Server)
+	p.durations("sessions", c.Sessions)
+	p.durations("http", c.HTTP)
+	p.durations("tls", c.TLS)
+	p.durations("callers", c.Callers)
+	p.durations("jobs", c.Jobs)
+	return p.err()
+}
+
+// ValidateTokens builds the keyring, which is only needed by the server
+func (c *Config) ValidateTokens() error {
+	var p problems
+	_, err := c.Tokens.Keyring()
+	p.check(err == nil, fmt.Sprintf("tokens: %v", err))
+	p.durations("tokens", c.Tokens)
+	return p.err()
+}
+
+// ValidatePasswords builds the password policy, which is only needed by the server
+func (c *Config) ValidatePasswords() error {
+	var p problems
+	_, err := c.Passwords.Policy()
+	p.check(err == nil, fmt.Sprintf("passwords: %v", err))
+	p.durations("passwords", c.Passwords)
+	return p.err()
+}
+
+// ValidateClean checks the cleanup settings shared by the clean command and the server's clean job
+func (c *Config) ValidateClean() error {
+	var p problems
+	p.check(c.Clean.BatchSize > 0, "clean.batch_size must be positive")
+	p.durations("clean", c.Clean)
+	return p.err()
+}
+
+// ValidateClient checks the settings the client command connects with
+func (c *Config) ValidateClient() error {
+	var p problems
+	p.check((c.Client.Cert == "") == (c.Client.Key == ""), "client.cert and client.key must be set together")
+	p.durations("client", c.Client)
+	return p.err()
+}
+
+// Warnings are settings that work but shouldn't be used in production
+func (c *Config) Warnings() []string {
+	var warnings []string
+	if c.Tokens.Development && len(c.Tokens.Keys) == 0 {
+		warnings = append(warnings, "tokens.development is set, tokens are encrypted with the built in development secret")
+	}
+	if c.Database.Driver == DriverPostgres && c.Database.SSLMode == "disable" && c.Database.Host != "localhost" {
+		warnings = append(warnings, "database.sslmode is disable for a remote database")
+	}
+	return warnings
+}
+
+// Settings is the config as nested maps keyed like the config file, with secrets redacted
+func (c *Config) Settings() map[string]interface{} {
+	settings := map[string]interface{}{}
+	walk(c, func(key string, field reflect.StructField, value reflect.Value) {
+		parts := strings.Split(key, ".")
+		section := settings
+		for _, part := range parts[:len(parts)-1] {
+			if _, ok := section[part]; !ok {
+				section[part] = map[string]interface{}{}
+			}
+			section = section[part].(map[string]interface{})
+		}
+		section[parts[len(parts)-1]] = printable(field, value)
+	})
+	return settings
+}
+
+func printable(field reflect.StructField, value reflect.Value) interface{} {
+	if field.Tag.Get("secret") == "true" {
+		if m, ok := value.Interface().(map[string]string); ok {
+			hidden := map[string]string{}
+			for k := range m {
+				hidden[k] = redacted
+			}
+			return hidden
+		}
+		if !value.IsZero() {
+			return redacted
+		}
+	}
+	if d, ok := value.Interface().(time.Duration); ok {
+		return d.String()
+	}
+	if t, ok := value.Interface().(time.Time); ok {
+		if t.IsZero() {
+			return ""
+		}
+		return t.Format(time.RFC3339)
+	}
+	// Lists of sections, like tokens.keys, are printed field by field so their secrets are redacted too
+	if value.Kind() == reflect.Slice && value.Type().Elem().Kind() == reflect.Struct {
+		items := make([]map[string]interface{}, value.Len())
+		for i := range items {
+			item := value.Index(i)
+			items[i] = map[string]interface{}{}
+			for j := 0; j < item.NumField(); j++ {
+				items[i][item.Type().Field(j).Tag.Get("yaml")] = printable(item.Type().Field(j), item.Field(j))
+			}
+		}
+		return items
+	}
+	return value.Interface()
+}
+
+// walk calls fn for every leaf setting with its dotted key
+func walk(c *Config, fn func(key string, field reflect.StructField, value reflect.Value)) {
+	walkStruct("", reflect.ValueOf(c).Elem(), fn)
+}
+
+func walkStruct(prefix string, v reflect.Value, fn func(key string, field reflect.StructField, value reflect.Value)) {
+	for i := 0; i < v.NumField(); i++ {
+		field := v.Type().Field(i)
+		key := prefix + field.Tag.Get("yaml")
+		if field.Type.Kind() == reflect.Struct {
+			walkStruct(key+".", v.Field(i), fn)
+			continue
+		}
+		fn(key, field, v.Field(i))
+	}
+}
@@ -0,0 +1,236 @@
+package config
+
+import (
+	"encoding/base64"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/spf13/pflag"
+	"github.com/spf13/viper"
+	"github.com/willschroeder/fingerprint/pkg/session_representations"
+)
+
+func newViper() *viper.Viper {
+	v := viper.New()
+	SetDefaults(v)
+	return v
+}
+
+func TestDefaultsNeedTokenKeys(t *testing.T) {
+	_, err := Load(newViper())
+	if err == nil || !strings.Contains(err.Error(), "tokens:") {
+		t.Errorf("Expected missing token keys to be reported, got %v", err)
+	}
+
+	v := newViper()
+	v.Set("tokens.development", true)
+	cfg, err := Load(v)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.Server.GRPCAddress != ":50051" || cfg.Tokens.ClockSkew != 30*time.Second {
+		t.Errorf("Unexpected defaults %+v", cfg)
+	}
+	if len(cfg.Warnings()) == 0 {
+		t.Errorf("Expected the development secret to be warned about")
+	}
+}
+
+func TestLoadValidatesOnlyTheSectionsAskedFor(t *testing.T) {
+	v := newViper()
+	v.Set("client.cert", "client.pem")
+	_, err := Load(v, (*Config).ValidateDatabase, (*Config).ValidateClean)
+	if err != nil {
+		t.Errorf("Expected the database and clean sections to be valid without token keys, got %v", err)
+	}
+
+	_, err = Load(v, (*Config).ValidateDatabase, (*Config).ValidateTokens, (*Config).ValidateClient)
+	if err == nil || !strings.Contains(err.Error(), "tokens:") || !strings.Contains(err.Error(), "client.cert") {
+		t.Errorf("Expected problems in every section asked for to be reported, got %v", err)
+	}
+	if strings.Contains(err.Error(), "database") {
+		t.Errorf("Expected only the sections with problems to be reported, got %v", err)
+	}
+}
+
+func TestFileThenEnvironmentThenFlags(t *testing.T) {
+	v := newViper()
+	v.SetConfigType("yaml")
+	err := v.ReadConfig(strings.NewReader("database:\n  host: file.internal\n  port: 6432\nsessions:\n  idle_timeout: 10m\ntokens:\n  development: true\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	os.Setenv("FINGERPRINT_DATABASE_HOST", "env.internal")
+	os.Setenv("FINGERPRINT_HTTP_INTROSPECTION_CLIENTS", "gateway=secret,mesh=other")
+	defer os.Unsetenv("FINGERPRINT_DATABASE_HOST")
+	defer os.Unsetenv("FINGERPRINT_HTTP_INTROSPECTION_CLIENTS")
+
+	flags := pflag.NewFlagSet("serve", pflag.ContinueOnError)
+	flags.Duration("idle-timeout", 0, "")
+	v.BindPFlag("sessions.idle_timeout", flags.Lookup("idle-timeout"))
+	flags.Parse([]string{"--idle-timeout", "20m"})
+
+	cfg, err := Load(v)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.Database.Port != 6432 {
+		t.Errorf("Expected the file's port, got %d", cfg.Database.Port)
+	}
+	if cfg.Database.Host != "env.internal" {
+		t.Errorf("Expected the environment to override the file, got %s", cfg.Database.Host)
+	}
+	if cfg.Sessions.IdleTimeout != 20*time.Minute {
+		t.Errorf("Expected the flag to override the file, got %s", cfg.Sessions.IdleTimeout)
+	}
+	if cfg.HTTP.IntrospectionClients["mesh"] != "other" {
+		t.Errorf("Expected maps to be read from the environment, got %v", cfg.HTTP.IntrospectionClients)
+	}
+}
+
+func TestValidateReportsEveryProblem(t *testing.T) {
+	cfg := Default()
+	cfg.Database.Host = ""
+	cfg.Tokens.Format = "rot13"
+	cfg.Sessions.IdleTimeout = -time.Minute
+	cfg.TLS.AdminSubjects = []string{"billing"}
+	cfg.Jobs.Schedules = map[string]string{"clean": "every tuesday", "warm": JobOff}
+
+	err := cfg.Validate()
+	if err == nil {
+		t.Fatal("Expected the config to be invalid")
+	}
+	for _, problem := range []string{"database.host", "tokens:", "sessions.idle_timeout", "tls.admin_subjects", "jobs.schedules.clean"} {
+		if !strings.Contains(err.Error(), problem) {
+			t.Errorf("Expected %s to be reported, got %v", problem, err)
+		}
+	}
+}
+
+func TestValidateSQLiteNeedsOnlyAPath(t *testing.T) {
+	cfg := Default()
+	cfg.Tokens.Development = true
+	cfg.Database.Driver = DriverSQLite
+	cfg.Database.Host = ""
+	cfg.Database.Name = ""
+	if err := cfg.Validate(); err != nil {
+		t.Errorf("Expected a sqlite config without postgres settings to be valid, got %v", err)
+	}
+
+	cfg.Database.Path = ""
+	err := cfg.Validate()
+	if err == nil || !strings.Contains(err.Error(), "database.path") {
+		t.Errorf("Expected a missing path to be reported, got %v", err)
+	}
+}
+
+func testTokenSecret(fill byte) string {
+	return base64.StdEncoding.EncodeToString([]byte(strings.Repeat(string(fill), 32)))
+}
+
+func TestTokensKeyring(t *testing.T) {
+	retired := time.Now().Add(time.Hour).UTC().Truncate(time.Second)
+	tokens := Default().Tokens
+	tokens.Keys = []TokenKey{
+		{ID: "previous", Secret: testTokenSecret('a'), RetiredAt: retired},
+		{ID: "current", Secret: testTokenSecret('b'), Primary: true},
+		{ID: "next", Secret: testTokenSecret('c'), NotBefore: retired},
+	}
+	keyring, err := tokens.Keyring()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if keyring.Primary() == nil || keyring.Primary().ID != "current" {
+		t.Errorf("Expected the key marked primary to issue tokens, got %+v", keyring.Primary())
+	}
+	previous, err := keyring.Lookup("previous")
+	if err != nil || !previous.RetiredAt.Equal(retired) {
+		t.Errorf("Expected the retired key to decode until it's retired, got %+v %v", previous, err)
+	}
+	_, err = keyring.Lookup("next")
+	if err != session_representations.ErrKeyNotYetValid {
+		t.Errorf("Expected the upcoming key not to decode before its not_before, got %v", err)
+	}
+
+	for problem, keys := range map[string][]TokenKey{
+		"one of the keys must be primary": {{ID: "a", Secret: testTokenSecret('a')}},
+		"only one key can be primary":     {{ID: "a", Secret: testTokenSecret('a'), Primary: true}, {ID: "b", Secret: testTokenSecret('b'), Primary: true}},
+		"used more than once":             {{ID: "a", Secret: testTokenSecret('a'), Primary: true}, {ID: "a", Secret: testTokenSecret('b')}},
+		"can't be retired":                {{ID: "a", Secret: testTokenSecret('a'), Primary: true, RetiredAt: retired}},
+		"isn't valid until":               {{ID: "a", Secret: testTokenSecret('a'), Primary: true, NotBefore: retired}},
+		"secret is required":              {{ID: "a", Primary: true}},
+	} {
+		tokens.Keys = keys
+		_, err = tokens.Keyring()
+		if err == nil || !strings.Contains(err.Error(), problem) {
+			t.Errorf("Expected %q, got %v", problem, err)
+		}
+	}
+
+	tokens.Keys = nil
+	tokens.Development = true
+	tokens.Format = "paseto.v4.public"
+	_, err = tokens.Keyring()
+	if err == nil {
+		t.Errorf("Expected public formats to need a signing key")
+	}
+}
+
+func TestTokenKeysFromEnvironment(t *testing.T) {
+	os.Setenv("FINGERPRINT_TOKENS_KEYS", `[{"id":"current","secret":"`+testTokenSecret('a')+`","primary":true},{"id":"previous","secret":"`+testTokenSecret('b')+`","retired_at":"2030-01-02T03:04:05Z"}]`)
+	defer os.Unsetenv("FINGERPRINT_TOKENS_KEYS")
+
+	cfg, err := Load(newViper())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(cfg.Tokens.Keys) != 2 || !cfg.Tokens.Keys[0].Primary || cfg.Tokens.Keys[1].RetiredAt.Year() != 2030 {
+		t.Errorf("Expected the keys to be read from the environment, got %+v", cfg.Tokens.Keys)
+	}
+
+	keys := cfg.Settings()["tokens"].(map[string]interface{})["keys"].([]map[string]interface{})
+	if keys[0]["id"] != "current" || keys[0]["secret"] != redacted || keys[1]["retired_at"] != "2030-01-02T03:04:05Z" {
+		t.Errorf("Expected key secrets to be redacted, got %v", keys)
+	}
+}
+
+func TestSettingsRedactSecrets(t *testing.T) {
+	cfg := Default()
+	cfg.Client.APIKey = "fpk_secret"
+	cfg.HTTP.IntrospectionClients = map[string]string{"gateway": "secret"}
+
+	settings := cfg.Settings()
+	client := settings["client"].(map[string]interface{})
+	if client["api_key"] != redacted || client["address"] != "localhost:50051" {
+		t.Errorf("Unexpected client settings %v", client)
+	}
+	clients := settings["http"].(map[string]interface{})["introspection_clients"].(map[string]string)
+	if clients["gateway"] != redacted {
+		t.Errorf("Expected introspection secrets to be redacted, got %v", clients)
+	}
+	if settings["sessions"].(map[string]interface{})["last_seen_flush_interval"] != "10s" {
+		t.Errorf("Expected durations to print as strings")
+	}
+}
+
+func TestPasswordsPolicy(t *testing.T) {
+	cfg := Default()
+	cfg.Passwords.Algorithm = "bcrypt"
+	cfg.Passwords.BcryptCost = 4
+	policy, err := cfg.Passwords.Policy()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if policy.Current.Algorithm() != "bcrypt" || len(policy.Legacy) != 2 {
+		t.Errorf("Expected bcrypt to hash new passwords with the others kept for old hashes, got %+v", policy)
+	}
+
+	cfg.Passwords.Algorithm = "md5"
+	err = cfg.Validate()
+	if err == nil || !strings.Contains(err.Error(), "passwords:") {
+		t.Errorf("Expected an unknown algorithm to be reported, got %v", err)
+	}
+}
@@ -0,0 +1,45 @@
+package config
+
+import (
+	"fmt"
+
+	"golang.org/x/crypto/bcrypt"
+
+	"github.com/willschroeder/fingerprint/pkg/passwords"
+)
+
+// Policy builds the password policy, new passwords are hashed with Algorithm and the other algorithms are
+// kept so older hashes still verify. Hashes made with another algorithm or other parameters are replaced
+// the next time their user logs in.
+func (p Passwords) Policy() (*passwords.Policy, error) {
+	if p.BcryptCost < bcrypt.MinCost || p.BcryptCost > bcrypt.MaxCost {
+		return nil, fmt.Errorf("bcrypt_cost must be between %d and %d", bcrypt.MinCost, bcrypt.MaxCost)
+	}
+	if p.Argon2idMemory == 0 || p.Argon2idIterations == 0 || p.Argon2idParallelism == 0 {
+		return nil, fmt.Errorf("argon2id_memory, argon2id_iterations and argon2id_parallelism must be positive")
+	}
+	if p.ScryptLogN < 1 || p.ScryptLogN > 30 {
+		return nil, fmt.Errorf("scrypt_log_n must be between 1 and 30")
+	}
+
+	argon2idParams := passwords.DefaultArgon2idParams
+	argon2idParams.Memory = p.Argon2idMemory
+	argon2idParams.Iterations = p.Argon2idIterations
+	argon2idParams.Parallelism = p.Argon2idParallelism
+	scryptParams := passwords.DefaultScryptParams
+	scryptParams.LogN = p.ScryptLogN
+
+	argon2id := passwords.NewArgon2idHasher(argon2idParams)
+	bcryptHasher := passwords.NewBcryptHasher(p.BcryptCost)
+	scrypt := passwords.NewScryptHasher(scryptParams)
+
+	switch p.Algorithm {
+	case argon2id.Algorithm():
+		return passwords.NewPolicy(argon2id, bcryptHasher, scrypt), nil
+	case bcryptHasher.Algorithm():
+		return passwords.NewPolicy(bcryptHasher, argon2id, scrypt), nil
+	case scrypt.Algorithm():
+		return passwords.NewPolicy(scrypt, argon2id, bcryptHasher), nil
+	}
+	return nil, fmt.Errorf("algorithm must be argon2id, bcrypt or scrypt, not %q", p.Algorithm)
+}
@@ -0,0 +1,135 @@
+package config
+
+import (
+	"crypto"
+	"crypto/ed25519"
+	"crypto/x509"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/willschroeder/fingerprint/pkg/session_representations"
+)
+
+// local formats encrypt with a shared secret, the others sign with a private key
+func (t Tokens) local() bool {
+	codec, err := session_representations.CodecNamed(t.Format)
+	return err == nil && codec.CanIssue(&session_representations.Key{Secret: make([]byte, 32)})
+}
+
+// Keyring builds the keys token factories issue with from Keys. Local formats need a secret for each
+// key and public formats a signing key, the development secret is only used without keys when
+// Development is set.
+func (t Tokens) Keyring() (*session_representations.Keyring, error) {
+	codec, err := session_representations.CodecNamed(t.Format)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(t.Keys) == 0 {
+		if !t.Development {
+			return nil, errors.New("keys are required, set development to use the built in development secret")
+		}
+		if !t.local() {
+			return nil, errors.New("the development secret only works with local formats")
+		}
+		keyring := session_representations.DevelopmentKeyring()
+		keyring.Codec = codec
+		return keyring, nil
+	}
+
+	keyring := session_representations.NewKeyringWithCodec(codec, 0)
+	keyring.Skew = t.ClockSkew
+	var primary string
+	seen := map[string]bool{}
+	for _, key := range t.Keys {
+		if key.ID == "" {
+			return nil, errors.New("every key needs an id")
+		}
+		if seen[key.ID] {
+			return nil, fmt.Errorf("keys.%s: the id is used more than once", key.ID)
+		}
+		seen[key.ID] = true
+		err := t.addKey(keyring, key)
+		if err != nil {
+			return nil, fmt.Errorf("keys.%s: %v", key.ID, err)
+		}
+
+		if !key.NotBefore.IsZero() && !key.RetiredAt.IsZero() && !key.NotBefore.Before(key.RetiredAt) {
+			return nil, fmt.Errorf("keys.%s: not_before must be before retired_at", key.ID)
+		}
+		if key.Primary {
+			if primary != "" {
+				return nil, fmt.Errorf("keys.%s: only one key can be primary, %s already is", key.ID, primary)
+			}
+			if !key.RetiredAt.IsZero() {
+				return nil, fmt.Errorf("keys.%s: the primary key can't be retired", key.ID)
+			}
+			if key.NotBefore.After(time.Now()) {
+				return nil, fmt.Errorf("keys.%s: the primary key isn't valid until %s", key.ID, key.NotBefore.Format(time.RFC3339))
+			}
+			primary = key.ID
+		}
+		keyring.Schedule(key.ID, key.NotBefore, key.RetiredAt)
+	}
+
+	if primary == "" {
+		return nil, errors.New("one of the keys must be primary")
+	}
+	err = keyring.SetPrimary(primary)
+	if err == session_representations.ErrKeyCantIssue {
+		return nil, fmt.Errorf("keys.%s: can't issue %s tokens", primary, codec.Name())
+	}
+	if err != nil {
+		return nil, err
+	}
+	return keyring, nil
+}
+
+// addKey adds a key's secret for local formats, or its signing key for public ones
+func (t Tokens) addKey(keyring *session_representations.Keyring, key TokenKey) error {
+	if t.local() {
+		if key.Secret == "" {
+			return errors.New("secret is required for local formats")
+		}
+		secret, err := base64.StdEncoding.DecodeString(key.Secret)
+		if err != nil {
+			return errors.New("secret must be base64")
+		}
+		return keyring.Add(key.ID, secret)
+	}
+
+	signingKey, err := parseSigningKey(key.SigningKey)
+	if err != nil {
+		return err
+	}
+	return keyring.AddSigningKey(key.ID, signingKey)
+}
+
+// parseSigningKey accepts a base64 32 byte Ed25519 seed, or a base64 PKCS#8 / SEC 1 DER ECDSA P-256 key
+func parseSigningKey(encoded string) (crypto.Signer, error) {
+	if encoded == "" {
+		return nil, errors.New("signing_key is required for public formats")
+	}
+	raw, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil {
+		return nil, err
+	}
+	if len(raw) == ed25519.SeedSize {
+		return ed25519.NewKeyFromSeed(raw), nil
+	}
+
+	if key, err := x509.ParseECPrivateKey(raw); err == nil {
+		return key, nil
+	}
+	key, err := x509.ParsePKCS8PrivateKey(raw)
+	if err != nil {
+		return nil, errors.New("signing_key must be a base64 Ed25519 seed or DER encoded private key")
+	}
+	signer, ok := key.(crypto.Signer)
+	if !ok {
+		return nil, errors.New("signing_key isn't a signing key")
+	}
+	return signer, nil
+}
@@ -3,44 +3,112 @@ package db
 import (
 	"database/sql"
 	"fmt"
-	_ "github.com/lib/pq"
+	"github.com/lib/pq"
+	_ "github.com/mattn/go-sqlite3"
+	"github.com/willschroeder/fingerprint/pkg/config"
+	"os"
 )
 
-const (
-	host     = "localhost"
-	port     = 5432
-	user     = "postgres"
-	password = "postgres"
-	dbname   = "fingerprint_development"
-)
+// duplicateDatabase is the Postgres error code for CREATE DATABASE on a name that's taken
+const duplicateDatabase = "42P04"
 
 type DAO struct {
 	Conn *sql.DB
+	// Driver is the config.Database driver the connection was opened with
+	Driver string
 }
 
-func ConnectToDatabase() *DAO {
-	psqlInfo := fmt.Sprintf("host=%s port=%d user=%s "+
-		"password=%s dbname=%s sslmode=disable",
-		host, port, user, password, dbname)
-
-	conn, err := sql.Open("postgres", psqlInfo)
+func ConnectToDatabase(cfg config.Database) *DAO {
+	conn, err := open(cfg)
 	if err != nil {
 		panic(err)
 	}
+	return &DAO{Conn: conn, Driver: cfg.Driver}
+}
+
+// open connects and pings, so a bad config fails here rather than on the first query
+func open(cfg config.Database) (*sql.DB, error) {
+	var conn *sql.DB
+	var err error
+	switch cfg.Driver {
+	case config.DriverSQLite:
+		// Transactions take the write lock up front so concurrent ones wait for each other instead of
+		// failing to upgrade a read lock, WAL lets reads carry on meanwhile
+		conn, err = sql.Open("sqlite3", fmt.Sprintf("file:%s?_foreign_keys=on&_busy_timeout=5000&_txlock=immediate&_journal_mode=WAL", cfg.Path))
+	default:
+		psqlInfo := fmt.Sprintf("host=%s port=%d user=%s "+
+			"password=%s dbname=%s sslmode=%s",
+			cfg.Host, cfg.Port, cfg.User, cfg.Password, cfg.Name, cfg.SSLMode)
+		conn, err = sql.Open("postgres", psqlInfo)
+	}
+	if err != nil {
+		return nil, err
+	}
 
 	// Ensures Conn
 	err = conn.Ping()
 	if err != nil {
-		panic(err)
+		conn.Close()
+		return nil, err
 	}
-	return &DAO{Conn: conn}
+	return conn, nil
 }
 
-func MigrateUp() {
+// CreateDatabase creates the configured database, reporting false if it already existed. Postgres is
+// reached through its postgres maintenance database, SQLite creates the file.
+func CreateDatabase(cfg config.Database) (bool, error) {
+	if cfg.Driver == config.DriverSQLite {
+		_, err := os.Stat(cfg.Path)
+		if err == nil {
+			return false, nil
+		}
+		conn, err := open(cfg)
+		if err != nil {
+			return false, err
+		}
+		return true, conn.Close()
+	}
+
+	conn, err := open(maintenance(cfg))
+	if err != nil {
+		return false, err
+	}
+	defer conn.Close()
 
+	_, err = conn.Exec("CREATE DATABASE " + pq.QuoteIdentifier(cfg.Name))
+	if pqErr, ok := err.(*pq.Error); ok && pqErr.Code == duplicateDatabase {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	return true, nil
 }
 
-func MigrateDown() {
+// DropDatabase drops the configured database if it exists
+func DropDatabase(cfg config.Database) error {
+	if cfg.Driver == config.DriverSQLite {
+		for _, path := range []string{cfg.Path, cfg.Path + "-wal", cfg.Path + "-shm"} {
+			err := os.Remove(path)
+			if err != nil && !os.IsNotExist(err) {
+				return err
+			}
+		}
+		return nil
+	}
 
+	conn, err := open(maintenance(cfg))
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	_, err = conn.Exec("DROP DATABASE IF EXISTS " + pq.QuoteIdentifier(cfg.Name))
+	return err
 }
 
+// maintenance is cfg pointed at the database Postgres always has, for creating and dropping others
+func maintenance(cfg config.Database) config.Database {
+	cfg.Name = "postgres"
+	return cfg
+}
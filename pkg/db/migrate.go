@@ -0,0 +1,167 @@
+package db
+
+import (
+	"fmt"
+	"github.com/rubenv/sql-migrate"
+	"github.com/willschroeder/fingerprint/migrations"
+	"github.com/willschroeder/fingerprint/pkg/config"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// MigrationStatus is one migration and whether it has been applied
+type MigrationStatus struct {
+	ID        string
+	Applied   bool
+	AppliedAt time.Time
+	// Unknown migrations are recorded as applied but the binary doesn't have them, usually because
+	// a newer version migrated the database
+	Unknown bool
+}
+
+// MigrationDialect is the sql-migrate dialect for the connection
+func (d *DAO) MigrationDialect() string {
+	if d.Driver == config.DriverSQLite {
+		return "sqlite3"
+	}
+	return "postgres"
+}
+
+// migrationSource is the driver's migrations, embedded in the binary. SQLite has its own because the
+// Postgres ones use types it doesn't have.
+func (d *DAO) migrationSource() migrate.MigrationSource {
+	root := "."
+	if d.Driver == config.DriverSQLite {
+		root = "sqlite"
+	}
+	return &migrate.EmbedFileSystemMigrationSource{FileSystem: migrations.FS, Root: root}
+}
+
+// MigrateUp applies every pending migration, returning how many ran
+func (d *DAO) MigrateUp() (int, error) {
+	return migrate.Exec(d.Conn, d.MigrationDialect(), d.migrationSource(), migrate.Up)
+}
+
+// MigrateTo applies or rolls back migrations until version is the latest applied, 0 rolls back
+// everything. It returns how many ran in either direction.
+func (d *DAO) MigrateTo(version int64) (int, error) {
+	statuses, err := d.MigrationStatus()
+	if err != nil {
+		return 0, err
+	}
+
+	known := version == 0
+	current := version == 0
+	newer := 0
+	var unknown []string
+	for _, status := range statuses {
+		id := migrationVersion(status.ID)
+		if id == version && !status.Unknown {
+			known = true
+			current = status.Applied
+		}
+		if id > version && status.Applied {
+			newer++
+			if status.Unknown {
+				unknown = append(unknown, status.ID)
+			}
+		}
+	}
+	if !known {
+		return 0, fmt.Errorf("there is no migration with version %d", version)
+	}
+	// This binary can't roll back migrations it doesn't have, refuse before rolling back any of the others
+	if len(unknown) > 0 {
+		return 0, fmt.Errorf("%s were applied by a newer version, roll them back with that version first", strings.Join(unknown, ", "))
+	}
+
+	rolledBack := 0
+	if newer > 0 {
+		rolledBack, err = d.rollback(statuses, newer)
+		if err != nil {
+			return rolledBack, err
+		}
+	}
+	// The target may never have been applied, e.g. when it was added after a newer migration had already run
+	if current {
+		return rolledBack, nil
+	}
+	applied, err := migrate.ExecVersion(d.Conn, d.MigrationDialect(), d.migrationSource(), migrate.Up, version)
+	return rolledBack + applied, err
+}
+
+// migrationVersion is the number an id starts with, -1 when it doesn't start with one
+func migrationVersion(id string) int64 {
+	digits := strings.IndexFunc(id, func(r rune) bool { return r < '0' || r > '9' })
+	if digits == -1 {
+		digits = len(id)
+	}
+	version, err := strconv.ParseInt(id[:digits], 10, 64)
+	if err != nil {
+		return -1
+	}
+	return version
+}
+
+// Rollback rolls back the latest steps migrations, returning how many were rolled back
+func (d *DAO) Rollback(steps int) (int, error) {
+	if steps < 1 {
+		return 0, fmt.Errorf("steps must be at least 1, got %d", steps)
+	}
+	statuses, err := d.MigrationStatus()
+	if err != nil {
+		return 0, err
+	}
+	return d.rollback(statuses, steps)
+}
+
+// rollback only gives sql-migrate the applied migrations. Before any plan, even a rollback, it applies
+// pending migrations older than the latest applied one, and on the way down it doesn't record them.
+func (d *DAO) rollback(statuses []MigrationStatus, steps int) (int, error) {
+	found, err := d.migrationSource().FindMigrations()
+	if err != nil {
+		return 0, err
+	}
+	applied := map[string]bool{}
+	for _, status := range statuses {
+		applied[status.ID] = status.Applied
+	}
+	source := &migrate.MemoryMigrationSource{}
+	for _, migration := range found {
+		if applied[migration.Id] {
+			source.Migrations = append(source.Migrations, migration)
+		}
+	}
+	return migrate.ExecMax(d.Conn, d.MigrationDialect(), source, migrate.Down, steps)
+}
+
+// MigrationStatus lists every migration in order, followed by any applied ones the binary doesn't know
+func (d *DAO) MigrationStatus() ([]MigrationStatus, error) {
+	found, err := d.migrationSource().FindMigrations()
+	if err != nil {
+		return nil, err
+	}
+	records, err := migrate.GetMigrationRecords(d.Conn, d.MigrationDialect())
+	if err != nil {
+		return nil, err
+	}
+
+	applied := map[string]time.Time{}
+	for _, record := range records {
+		applied[record.Id] = record.AppliedAt
+	}
+
+	var statuses []MigrationStatus
+	for _, migration := range found {
+		appliedAt, ok := applied[migration.Id]
+		statuses = append(statuses, MigrationStatus{ID: migration.Id, Applied: ok, AppliedAt: appliedAt})
+		delete(applied, migration.Id)
+	}
+	for _, record := range records {
+		if _, unknown := applied[record.Id]; unknown {
+			statuses = append(statuses, MigrationStatus{ID: record.Id, Applied: true, AppliedAt: record.AppliedAt, Unknown: true})
+		}
+	}
+	return statuses, nil
+}
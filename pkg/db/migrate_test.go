@@ -0,0 +1,167 @@
+package db
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/willschroeder/fingerprint/pkg/config"
+)
+
+func sqliteDAO(t *testing.T) (*DAO, config.Database) {
+	dir, err := ioutil.TempDir("", "fingerprint")
+	if err != nil {
+		t.Fatal(err)
+	}
+	cfg := config.Database{Driver: config.DriverSQLite, Path: filepath.Join(dir, "test.db")}
+	created, err := CreateDatabase(cfg)
+	if err != nil || !created {
+		t.Fatalf("Expected the database to be created, got %v %v", created, err)
+	}
+	dao := ConnectToDatabase(cfg)
+	t.Cleanup(func() {
+		dao.Conn.Close()
+		os.RemoveAll(dir)
+	})
+	return dao, cfg
+}
+
+func appliedCount(t *testing.T, dao *DAO) int {
+	statuses, err := dao.MigrationStatus()
+	if err != nil {
+		t.Fatal(err)
+	}
+	applied := 0
+	for _, status := range statuses {
+		if status.Applied {
+			applied++
+		}
+	}
+	return applied
+}
+
+func TestMigrationsAreEmbedded(t *testing.T) {
+	dao, _ := sqliteDAO(t)
+	statuses, err := dao.MigrationStatus()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(statuses) == 0 || statuses[0].ID != "1543125604_create_users_table.sql" || statuses[0].Applied {
+		t.Errorf("Expected the first migration to be pending, got %+v", statuses)
+	}
+
+	n, err := dao.MigrateUp()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n != len(statuses) || appliedCount(t, dao) != n {
+		t.Errorf("Expected all %d migrations to be applied, ran %d", len(statuses), n)
+	}
+}
+
+func TestMigrateToAndRollback(t *testing.T) {
+	dao, _ := sqliteDAO(t)
+
+	n, err := dao.MigrateTo(1543387200)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n != 2 || appliedCount(t, dao) != 2 {
+		t.Errorf("Expected to migrate up to the second migration, ran %d", n)
+	}
+
+	n, err = dao.MigrateTo(1543387200)
+	if err != nil || n != 0 {
+		t.Errorf("Expected migrating to the current version to do nothing, ran %d %v", n, err)
+	}
+
+	_, err = dao.MigrateUp()
+	if err != nil {
+		t.Fatal(err)
+	}
+	n, err = dao.MigrateTo(1543125604)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if appliedCount(t, dao) != 1 {
+		t.Errorf("Expected newer migrations to be rolled back, %d were", n)
+	}
+
+	n, err = dao.Rollback(1)
+	if err != nil || n != 1 || appliedCount(t, dao) != 0 {
+		t.Errorf("Expected the last migration to be rolled back, got %d %v", n, err)
+	}
+
+	_, err = dao.MigrateTo(42)
+	if err == nil {
+		t.Errorf("Expected an unknown version to be refused")
+	}
+}
+
+func TestMigrateToAppliesATargetBehindNewerMigrations(t *testing.T) {
+	dao, _ := sqliteDAO(t)
+	_, err := dao.MigrateUp()
+	if err != nil {
+		t.Fatal(err)
+	}
+	// The password reset migration is pending while the newer job runs migration is applied
+	_, err = dao.Conn.Exec("ALTER TABLE users DROP COLUMN password_reset_requested_at")
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = dao.Conn.Exec("DELETE FROM gorp_migrations WHERE id = '1543732800_add_password_reset_requested_at.sql'")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	n, err := dao.MigrateTo(1543732800)
+	if err != nil {
+		t.Fatal(err)
+	}
+	statuses, err := dao.MigrationStatus()
+	if err != nil {
+		t.Fatal(err)
+	}
+	last := statuses[len(statuses)-1]
+	target := statuses[len(statuses)-2]
+	if n != 2 || !target.Applied || last.Applied {
+		t.Errorf("Expected the newer migration to be rolled back and the target applied, ran %d and got %+v", n, statuses)
+	}
+}
+
+func TestMigrateToRefusesUnknownNewerMigrations(t *testing.T) {
+	dao, _ := sqliteDAO(t)
+	_, err := dao.MigrateUp()
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = dao.Conn.Exec("INSERT INTO gorp_migrations (id, applied_at) VALUES ('1543900000_from_a_newer_version.sql', CURRENT_TIMESTAMP)")
+	if err != nil {
+		t.Fatal(err)
+	}
+	applied := appliedCount(t, dao)
+
+	n, err := dao.MigrateTo(1543125604)
+	if err == nil || !strings.Contains(err.Error(), "1543900000_from_a_newer_version.sql") {
+		t.Errorf("Expected the unknown migration to be reported, got %v", err)
+	}
+	if n != 0 || appliedCount(t, dao) != applied {
+		t.Errorf("Expected nothing to be rolled back, %d were", n)
+	}
+}
+
+func TestDropDatabase(t *testing.T) {
+	dao, cfg := sqliteDAO(t)
+	dao.Conn.Close()
+
+	err := DropDatabase(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = os.Stat(cfg.Path)
+	if !os.IsNotExist(err) {
+		t.Errorf("Expected the database file to be removed, got %v", err)
+	}
+}
@@ -0,0 +1,165 @@
+package domain_errors
+
+import (
+	"errors"
+	"fmt"
+
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// Domain is reported in the google.rpc.ErrorInfo detail attached to every status
+const Domain = "fingerprint"
+
+type Kind int
+
+const (
+	KindInternal Kind = iota
+	KindNotFound
+	KindAlreadyExists
+	KindInvalidArgument
+	KindInvalidCredentials
+	KindPasswordMismatch
+	KindInvalidToken
+	KindExpiredToken
+	KindPermissionDenied
+	KindAborted
+)
+
+// Machine readable reasons, callers should branch on these rather than the message
+const (
+	ReasonInternal              = "INTERNAL"
+	ReasonUserNotFound          = "USER_NOT_FOUND"
+	ReasonSessionNotFound       = "SESSION_NOT_FOUND"
+	ReasonSessionRevokeNotFound = "SESSION_REVOKE_NOT_FOUND"
+	ReasonEmailTaken            = "EMAIL_TAKEN"
+	ReasonInvalidArgument       = "INVALID_ARGUMENT"
+	ReasonInvalidCredentials    = "INVALID_CREDENTIALS"
+	ReasonPasswordMismatch      = "PASSWORD_MISMATCH"
+	ReasonInvalidResetToken     = "INVALID_RESET_TOKEN"
+	ReasonInvalidToken          = "INVALID_TOKEN"
+	ReasonExpiredToken          = "EXPIRED_TOKEN"
+	ReasonInvalidRefreshToken   = "INVALID_REFRESH_TOKEN"
+	ReasonRefreshTokenReused    = "REFRESH_TOKEN_REUSED"
+	ReasonCallerNotAllowed      = "CALLER_NOT_ALLOWED"
+	ReasonCallerNameTaken       = "CALLER_NAME_TAKEN"
+	ReasonCallerNotFound        = "CALLER_NOT_FOUND"
+	ReasonInvalidAPIKey         = "INVALID_API_KEY"
+	ReasonScopeNotAllowed       = "SCOPE_NOT_ALLOWED"
+	ReasonJobNotFound           = "JOB_NOT_FOUND"
+	ReasonJobRunning            = "JOB_RUNNING"
+	ReasonJobRunExists          = "JOB_RUN_EXISTS"
+)
+
+type Error struct {
+	Kind    Kind
+	Reason  string
+	Message string
+	Err     error
+}
+
+func (e *Error) Error() string {
+	if e.Err != nil {
+		return fmt.Sprintf("%s: %v", e.Message, e.Err)
+	}
+	return e.Message
+}
+
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+// GRPCStatus lets grpc and status.FromError convert the error without any help from the handler
+func (e *Error) GRPCStatus() *status.Status {
+	message := e.Message
+	if e.Kind == KindInternal {
+		// Never leak the cause of an internal failure to a caller
+		message = "internal error"
+	}
+
+	st := status.New(e.Kind.Code(), message)
+	detailed, err := st.WithDetails(&errdetails.ErrorInfo{Reason: e.Reason, Domain: Domain})
+	if err != nil {
+		return st
+	}
+	return detailed
+}
+
+func (k Kind) Code() codes.Code {
+	switch k {
+	case KindNotFound:
+		return codes.NotFound
+	case KindAlreadyExists:
+		return codes.AlreadyExists
+	case KindInvalidArgument, KindPasswordMismatch:
+		return codes.InvalidArgument
+	case KindInvalidCredentials, KindInvalidToken, KindExpiredToken:
+		return codes.Unauthenticated
+	case KindPermissionDenied:
+		return codes.PermissionDenied
+	case KindAborted:
+		return codes.Aborted
+	}
+	return codes.Internal
+}
+
+func NotFound(reason string, message string) *Error {
+	return &Error{Kind: KindNotFound, Reason: reason, Message: message}
+}
+
+func AlreadyExists(reason string, message string) *Error {
+	return &Error{Kind: KindAlreadyExists, Reason: reason, Message: message}
+}
+
+func InvalidArgument(message string) *Error {
+	return &Error{Kind: KindInvalidArgument, Reason: ReasonInvalidArgument, Message: message}
+}
+
+func InvalidCredentials() *Error {
+	return &Error{Kind: KindInvalidCredentials, Reason: ReasonInvalidCredentials, Message: "incorrect email or password"}
+}
+
+func PasswordMismatch() *Error {
+	return &Error{Kind: KindPasswordMismatch, Reason: ReasonPasswordMismatch, Message: "password and confirmation don't match"}
+}
+
+func InvalidToken(reason string, message string) *Error {
+	return &Error{Kind: KindInvalidToken, Reason: reason, Message: message}
+}
+
+func ExpiredToken(message string) *Error {
+	return &Error{Kind: KindExpiredToken, Reason: ReasonExpiredToken, Message: message}
+}
+
+func PermissionDenied(reason string, message string) *Error {
+	return &Error{Kind: KindPermissionDenied, Reason: reason, Message: message}
+}
+
+// Aborted is for work that couldn't go ahead because of something else in flight, retrying later can succeed
+func Aborted(reason string, message string) *Error {
+	return &Error{Kind: KindAborted, Reason: reason, Message: message}
+}
+
+// Internal wraps an unexpected failure, the cause is kept for logging but not sent to callers
+func Internal(err error) *Error {
+	return &Error{Kind: KindInternal, Reason: ReasonInternal, Message: "internal error", Err: err}
+}
+
+// From returns err as a domain error, anything that isn't one already is treated as internal
+func From(err error) *Error {
+	var de *Error
+	if errors.As(err, &de) {
+		return de
+	}
+	return Internal(err)
+}
+
+func KindOf(err error) Kind {
+	return From(err).Kind
+}
+
+func Is(err error, kind Kind) bool {
+	var de *Error
+	return errors.As(err, &de) && de.Kind == kind
+}
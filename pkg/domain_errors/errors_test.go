@@ -0,0 +1,59 @@
+package domain_errors
+
+import (
+	"errors"
+	"fmt"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"testing"
+)
+
+func TestKindCodes(t *testing.T) {
+	cases := map[*Error]codes.Code{
+		NotFound(ReasonUserNotFound, "user not found"): codes.NotFound,
+		AlreadyExists(ReasonEmailTaken, "email taken"): codes.AlreadyExists,
+		InvalidCredentials():                           codes.Unauthenticated,
+		PasswordMismatch():                             codes.InvalidArgument,
+		ExpiredToken("token expired"):                  codes.Unauthenticated,
+		InvalidToken(ReasonInvalidToken, "bad token"):  codes.Unauthenticated,
+		Internal(errors.New("connection refused")):     codes.Internal,
+		PermissionDenied(ReasonCallerNotAllowed, "no"): codes.PermissionDenied,
+		Aborted(ReasonJobRunning, "job running"):       codes.Aborted,
+	}
+
+	for err, code := range cases {
+		if got := status.Code(err); got != code {
+			t.Errorf("%s mapped to %s, expected %s", err.Reason, got, code)
+		}
+	}
+}
+
+func TestStatusCarriesReason(t *testing.T) {
+	st, _ := status.FromError(NotFound(ReasonUserNotFound, "user not found"))
+	if len(st.Details()) != 1 {
+		t.Fatalf("Expected one detail, got %d", len(st.Details()))
+	}
+	info, ok := st.Details()[0].(*errdetails.ErrorInfo)
+	if !ok || info.Reason != ReasonUserNotFound || info.Domain != Domain {
+		t.Errorf("Status missing error info, got %v", st.Details()[0])
+	}
+}
+
+func TestInternalHidesCause(t *testing.T) {
+	st := Internal(errors.New("password=hunter2")).GRPCStatus()
+	if st.Message() != "internal error" {
+		t.Errorf("Internal error leaked its cause: %s", st.Message())
+	}
+}
+
+func TestFromWrapsUnknownErrors(t *testing.T) {
+	if KindOf(errors.New("boom")) != KindInternal {
+		t.Errorf("Unknown errors should be internal")
+	}
+
+	wrapped := fmt.Errorf("loading user: %w", NotFound(ReasonUserNotFound, "user not found"))
+	if !Is(wrapped, KindNotFound) {
+		t.Errorf("Wrapped domain errors should keep their kind")
+	}
+}
@@ -0,0 +1,102 @@
+package passwords
+
+import (
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strings"
+
+	"golang.org/x/crypto/argon2"
+)
+
+type Argon2idParams struct {
+	Memory      uint32 // KiB
+	Iterations  uint32
+	Parallelism uint8
+	SaltLength  uint32
+	KeyLength   uint32
+}
+
+// DefaultArgon2idParams follows the second recommended option of RFC 9106
+var DefaultArgon2idParams = Argon2idParams{Memory: 64 * 1024, Iterations: 3, Parallelism: 4, SaltLength: 16, KeyLength: 32}
+
+const argon2idPrefix = "$argon2id$"
+
+// Argon2idHasher encodes hashes in the PHC string format, $argon2id$v=19$m=65536,t=3,p=4$salt$key
+type Argon2idHasher struct {
+	Params Argon2idParams
+}
+
+func NewArgon2idHasher(params Argon2idParams) *Argon2idHasher {
+	return &Argon2idHasher{Params: params}
+}
+
+func (h *Argon2idHasher) Algorithm() string {
+	return "argon2id"
+}
+
+func (h *Argon2idHasher) Hash(password string) (string, error) {
+	salt, err := randomSalt(h.Params.SaltLength)
+	if err != nil {
+		return "", err
+	}
+
+	p := h.Params
+	key := argon2.IDKey([]byte(password), salt, p.Iterations, p.Memory, p.Parallelism, p.KeyLength)
+
+	return fmt.Sprintf("%sv=%d$m=%d,t=%d,p=%d$%s$%s", argon2idPrefix, argon2.Version, p.Memory, p.Iterations, p.Parallelism,
+		base64.RawStdEncoding.EncodeToString(salt), base64.RawStdEncoding.EncodeToString(key)), nil
+}
+
+func (h *Argon2idHasher) Verify(encoded string, password string) (bool, error) {
+	p, salt, key, err := decodeArgon2id(encoded)
+	if err != nil {
+		return false, err
+	}
+
+	other := argon2.IDKey([]byte(password), salt, p.Iterations, p.Memory, p.Parallelism, p.KeyLength)
+	return equal(key, other), nil
+}
+
+func (h *Argon2idHasher) Identifies(encoded string) bool {
+	return strings.HasPrefix(encoded, argon2idPrefix)
+}
+
+func (h *Argon2idHasher) NeedsRehash(encoded string) bool {
+	p, _, _, err := decodeArgon2id(encoded)
+	return err != nil || p != h.Params
+}
+
+func decodeArgon2id(encoded string) (p Argon2idParams, salt []byte, key []byte, err error) {
+	parts := strings.Split(encoded, "$")
+	if len(parts) != 6 || parts[1] != "argon2id" {
+		return p, nil, nil, errors.New("malformed argon2id hash")
+	}
+
+	var version int
+	_, err = fmt.Sscanf(parts[2], "v=%d", &version)
+	if err != nil {
+		return p, nil, nil, err
+	}
+	if version != argon2.Version {
+		return p, nil, nil, fmt.Errorf("unsupported argon2 version %d", version)
+	}
+
+	_, err = fmt.Sscanf(parts[3], "m=%d,t=%d,p=%d", &p.Memory, &p.Iterations, &p.Parallelism)
+	if err != nil {
+		return p, nil, nil, err
+	}
+
+	salt, err = base64.RawStdEncoding.DecodeString(parts[4])
+	if err != nil {
+		return p, nil, nil, err
+	}
+	key, err = base64.RawStdEncoding.DecodeString(parts[5])
+	if err != nil {
+		return p, nil, nil, err
+	}
+
+	p.SaltLength = uint32(len(salt))
+	p.KeyLength = uint32(len(key))
+	return p, salt, key, nil
+}
@@ -0,0 +1,82 @@
+package passwords
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"errors"
+)
+
+// ErrUnknownAlgorithm is returned when a stored hash wasn't produced by any hasher the policy knows about
+var ErrUnknownAlgorithm = errors.New("password hash uses an unknown algorithm")
+
+// PasswordHasher hashes passwords into self describing strings that carry the algorithm and its parameters
+type PasswordHasher interface {
+	Algorithm() string
+	Hash(password string) (string, error)
+	Verify(encoded string, password string) (bool, error)
+	// Identifies reports if encoded was produced by this algorithm
+	Identifies(encoded string) bool
+	// NeedsRehash reports if encoded was produced with different parameters than the hasher is configured with
+	NeedsRehash(encoded string) bool
+}
+
+// Policy hashes new passwords with Current, and can still verify hashes made by any of the Legacy hashers
+type Policy struct {
+	Current PasswordHasher
+	Legacy  []PasswordHasher
+}
+
+func NewPolicy(current PasswordHasher, legacy ...PasswordHasher) *Policy {
+	return &Policy{Current: current, Legacy: legacy}
+}
+
+// DefaultPolicy hashes with argon2id, keeping bcrypt and scrypt around so older hashes still verify
+func DefaultPolicy() *Policy {
+	return NewPolicy(NewArgon2idHasher(DefaultArgon2idParams), NewBcryptHasher(DefaultBcryptCost), NewScryptHasher(DefaultScryptParams))
+}
+
+func (p *Policy) Hash(password string) (string, error) {
+	return p.Current.Hash(password)
+}
+
+// Verify checks password against encoded, rehash is true when the password matched but the
+// stored hash should be replaced with one from the current hasher
+func (p *Policy) Verify(encoded string, password string) (ok bool, rehash bool, err error) {
+	hasher := p.hasherFor(encoded)
+	if hasher == nil {
+		return false, false, ErrUnknownAlgorithm
+	}
+
+	ok, err = hasher.Verify(encoded, password)
+	if err != nil || !ok {
+		return false, false, err
+	}
+
+	rehash = hasher != p.Current || p.Current.NeedsRehash(encoded)
+	return true, rehash, nil
+}
+
+func (p *Policy) hasherFor(encoded string) PasswordHasher {
+	if p.Current.Identifies(encoded) {
+		return p.Current
+	}
+	for _, h := range p.Legacy {
+		if h.Identifies(encoded) {
+			return h
+		}
+	}
+	return nil
+}
+
+func randomSalt(length uint32) ([]byte, error) {
+	salt := make([]byte, length)
+	_, err := rand.Read(salt)
+	if err != nil {
+		return nil, err
+	}
+	return salt, nil
+}
+
+func equal(a []byte, b []byte) bool {
+	return subtle.ConstantTimeCompare(a, b) == 1
+}
@@ -0,0 +1,86 @@
+package passwords
+
+import (
+	"golang.org/x/crypto/bcrypt"
+	"testing"
+)
+
+var fastArgon2id = Argon2idParams{Memory: 1024, Iterations: 1, Parallelism: 1, SaltLength: 16, KeyLength: 32}
+var fastScrypt = ScryptParams{LogN: 4, R: 8, P: 1, SaltLength: 16, KeyLength: 32}
+
+func testHashers() []PasswordHasher {
+	return []PasswordHasher{
+		NewBcryptHasher(bcrypt.MinCost),
+		NewScryptHasher(fastScrypt),
+		NewArgon2idHasher(fastArgon2id),
+	}
+}
+
+func TestHashAndVerify(t *testing.T) {
+	for _, h := range testHashers() {
+		encoded, err := h.Hash("correct horse")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !h.Identifies(encoded) {
+			t.Errorf("%s does not identify its own hash %s", h.Algorithm(), encoded)
+		}
+
+		ok, err := h.Verify(encoded, "correct horse")
+		if err != nil || !ok {
+			t.Errorf("%s failed to verify the correct password: %v", h.Algorithm(), err)
+		}
+		ok, err = h.Verify(encoded, "battery staple")
+		if err != nil || ok {
+			t.Errorf("%s verified the wrong password: %v", h.Algorithm(), err)
+		}
+		if h.NeedsRehash(encoded) {
+			t.Errorf("%s wants to rehash a hash with its own parameters", h.Algorithm())
+		}
+	}
+}
+
+func TestPolicyRehashesLegacyAlgorithm(t *testing.T) {
+	legacy := NewBcryptHasher(bcrypt.MinCost)
+	encoded, _ := legacy.Hash("correct horse")
+
+	policy := NewPolicy(NewArgon2idHasher(fastArgon2id), legacy)
+	ok, rehash, err := policy.Verify(encoded, "correct horse")
+	if err != nil || !ok {
+		t.Fatalf("Policy failed to verify legacy hash: %v", err)
+	}
+	if !rehash {
+		t.Errorf("Expected a rehash when the stored algorithm isn't the current one")
+	}
+
+	_, rehash, _ = policy.Verify(encoded, "battery staple")
+	if rehash {
+		t.Errorf("Should never rehash on a wrong password")
+	}
+}
+
+func TestPolicyRehashesChangedParameters(t *testing.T) {
+	encoded, _ := NewArgon2idHasher(fastArgon2id).Hash("correct horse")
+
+	stronger := fastArgon2id
+	stronger.Iterations = 2
+	policy := NewPolicy(NewArgon2idHasher(stronger))
+	ok, rehash, _ := policy.Verify(encoded, "correct horse")
+	if !ok || !rehash {
+		t.Errorf("Expected a rehash after the argon2id parameters changed")
+	}
+
+	bcryptHash, _ := NewBcryptHasher(bcrypt.MinCost).Hash("correct horse")
+	policy = NewPolicy(NewBcryptHasher(bcrypt.MinCost + 1))
+	ok, rehash, _ = policy.Verify(bcryptHash, "correct horse")
+	if !ok || !rehash {
+		t.Errorf("Expected a rehash after the bcrypt cost changed")
+	}
+}
+
+func TestPolicyUnknownAlgorithm(t *testing.T) {
+	_, _, err := DefaultPolicy().Verify("plaintext", "plaintext")
+	if err != ErrUnknownAlgorithm {
+		t.Errorf("Expected ErrUnknownAlgorithm, got %v", err)
+	}
+}
@@ -0,0 +1,98 @@
+package passwords
+
+import (
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strings"
+
+	"golang.org/x/crypto/scrypt"
+)
+
+type ScryptParams struct {
+	LogN       uint8 // N = 2^LogN
+	R          int
+	P          int
+	SaltLength uint32
+	KeyLength  uint32
+}
+
+var DefaultScryptParams = ScryptParams{LogN: 15, R: 8, P: 1, SaltLength: 16, KeyLength: 32}
+
+const scryptPrefix = "$scrypt$"
+
+// ScryptHasher encodes hashes as $scrypt$ln=15,r=8,p=1$salt$key
+type ScryptHasher struct {
+	Params ScryptParams
+}
+
+func NewScryptHasher(params ScryptParams) *ScryptHasher {
+	return &ScryptHasher{Params: params}
+}
+
+func (h *ScryptHasher) Algorithm() string {
+	return "scrypt"
+}
+
+func (h *ScryptHasher) Hash(password string) (string, error) {
+	salt, err := randomSalt(h.Params.SaltLength)
+	if err != nil {
+		return "", err
+	}
+
+	p := h.Params
+	key, err := scrypt.Key([]byte(password), salt, 1<<p.LogN, p.R, p.P, int(p.KeyLength))
+	if err != nil {
+		return "", err
+	}
+
+	return fmt.Sprintf("%sln=%d,r=%d,p=%d$%s$%s", scryptPrefix, p.LogN, p.R, p.P,
+		base64.RawStdEncoding.EncodeToString(salt), base64.RawStdEncoding.EncodeToString(key)), nil
+}
+
+func (h *ScryptHasher) Verify(encoded string, password string) (bool, error) {
+	p, salt, key, err := decodeScrypt(encoded)
+	if err != nil {
+		return false, err
+	}
+
+	other, err := scrypt.Key([]byte(password), salt, 1<<p.LogN, p.R, p.P, int(p.KeyLength))
+	if err != nil {
+		return false, err
+	}
+	return equal(key, other), nil
+}
+
+func (h *ScryptHasher) Identifies(encoded string) bool {
+	return strings.HasPrefix(encoded, scryptPrefix)
+}
+
+func (h *ScryptHasher) NeedsRehash(encoded string) bool {
+	p, _, _, err := decodeScrypt(encoded)
+	return err != nil || p != h.Params
+}
+
+func decodeScrypt(encoded string) (p ScryptParams, salt []byte, key []byte, err error) {
+	parts := strings.Split(encoded, "$")
+	if len(parts) != 5 || parts[1] != "scrypt" {
+		return p, nil, nil, errors.New("malformed scrypt hash")
+	}
+
+	_, err = fmt.Sscanf(parts[2], "ln=%d,r=%d,p=%d", &p.LogN, &p.R, &p.P)
+	if err != nil {
+		return p, nil, nil, err
+	}
+
+	salt, err = base64.RawStdEncoding.DecodeString(parts[3])
+	if err != nil {
+		return p, nil, nil, err
+	}
+	key, err = base64.RawStdEncoding.DecodeString(parts[4])
+	if err != nil {
+		return p, nil, nil, err
+	}
+
+	p.SaltLength = uint32(len(salt))
+	p.KeyLength = uint32(len(key))
+	return p, salt, key, nil
+}
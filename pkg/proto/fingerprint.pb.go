@@ -7,8 +7,12 @@ import (
 	context "context"
 	fmt "fmt"
 	proto "github.com/golang/protobuf/proto"
+	duration "github.com/golang/protobuf/ptypes/duration"
 	timestamp "github.com/golang/protobuf/ptypes/timestamp"
+	_ "google.golang.org/genproto/googleapis/api/annotations"
 	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
 	math "math"
 )
 
@@ -21,7 +25,7 @@ var _ = math.Inf
 // is compatible with the proto package it is being compiled against.
 // A compilation error at this line likely means your copy of the
 // proto package needs to be updated.
-const _ = proto.ProtoPackageIsVersion2 // please upgrade the proto package
+const _ = proto.ProtoPackageIsVersion3 // please upgrade the proto package
 
 type ResetUserPasswordResponse_Status int32
 
@@ -51,6 +55,64 @@ func (ResetUserPasswordResponse_Status) EnumDescriptor() ([]byte, []int) {
 	return fileDescriptor_958480b1a11f31b5, []int{11, 0}
 }
 
+type AuthorizeResponse_Decision int32
+
+const (
+	AuthorizeResponse_ALLOW AuthorizeResponse_Decision = 0
+	AuthorizeResponse_DENY  AuthorizeResponse_Decision = 1
+)
+
+var AuthorizeResponse_Decision_name = map[int32]string{
+	0: "ALLOW",
+	1: "DENY",
+}
+
+var AuthorizeResponse_Decision_value = map[string]int32{
+	"ALLOW": 0,
+	"DENY":  1,
+}
+
+func (x AuthorizeResponse_Decision) String() string {
+	return proto.EnumName(AuthorizeResponse_Decision_name, int32(x))
+}
+
+func (AuthorizeResponse_Decision) EnumDescriptor() ([]byte, []int) {
+	return fileDescriptor_958480b1a11f31b5, []int{21, 0}
+}
+
+type AuthorizeResponse_DenyReason int32
+
+const (
+	AuthorizeResponse_NONE AuthorizeResponse_DenyReason = 0
+	// No live grouping holds every required scope
+	AuthorizeResponse_MISSING_SCOPES AuthorizeResponse_DenyReason = 1
+	// Only an expired grouping held the scopes, the customer should log in again
+	AuthorizeResponse_REAUTHENTICATION_REQUIRED AuthorizeResponse_DenyReason = 2
+	AuthorizeResponse_SESSION_REVOKED           AuthorizeResponse_DenyReason = 3
+)
+
+var AuthorizeResponse_DenyReason_name = map[int32]string{
+	0: "NONE",
+	1: "MISSING_SCOPES",
+	2: "REAUTHENTICATION_REQUIRED",
+	3: "SESSION_REVOKED",
+}
+
+var AuthorizeResponse_DenyReason_value = map[string]int32{
+	"NONE":                      0,
+	"MISSING_SCOPES":            1,
+	"REAUTHENTICATION_REQUIRED": 2,
+	"SESSION_REVOKED":           3,
+}
+
+func (x AuthorizeResponse_DenyReason) String() string {
+	return proto.EnumName(AuthorizeResponse_DenyReason_name, int32(x))
+}
+
+func (AuthorizeResponse_DenyReason) EnumDescriptor() ([]byte, []int) {
+	return fileDescriptor_958480b1a11f31b5, []int{21, 1}
+}
+
 type GetUserRequest struct {
 	// Types that are valid to be assigned to Identifier:
 	//	*GetUserRequest_Uuid
@@ -123,72 +185,14 @@ func (m *GetUserRequest) GetEmail() string {
 	return ""
 }
 
-// XXX_OneofFuncs is for the internal use of the proto package.
-func (*GetUserRequest) XXX_OneofFuncs() (func(msg proto.Message, b *proto.Buffer) error, func(msg proto.Message, tag, wire int, b *proto.Buffer) (bool, error), func(msg proto.Message) (n int), []interface{}) {
-	return _GetUserRequest_OneofMarshaler, _GetUserRequest_OneofUnmarshaler, _GetUserRequest_OneofSizer, []interface{}{
+// XXX_OneofWrappers is for the internal use of the proto package.
+func (*GetUserRequest) XXX_OneofWrappers() []interface{} {
+	return []interface{}{
 		(*GetUserRequest_Uuid)(nil),
 		(*GetUserRequest_Email)(nil),
 	}
 }
 
-func _GetUserRequest_OneofMarshaler(msg proto.Message, b *proto.Buffer) error {
-	m := msg.(*GetUserRequest)
-	// identifier
-	switch x := m.Identifier.(type) {
-	case *GetUserRequest_Uuid:
-		b.EncodeVarint(1<<3 | proto.WireBytes)
-		b.EncodeStringBytes(x.Uuid)
-	case *GetUserRequest_Email:
-		b.EncodeVarint(2<<3 | proto.WireBytes)
-		b.EncodeStringBytes(x.Email)
-	case nil:
-	default:
-		return fmt.Errorf("GetUserRequest.Identifier has unexpected type %T", x)
-	}
-	return nil
-}
-
-func _GetUserRequest_OneofUnmarshaler(msg proto.Message, tag, wire int, b *proto.Buffer) (bool, error) {
-	m := msg.(*GetUserRequest)
-	switch tag {
-	case 1: // identifier.uuid
-		if wire != proto.WireBytes {
-			return true, proto.ErrInternalBadWireType
-		}
-		x, err := b.DecodeStringBytes()
-		m.Identifier = &GetUserRequest_Uuid{x}
-		return true, err
-	case 2: // identifier.email
-		if wire != proto.WireBytes {
-			return true, proto.ErrInternalBadWireType
-		}
-		x, err := b.DecodeStringBytes()
-		m.Identifier = &GetUserRequest_Email{x}
-		return true, err
-	default:
-		return false, nil
-	}
-}
-
-func _GetUserRequest_OneofSizer(msg proto.Message) (n int) {
-	m := msg.(*GetUserRequest)
-	// identifier
-	switch x := m.Identifier.(type) {
-	case *GetUserRequest_Uuid:
-		n += 1 // tag and wire
-		n += proto.SizeVarint(uint64(len(x.Uuid)))
-		n += len(x.Uuid)
-	case *GetUserRequest_Email:
-		n += 1 // tag and wire
-		n += proto.SizeVarint(uint64(len(x.Email)))
-		n += len(x.Email)
-	case nil:
-	default:
-		panic(fmt.Sprintf("proto: unexpected type %T in oneof", x))
-	}
-	return n
-}
-
 type GetUserResponse struct {
 	User                 *User    `protobuf:"bytes,1,opt,name=user,proto3" json:"user,omitempty"`
 	XXX_NoUnkeyedLiteral struct{} `json:"-"`
@@ -433,12 +437,14 @@ func (m *CreateGuestUserResponse) GetSession() *Session {
 }
 
 type CreateSessionRequest struct {
-	Email                string           `protobuf:"bytes,1,opt,name=email,proto3" json:"email,omitempty"`
-	Password             string           `protobuf:"bytes,2,opt,name=password,proto3" json:"password,omitempty"`
-	ScopeGroupings       []*ScopeGrouping `protobuf:"bytes,3,rep,name=scope_groupings,json=scopeGroupings,proto3" json:"scope_groupings,omitempty"`
-	XXX_NoUnkeyedLiteral struct{}         `json:"-"`
-	XXX_unrecognized     []byte           `json:"-"`
-	XXX_sizecache        int32            `json:"-"`
+	Email          string           `protobuf:"bytes,1,opt,name=email,proto3" json:"email,omitempty"`
+	Password       string           `protobuf:"bytes,2,opt,name=password,proto3" json:"password,omitempty"`
+	ScopeGroupings []*ScopeGrouping `protobuf:"bytes,3,rep,name=scope_groupings,json=scopeGroupings,proto3" json:"scope_groupings,omitempty"`
+	// Ends the session after this long without being validated, defaults to the server's idle timeout
+	IdleTimeout          *duration.Duration `protobuf:"bytes,4,opt,name=idle_timeout,json=idleTimeout,proto3" json:"idle_timeout,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}           `json:"-"`
+	XXX_unrecognized     []byte             `json:"-"`
+	XXX_sizecache        int32              `json:"-"`
 }
 
 func (m *CreateSessionRequest) Reset()         { *m = CreateSessionRequest{} }
@@ -487,6 +493,13 @@ func (m *CreateSessionRequest) GetScopeGroupings() []*ScopeGrouping {
 	return nil
 }
 
+func (m *CreateSessionRequest) GetIdleTimeout() *duration.Duration {
+	if m != nil {
+		return m.IdleTimeout
+	}
+	return nil
+}
+
 type CreateSessionResponse struct {
 	Session              *Session `protobuf:"bytes,1,opt,name=session,proto3" json:"session,omitempty"`
 	XXX_NoUnkeyedLiteral struct{} `json:"-"`
@@ -824,10 +837,20 @@ func (m *GetSessionRequest) GetToken() string {
 }
 
 type GetSessionResponse struct {
-	Session              *Session `protobuf:"bytes,1,opt,name=session,proto3" json:"session,omitempty"`
-	XXX_NoUnkeyedLiteral struct{} `json:"-"`
-	XXX_unrecognized     []byte   `json:"-"`
-	XXX_sizecache        int32    `json:"-"`
+	Session       *Session             `protobuf:"bytes,1,opt,name=session,proto3" json:"session,omitempty"`
+	Revoked       bool                 `protobuf:"varint,2,opt,name=revoked,proto3" json:"revoked,omitempty"`
+	RevokedReason string               `protobuf:"bytes,3,opt,name=revoked_reason,json=revokedReason,proto3" json:"revoked_reason,omitempty"`
+	RevokedAt     *timestamp.Timestamp `protobuf:"bytes,4,opt,name=revoked_at,json=revokedAt,proto3" json:"revoked_at,omitempty"`
+	// Every scope from a grouping that hasn't expired
+	ActiveScopes          []string             `protobuf:"bytes,5,rep,name=active_scopes,json=activeScopes,proto3" json:"active_scopes,omitempty"`
+	ActiveScopeGroupings  []*ScopeGrouping     `protobuf:"bytes,6,rep,name=active_scope_groupings,json=activeScopeGroupings,proto3" json:"active_scope_groupings,omitempty"`
+	ExpiredScopeGroupings []*ScopeGrouping     `protobuf:"bytes,7,rep,name=expired_scope_groupings,json=expiredScopeGroupings,proto3" json:"expired_scope_groupings,omitempty"`
+	Expiration            *timestamp.Timestamp `protobuf:"bytes,8,opt,name=expiration,proto3" json:"expiration,omitempty"`
+	// When the session idles out unless it's seen again, unset for sessions without an idle timeout
+	IdleExpiration       *timestamp.Timestamp `protobuf:"bytes,9,opt,name=idle_expiration,json=idleExpiration,proto3" json:"idle_expiration,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}             `json:"-"`
+	XXX_unrecognized     []byte               `json:"-"`
+	XXX_sizecache        int32                `json:"-"`
 }
 
 func (m *GetSessionResponse) Reset()         { *m = GetSessionResponse{} }
@@ -862,245 +885,1325 @@ func (m *GetSessionResponse) GetSession() *Session {
 	return nil
 }
 
-type User struct {
-	Uuid                 string   `protobuf:"bytes,1,opt,name=uuid,proto3" json:"uuid,omitempty"`
-	Email                string   `protobuf:"bytes,2,opt,name=email,proto3" json:"email,omitempty"`
-	XXX_NoUnkeyedLiteral struct{} `json:"-"`
-	XXX_unrecognized     []byte   `json:"-"`
-	XXX_sizecache        int32    `json:"-"`
+func (m *GetSessionResponse) GetRevoked() bool {
+	if m != nil {
+		return m.Revoked
+	}
+	return false
 }
 
-func (m *User) Reset()         { *m = User{} }
-func (m *User) String() string { return proto.CompactTextString(m) }
-func (*User) ProtoMessage()    {}
-func (*User) Descriptor() ([]byte, []int) {
+func (m *GetSessionResponse) GetRevokedReason() string {
+	if m != nil {
+		return m.RevokedReason
+	}
+	return ""
+}
+
+func (m *GetSessionResponse) GetRevokedAt() *timestamp.Timestamp {
+	if m != nil {
+		return m.RevokedAt
+	}
+	return nil
+}
+
+func (m *GetSessionResponse) GetActiveScopes() []string {
+	if m != nil {
+		return m.ActiveScopes
+	}
+	return nil
+}
+
+func (m *GetSessionResponse) GetActiveScopeGroupings() []*ScopeGrouping {
+	if m != nil {
+		return m.ActiveScopeGroupings
+	}
+	return nil
+}
+
+func (m *GetSessionResponse) GetExpiredScopeGroupings() []*ScopeGrouping {
+	if m != nil {
+		return m.ExpiredScopeGroupings
+	}
+	return nil
+}
+
+func (m *GetSessionResponse) GetExpiration() *timestamp.Timestamp {
+	if m != nil {
+		return m.Expiration
+	}
+	return nil
+}
+
+func (m *GetSessionResponse) GetIdleExpiration() *timestamp.Timestamp {
+	if m != nil {
+		return m.IdleExpiration
+	}
+	return nil
+}
+
+type ElevateSessionRequest struct {
+	Token                string           `protobuf:"bytes,1,opt,name=token,proto3" json:"token,omitempty"`
+	Password             string           `protobuf:"bytes,2,opt,name=password,proto3" json:"password,omitempty"`
+	ScopeGroupings       []*ScopeGrouping `protobuf:"bytes,3,rep,name=scope_groupings,json=scopeGroupings,proto3" json:"scope_groupings,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}         `json:"-"`
+	XXX_unrecognized     []byte           `json:"-"`
+	XXX_sizecache        int32            `json:"-"`
+}
+
+func (m *ElevateSessionRequest) Reset()         { *m = ElevateSessionRequest{} }
+func (m *ElevateSessionRequest) String() string { return proto.CompactTextString(m) }
+func (*ElevateSessionRequest) ProtoMessage()    {}
+func (*ElevateSessionRequest) Descriptor() ([]byte, []int) {
 	return fileDescriptor_958480b1a11f31b5, []int{16}
 }
 
-func (m *User) XXX_Unmarshal(b []byte) error {
-	return xxx_messageInfo_User.Unmarshal(m, b)
+func (m *ElevateSessionRequest) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_ElevateSessionRequest.Unmarshal(m, b)
 }
-func (m *User) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
-	return xxx_messageInfo_User.Marshal(b, m, deterministic)
+func (m *ElevateSessionRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_ElevateSessionRequest.Marshal(b, m, deterministic)
 }
-func (m *User) XXX_Merge(src proto.Message) {
-	xxx_messageInfo_User.Merge(m, src)
+func (m *ElevateSessionRequest) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_ElevateSessionRequest.Merge(m, src)
 }
-func (m *User) XXX_Size() int {
-	return xxx_messageInfo_User.Size(m)
+func (m *ElevateSessionRequest) XXX_Size() int {
+	return xxx_messageInfo_ElevateSessionRequest.Size(m)
 }
-func (m *User) XXX_DiscardUnknown() {
-	xxx_messageInfo_User.DiscardUnknown(m)
+func (m *ElevateSessionRequest) XXX_DiscardUnknown() {
+	xxx_messageInfo_ElevateSessionRequest.DiscardUnknown(m)
 }
 
-var xxx_messageInfo_User proto.InternalMessageInfo
+var xxx_messageInfo_ElevateSessionRequest proto.InternalMessageInfo
 
-func (m *User) GetUuid() string {
+func (m *ElevateSessionRequest) GetToken() string {
 	if m != nil {
-		return m.Uuid
+		return m.Token
 	}
 	return ""
 }
 
-func (m *User) GetEmail() string {
+func (m *ElevateSessionRequest) GetPassword() string {
 	if m != nil {
-		return m.Email
+		return m.Password
 	}
 	return ""
 }
 
-type ScopeGrouping struct {
-	Scopes               []string             `protobuf:"bytes,1,rep,name=scopes,proto3" json:"scopes,omitempty"`
-	Expiration           *timestamp.Timestamp `protobuf:"bytes,2,opt,name=expiration,proto3" json:"expiration,omitempty"`
-	XXX_NoUnkeyedLiteral struct{}             `json:"-"`
-	XXX_unrecognized     []byte               `json:"-"`
-	XXX_sizecache        int32                `json:"-"`
+func (m *ElevateSessionRequest) GetScopeGroupings() []*ScopeGrouping {
+	if m != nil {
+		return m.ScopeGroupings
+	}
+	return nil
 }
 
-func (m *ScopeGrouping) Reset()         { *m = ScopeGrouping{} }
-func (m *ScopeGrouping) String() string { return proto.CompactTextString(m) }
-func (*ScopeGrouping) ProtoMessage()    {}
-func (*ScopeGrouping) Descriptor() ([]byte, []int) {
+type ElevateSessionResponse struct {
+	// Same session uuid with a new token, the previous token stops validating
+	Session              *Session `protobuf:"bytes,1,opt,name=session,proto3" json:"session,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *ElevateSessionResponse) Reset()         { *m = ElevateSessionResponse{} }
+func (m *ElevateSessionResponse) String() string { return proto.CompactTextString(m) }
+func (*ElevateSessionResponse) ProtoMessage()    {}
+func (*ElevateSessionResponse) Descriptor() ([]byte, []int) {
 	return fileDescriptor_958480b1a11f31b5, []int{17}
 }
 
-func (m *ScopeGrouping) XXX_Unmarshal(b []byte) error {
-	return xxx_messageInfo_ScopeGrouping.Unmarshal(m, b)
+func (m *ElevateSessionResponse) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_ElevateSessionResponse.Unmarshal(m, b)
 }
-func (m *ScopeGrouping) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
-	return xxx_messageInfo_ScopeGrouping.Marshal(b, m, deterministic)
+func (m *ElevateSessionResponse) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_ElevateSessionResponse.Marshal(b, m, deterministic)
 }
-func (m *ScopeGrouping) XXX_Merge(src proto.Message) {
-	xxx_messageInfo_ScopeGrouping.Merge(m, src)
+func (m *ElevateSessionResponse) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_ElevateSessionResponse.Merge(m, src)
 }
-func (m *ScopeGrouping) XXX_Size() int {
-	return xxx_messageInfo_ScopeGrouping.Size(m)
+func (m *ElevateSessionResponse) XXX_Size() int {
+	return xxx_messageInfo_ElevateSessionResponse.Size(m)
 }
-func (m *ScopeGrouping) XXX_DiscardUnknown() {
-	xxx_messageInfo_ScopeGrouping.DiscardUnknown(m)
+func (m *ElevateSessionResponse) XXX_DiscardUnknown() {
+	xxx_messageInfo_ElevateSessionResponse.DiscardUnknown(m)
 }
 
-var xxx_messageInfo_ScopeGrouping proto.InternalMessageInfo
+var xxx_messageInfo_ElevateSessionResponse proto.InternalMessageInfo
 
-func (m *ScopeGrouping) GetScopes() []string {
+func (m *ElevateSessionResponse) GetSession() *Session {
 	if m != nil {
-		return m.Scopes
+		return m.Session
 	}
 	return nil
 }
 
-func (m *ScopeGrouping) GetExpiration() *timestamp.Timestamp {
+type RefreshSessionRequest struct {
+	RefreshToken         string   `protobuf:"bytes,1,opt,name=refresh_token,json=refreshToken,proto3" json:"refresh_token,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *RefreshSessionRequest) Reset()         { *m = RefreshSessionRequest{} }
+func (m *RefreshSessionRequest) String() string { return proto.CompactTextString(m) }
+func (*RefreshSessionRequest) ProtoMessage()    {}
+func (*RefreshSessionRequest) Descriptor() ([]byte, []int) {
+	return fileDescriptor_958480b1a11f31b5, []int{18}
+}
+
+func (m *RefreshSessionRequest) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_RefreshSessionRequest.Unmarshal(m, b)
+}
+func (m *RefreshSessionRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_RefreshSessionRequest.Marshal(b, m, deterministic)
+}
+func (m *RefreshSessionRequest) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_RefreshSessionRequest.Merge(m, src)
+}
+func (m *RefreshSessionRequest) XXX_Size() int {
+	return xxx_messageInfo_RefreshSessionRequest.Size(m)
+}
+func (m *RefreshSessionRequest) XXX_DiscardUnknown() {
+	xxx_messageInfo_RefreshSessionRequest.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_RefreshSessionRequest proto.InternalMessageInfo
+
+func (m *RefreshSessionRequest) GetRefreshToken() string {
 	if m != nil {
-		return m.Expiration
+		return m.RefreshToken
 	}
-	return nil
+	return ""
 }
 
-type Session struct {
-	Uuid                 string   `protobuf:"bytes,1,opt,name=uuid,proto3" json:"uuid,omitempty"`
-	Token                string   `protobuf:"bytes,2,opt,name=token,proto3" json:"token,omitempty"`
-	Json                 string   `protobuf:"bytes,3,opt,name=json,proto3" json:"json,omitempty"`
+type RefreshSessionResponse struct {
+	// New token and refresh token, the refresh token that was sent can't be used again
+	Session              *Session `protobuf:"bytes,1,opt,name=session,proto3" json:"session,omitempty"`
 	XXX_NoUnkeyedLiteral struct{} `json:"-"`
 	XXX_unrecognized     []byte   `json:"-"`
 	XXX_sizecache        int32    `json:"-"`
 }
 
-func (m *Session) Reset()         { *m = Session{} }
-func (m *Session) String() string { return proto.CompactTextString(m) }
-func (*Session) ProtoMessage()    {}
-func (*Session) Descriptor() ([]byte, []int) {
-	return fileDescriptor_958480b1a11f31b5, []int{18}
+func (m *RefreshSessionResponse) Reset()         { *m = RefreshSessionResponse{} }
+func (m *RefreshSessionResponse) String() string { return proto.CompactTextString(m) }
+func (*RefreshSessionResponse) ProtoMessage()    {}
+func (*RefreshSessionResponse) Descriptor() ([]byte, []int) {
+	return fileDescriptor_958480b1a11f31b5, []int{19}
 }
 
-func (m *Session) XXX_Unmarshal(b []byte) error {
-	return xxx_messageInfo_Session.Unmarshal(m, b)
+func (m *RefreshSessionResponse) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_RefreshSessionResponse.Unmarshal(m, b)
 }
-func (m *Session) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
-	return xxx_messageInfo_Session.Marshal(b, m, deterministic)
+func (m *RefreshSessionResponse) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_RefreshSessionResponse.Marshal(b, m, deterministic)
 }
-func (m *Session) XXX_Merge(src proto.Message) {
-	xxx_messageInfo_Session.Merge(m, src)
+func (m *RefreshSessionResponse) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_RefreshSessionResponse.Merge(m, src)
 }
-func (m *Session) XXX_Size() int {
-	return xxx_messageInfo_Session.Size(m)
+func (m *RefreshSessionResponse) XXX_Size() int {
+	return xxx_messageInfo_RefreshSessionResponse.Size(m)
 }
-func (m *Session) XXX_DiscardUnknown() {
-	xxx_messageInfo_Session.DiscardUnknown(m)
+func (m *RefreshSessionResponse) XXX_DiscardUnknown() {
+	xxx_messageInfo_RefreshSessionResponse.DiscardUnknown(m)
 }
 
-var xxx_messageInfo_Session proto.InternalMessageInfo
+var xxx_messageInfo_RefreshSessionResponse proto.InternalMessageInfo
 
-func (m *Session) GetUuid() string {
+func (m *RefreshSessionResponse) GetSession() *Session {
 	if m != nil {
-		return m.Uuid
+		return m.Session
 	}
-	return ""
+	return nil
 }
 
-func (m *Session) GetToken() string {
+type AuthorizeRequest struct {
+	Token                string   `protobuf:"bytes,1,opt,name=token,proto3" json:"token,omitempty"`
+	RequiredScopes       []string `protobuf:"bytes,2,rep,name=required_scopes,json=requiredScopes,proto3" json:"required_scopes,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *AuthorizeRequest) Reset()         { *m = AuthorizeRequest{} }
+func (m *AuthorizeRequest) String() string { return proto.CompactTextString(m) }
+func (*AuthorizeRequest) ProtoMessage()    {}
+func (*AuthorizeRequest) Descriptor() ([]byte, []int) {
+	return fileDescriptor_958480b1a11f31b5, []int{20}
+}
+
+func (m *AuthorizeRequest) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_AuthorizeRequest.Unmarshal(m, b)
+}
+func (m *AuthorizeRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_AuthorizeRequest.Marshal(b, m, deterministic)
+}
+func (m *AuthorizeRequest) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_AuthorizeRequest.Merge(m, src)
+}
+func (m *AuthorizeRequest) XXX_Size() int {
+	return xxx_messageInfo_AuthorizeRequest.Size(m)
+}
+func (m *AuthorizeRequest) XXX_DiscardUnknown() {
+	xxx_messageInfo_AuthorizeRequest.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_AuthorizeRequest proto.InternalMessageInfo
+
+func (m *AuthorizeRequest) GetToken() string {
 	if m != nil {
 		return m.Token
 	}
 	return ""
 }
 
-func (m *Session) GetJson() string {
+func (m *AuthorizeRequest) GetRequiredScopes() []string {
 	if m != nil {
-		return m.Json
+		return m.RequiredScopes
 	}
-	return ""
+	return nil
 }
 
-func init() {
-	proto.RegisterEnum("proto.ResetUserPasswordResponse_Status", ResetUserPasswordResponse_Status_name, ResetUserPasswordResponse_Status_value)
-	proto.RegisterType((*GetUserRequest)(nil), "proto.GetUserRequest")
-	proto.RegisterType((*GetUserResponse)(nil), "proto.GetUserResponse")
-	proto.RegisterType((*CreateUserRequest)(nil), "proto.CreateUserRequest")
-	proto.RegisterType((*CreateUserResponse)(nil), "proto.CreateUserResponse")
-	proto.RegisterType((*CreateGuestUserRequest)(nil), "proto.CreateGuestUserRequest")
-	proto.RegisterType((*CreateGuestUserResponse)(nil), "proto.CreateGuestUserResponse")
-	proto.RegisterType((*CreateSessionRequest)(nil), "proto.CreateSessionRequest")
-	proto.RegisterType((*CreateSessionResponse)(nil), "proto.CreateSessionResponse")
-	proto.RegisterType((*CreatePasswordResetTokenRequest)(nil), "proto.CreatePasswordResetTokenRequest")
-	proto.RegisterType((*CreatePasswordResetTokenResponse)(nil), "proto.CreatePasswordResetTokenResponse")
-	proto.RegisterType((*ResetUserPasswordRequest)(nil), "proto.ResetUserPasswordRequest")
-	proto.RegisterType((*ResetUserPasswordResponse)(nil), "proto.ResetUserPasswordResponse")
-	proto.RegisterType((*DeleteSessionRequest)(nil), "proto.DeleteSessionRequest")
-	proto.RegisterType((*DeleteSessionResponse)(nil), "proto.DeleteSessionResponse")
-	proto.RegisterType((*GetSessionRequest)(nil), "proto.GetSessionRequest")
-	proto.RegisterType((*GetSessionResponse)(nil), "proto.GetSessionResponse")
-	proto.RegisterType((*User)(nil), "proto.User")
-	proto.RegisterType((*ScopeGrouping)(nil), "proto.ScopeGrouping")
-	proto.RegisterType((*Session)(nil), "proto.Session")
+type AuthorizeResponse struct {
+	Decision   AuthorizeResponse_Decision   `protobuf:"varint,1,opt,name=decision,proto3,enum=proto.AuthorizeResponse_Decision" json:"decision,omitempty"`
+	DenyReason AuthorizeResponse_DenyReason `protobuf:"varint,2,opt,name=deny_reason,json=denyReason,proto3,enum=proto.AuthorizeResponse_DenyReason" json:"deny_reason,omitempty"`
+	// The grouping that satisfied the request, or the expired one that would have
+	ScopeGrouping            *ScopeGrouping `protobuf:"bytes,3,opt,name=scope_grouping,json=scopeGrouping,proto3" json:"scope_grouping,omitempty"`
+	ReauthenticationRequired bool           `protobuf:"varint,4,opt,name=reauthentication_required,json=reauthenticationRequired,proto3" json:"reauthentication_required,omitempty"`
+	MissingScopes            []string       `protobuf:"bytes,5,rep,name=missing_scopes,json=missingScopes,proto3" json:"missing_scopes,omitempty"`
+	SessionUuid              string         `protobuf:"bytes,6,opt,name=session_uuid,json=sessionUuid,proto3" json:"session_uuid,omitempty"`
+	UserUuid                 string         `protobuf:"bytes,7,opt,name=user_uuid,json=userUuid,proto3" json:"user_uuid,omitempty"`
+	XXX_NoUnkeyedLiteral     struct{}       `json:"-"`
+	XXX_unrecognized         []byte         `json:"-"`
+	XXX_sizecache            int32          `json:"-"`
 }
 
-func init() { proto.RegisterFile("fingerprint.proto", fileDescriptor_958480b1a11f31b5) }
+func (m *AuthorizeResponse) Reset()         { *m = AuthorizeResponse{} }
+func (m *AuthorizeResponse) String() string { return proto.CompactTextString(m) }
+func (*AuthorizeResponse) ProtoMessage()    {}
+func (*AuthorizeResponse) Descriptor() ([]byte, []int) {
+	return fileDescriptor_958480b1a11f31b5, []int{21}
+}
 
-var fileDescriptor_958480b1a11f31b5 = []byte{
-	// 796 bytes of a gzipped FileDescriptorProto
-	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0xbc, 0x55, 0xcf, 0x6e, 0xda, 0x4c,
-	0x10, 0xc7, 0x40, 0x48, 0x32, 0xf9, 0x42, 0xc2, 0x0a, 0x08, 0x71, 0xbe, 0x06, 0xb4, 0x87, 0x86,
-	0xf6, 0x40, 0x22, 0x72, 0x88, 0x14, 0xa9, 0xad, 0x52, 0x42, 0x48, 0x54, 0xf2, 0x47, 0x36, 0xa8,
-	0xea, 0xc9, 0x22, 0xb0, 0x20, 0xb7, 0x01, 0xbb, 0x5e, 0xbb, 0xed, 0x1b, 0xf4, 0x05, 0xfa, 0x14,
-	0xbd, 0xf6, 0xd4, 0xb7, 0xab, 0xbc, 0xbb, 0x36, 0x36, 0xb6, 0x93, 0xa6, 0xaa, 0x7a, 0xb2, 0x67,
-	0x67, 0x76, 0xe6, 0x37, 0x33, 0xfb, 0x9b, 0x81, 0xc2, 0x58, 0x9f, 0x4d, 0x88, 0x65, 0x5a, 0xfa,
-	0xcc, 0x6e, 0x98, 0x96, 0x61, 0x1b, 0x68, 0x89, 0x7d, 0xe4, 0xea, 0xc4, 0x30, 0x26, 0x77, 0x64,
-	0x9f, 0x49, 0xb7, 0xce, 0x78, 0xdf, 0xd6, 0xa7, 0x84, 0xda, 0x83, 0xa9, 0xc9, 0xed, 0x70, 0x17,
-	0xf2, 0x1d, 0x62, 0xf7, 0x29, 0xb1, 0x14, 0xf2, 0xd1, 0x21, 0xd4, 0x46, 0x45, 0xc8, 0x3a, 0x8e,
-	0x3e, 0xaa, 0x48, 0x35, 0xa9, 0xbe, 0x7a, 0x9e, 0x52, 0x98, 0x84, 0xca, 0xb0, 0x44, 0xa6, 0x03,
-	0xfd, 0xae, 0x92, 0x16, 0xc7, 0x5c, 0x7c, 0xfd, 0x1f, 0x80, 0x3e, 0x22, 0x33, 0x5b, 0x1f, 0xeb,
-	0xc4, 0xc2, 0x4d, 0xd8, 0xf0, 0xbd, 0x51, 0xd3, 0x98, 0x51, 0x82, 0xaa, 0x90, 0x75, 0x28, 0xb1,
-	0x98, 0xbb, 0xb5, 0xe6, 0x1a, 0x0f, 0xdb, 0x60, 0x26, 0x4c, 0x81, 0x7f, 0x4a, 0x50, 0x68, 0x59,
-	0x64, 0x60, 0x93, 0x30, 0x0a, 0x11, 0x8f, 0xc1, 0x10, 0xd1, 0x90, 0x0c, 0x2b, 0xe6, 0x80, 0xd2,
-	0xcf, 0x86, 0x35, 0xe2, 0x40, 0x14, 0x5f, 0x46, 0x87, 0x50, 0xf2, 0xfe, 0xb5, 0xa1, 0x31, 0x1b,
-	0xeb, 0xd6, 0x74, 0x60, 0xeb, 0xc6, 0xac, 0x92, 0x61, 0x86, 0x45, 0x4f, 0xd9, 0x0a, 0xe8, 0xd0,
-	0x0b, 0xd8, 0xa0, 0x43, 0xc3, 0x24, 0xda, 0xc4, 0x32, 0x1c, 0x53, 0x9f, 0x4d, 0x68, 0x25, 0x5b,
-	0xcb, 0xd4, 0xd7, 0x9a, 0x45, 0x01, 0x54, 0x75, 0xb5, 0x1d, 0xa1, 0x54, 0xf2, 0x34, 0x28, 0x52,
-	0xac, 0x01, 0x0a, 0x42, 0xff, 0xcd, 0x94, 0x51, 0x1d, 0x96, 0x29, 0xa1, 0xd4, 0x05, 0x97, 0x66,
-	0x36, 0x79, 0x2f, 0x1a, 0x3f, 0x55, 0x3c, 0x35, 0x9e, 0x42, 0x99, 0x07, 0xe8, 0xb8, 0x55, 0x79,
-	0xb8, 0x40, 0x31, 0xf9, 0xa4, 0x1f, 0x91, 0xcf, 0x08, 0xb6, 0x22, 0xe1, 0xfe, 0x7e, 0x52, 0x5f,
-	0x25, 0x28, 0xf2, 0x30, 0x9e, 0xea, 0x8f, 0x9b, 0x1e, 0x93, 0x6f, 0xe6, 0x11, 0xf9, 0x9e, 0x40,
-	0x69, 0x01, 0x88, 0xc8, 0x36, 0x90, 0x8c, 0x74, 0x7f, 0x32, 0x47, 0x50, 0xe5, 0x2e, 0x6e, 0x04,
-	0x26, 0x85, 0x50, 0x62, 0xf7, 0x8c, 0x0f, 0xe4, 0xfe, 0xb4, 0x70, 0x0f, 0x6a, 0xc9, 0x17, 0x05,
-	0x8c, 0x03, 0xf0, 0x9f, 0xad, 0x66, 0xb9, 0x6a, 0xcd, 0x76, 0xf5, 0xc2, 0x11, 0x32, 0x23, 0x37,
-	0xf1, 0x0f, 0x09, 0x2a, 0x4c, 0x74, 0x3b, 0x33, 0xf7, 0xfc, 0x4f, 0x49, 0x95, 0x84, 0x3a, 0x9b,
-	0x88, 0xfa, 0xbb, 0x04, 0xdb, 0x31, 0xa8, 0x45, 0x15, 0x5e, 0x41, 0x8e, 0xda, 0x03, 0xdb, 0xa1,
-	0x0c, 0x77, 0xbe, 0xb9, 0x27, 0x7a, 0x91, 0x78, 0xa3, 0xa1, 0x32, 0x73, 0x45, 0x5c, 0xc3, 0x5d,
-	0xc8, 0xf1, 0x13, 0x94, 0x07, 0x50, 0xfb, 0xad, 0x56, 0x5b, 0x55, 0xcf, 0xfa, 0xdd, 0xcd, 0x14,
-	0x2a, 0x41, 0xe1, 0xe6, 0x44, 0x55, 0xdf, 0x5e, 0x2b, 0xa7, 0xda, 0xe5, 0x85, 0x7a, 0x79, 0xd2,
-	0x6b, 0x9d, 0x6f, 0x4a, 0x68, 0x07, 0xb6, 0xae, 0xae, 0x35, 0x26, 0x5d, 0x5c, 0x75, 0x34, 0xa5,
-	0xad, 0xb6, 0x7b, 0x5a, 0xef, 0xfa, 0x4d, 0xfb, 0x6a, 0x33, 0x8d, 0x9f, 0x43, 0xf1, 0x94, 0xdc,
-	0x91, 0xc8, 0xeb, 0x45, 0xc1, 0xc1, 0xc9, 0xc7, 0x26, 0x3e, 0x82, 0xd2, 0x82, 0xad, 0xc8, 0x69,
-	0x17, 0x80, 0x3a, 0xc3, 0x21, 0xa1, 0x74, 0xec, 0xf0, 0x7e, 0xac, 0x28, 0x81, 0x13, 0xfc, 0x0c,
-	0x0a, 0x1d, 0x62, 0x47, 0xf9, 0x11, 0xec, 0x3f, 0x17, 0xf0, 0x4b, 0x40, 0x41, 0xd3, 0x47, 0xbf,
-	0xe0, 0x03, 0xc8, 0xba, 0x45, 0x8c, 0xc3, 0x3f, 0x7f, 0x31, 0xe9, 0xe0, 0xd3, 0x1d, 0xc2, 0x7a,
-	0x88, 0x57, 0xa8, 0x0c, 0x39, 0xc6, 0x2c, 0xb7, 0x43, 0x99, 0xfa, 0xaa, 0x22, 0x24, 0x74, 0x0c,
-	0x40, 0xbe, 0x98, 0xba, 0xc5, 0xdf, 0x0c, 0x1f, 0x0b, 0x72, 0x83, 0xef, 0xa4, 0x86, 0xb7, 0x93,
-	0x1a, 0x3d, 0x6f, 0x27, 0x29, 0x01, 0x6b, 0xdc, 0x81, 0x65, 0x01, 0x35, 0x09, 0x19, 0xaf, 0x45,
-	0x3a, 0x50, 0x0b, 0xd7, 0xf2, 0x3d, 0xf5, 0x9f, 0x27, 0xfb, 0x6f, 0x7e, 0x5b, 0x02, 0x74, 0x36,
-	0x5f, 0x90, 0x2a, 0xb1, 0x3e, 0xe9, 0x43, 0x82, 0x8e, 0x61, 0x59, 0xec, 0x2a, 0x54, 0x12, 0xa5,
-	0x09, 0x6f, 0x42, 0xb9, 0xbc, 0x78, 0xcc, 0x4b, 0x8b, 0x53, 0xa8, 0x05, 0x30, 0x9f, 0xfb, 0xa8,
-	0x22, 0xec, 0x22, 0x5b, 0x4c, 0xde, 0x8e, 0xd1, 0xf8, 0x4e, 0x14, 0xd8, 0x58, 0x18, 0xb6, 0xe8,
-	0x49, 0xc8, 0x7e, 0x71, 0xe6, 0xcb, 0xbb, 0x49, 0x6a, 0xdf, 0xe7, 0x14, 0x2a, 0x49, 0x43, 0x05,
-	0x3d, 0x0d, 0xdd, 0x4e, 0x1c, 0x57, 0xf2, 0xde, 0x83, 0x76, 0x7e, 0xb8, 0x77, 0x80, 0xfa, 0xe6,
-	0x48, 0xa4, 0xe6, 0x59, 0xa2, 0x6a, 0x32, 0x3f, 0x79, 0x84, 0xda, 0x43, 0x04, 0xc6, 0x29, 0xd4,
-	0x85, 0xf5, 0xd0, 0x68, 0x46, 0x3b, 0x21, 0x58, 0x61, 0x66, 0xc8, 0xff, 0xc7, 0x2b, 0x83, 0xde,
-	0x42, 0x3c, 0xf4, 0xbd, 0xc5, 0x31, 0xd9, 0xf7, 0x16, 0x4b, 0x5d, 0xde, 0xfe, 0x39, 0xe3, 0xfc,
-	0xf6, 0x47, 0xf8, 0xea, 0xb7, 0x3f, 0x4a, 0x4f, 0x9c, 0xba, 0xcd, 0x31, 0xdd, 0xe1, 0xaf, 0x00,
-	0x00, 0x00, 0xff, 0xff, 0x9d, 0xa7, 0xff, 0x2b, 0xbd, 0x09, 0x00, 0x00,
+func (m *AuthorizeResponse) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_AuthorizeResponse.Unmarshal(m, b)
+}
+func (m *AuthorizeResponse) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_AuthorizeResponse.Marshal(b, m, deterministic)
+}
+func (m *AuthorizeResponse) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_AuthorizeResponse.Merge(m, src)
+}
+func (m *AuthorizeResponse) XXX_Size() int {
+	return xxx_messageInfo_AuthorizeResponse.Size(m)
+}
+func (m *AuthorizeResponse) XXX_DiscardUnknown() {
+	xxx_messageInfo_AuthorizeResponse.DiscardUnknown(m)
 }
 
-// Reference imports to suppress errors if they are not otherwise used.
-var _ context.Context
-var _ grpc.ClientConn
+var xxx_messageInfo_AuthorizeResponse proto.InternalMessageInfo
 
-// This is a compile-time assertion to ensure that this generated file
-// is compatible with the grpc package it is being compiled against.
-const _ = grpc.SupportPackageIsVersion4
+func (m *AuthorizeResponse) GetDecision() AuthorizeResponse_Decision {
+	if m != nil {
+		return m.Decision
+	}
+	return AuthorizeResponse_ALLOW
+}
 
-// FingerprintServiceClient is the client API for FingerprintService service.
-//
-// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://godoc.org/google.golang.org/grpc#ClientConn.NewStream.
+func (m *AuthorizeResponse) GetDenyReason() AuthorizeResponse_DenyReason {
+	if m != nil {
+		return m.DenyReason
+	}
+	return AuthorizeResponse_NONE
+}
+
+func (m *AuthorizeResponse) GetScopeGrouping() *ScopeGrouping {
+	if m != nil {
+		return m.ScopeGrouping
+	}
+	return nil
+}
+
+func (m *AuthorizeResponse) GetReauthenticationRequired() bool {
+	if m != nil {
+		return m.ReauthenticationRequired
+	}
+	return false
+}
+
+func (m *AuthorizeResponse) GetMissingScopes() []string {
+	if m != nil {
+		return m.MissingScopes
+	}
+	return nil
+}
+
+func (m *AuthorizeResponse) GetSessionUuid() string {
+	if m != nil {
+		return m.SessionUuid
+	}
+	return ""
+}
+
+func (m *AuthorizeResponse) GetUserUuid() string {
+	if m != nil {
+		return m.UserUuid
+	}
+	return ""
+}
+
+type RevokeSessionsRequest struct {
+	// Types that are valid to be assigned to Identifier:
+	//	*RevokeSessionsRequest_SessionUuid
+	//	*RevokeSessionsRequest_UserUuid
+	Identifier           isRevokeSessionsRequest_Identifier `protobuf_oneof:"identifier"`
+	Reason               string                             `protobuf:"bytes,3,opt,name=reason,proto3" json:"reason,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}                           `json:"-"`
+	XXX_unrecognized     []byte                             `json:"-"`
+	XXX_sizecache        int32                              `json:"-"`
+}
+
+func (m *RevokeSessionsRequest) Reset()         { *m = RevokeSessionsRequest{} }
+func (m *RevokeSessionsRequest) String() string { return proto.CompactTextString(m) }
+func (*RevokeSessionsRequest) ProtoMessage()    {}
+func (*RevokeSessionsRequest) Descriptor() ([]byte, []int) {
+	return fileDescriptor_958480b1a11f31b5, []int{22}
+}
+
+func (m *RevokeSessionsRequest) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_RevokeSessionsRequest.Unmarshal(m, b)
+}
+func (m *RevokeSessionsRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_RevokeSessionsRequest.Marshal(b, m, deterministic)
+}
+func (m *RevokeSessionsRequest) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_RevokeSessionsRequest.Merge(m, src)
+}
+func (m *RevokeSessionsRequest) XXX_Size() int {
+	return xxx_messageInfo_RevokeSessionsRequest.Size(m)
+}
+func (m *RevokeSessionsRequest) XXX_DiscardUnknown() {
+	xxx_messageInfo_RevokeSessionsRequest.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_RevokeSessionsRequest proto.InternalMessageInfo
+
+type isRevokeSessionsRequest_Identifier interface {
+	isRevokeSessionsRequest_Identifier()
+}
+
+type RevokeSessionsRequest_SessionUuid struct {
+	SessionUuid string `protobuf:"bytes,1,opt,name=session_uuid,json=sessionUuid,proto3,oneof"`
+}
+
+type RevokeSessionsRequest_UserUuid struct {
+	UserUuid string `protobuf:"bytes,2,opt,name=user_uuid,json=userUuid,proto3,oneof"`
+}
+
+func (*RevokeSessionsRequest_SessionUuid) isRevokeSessionsRequest_Identifier() {}
+
+func (*RevokeSessionsRequest_UserUuid) isRevokeSessionsRequest_Identifier() {}
+
+func (m *RevokeSessionsRequest) GetIdentifier() isRevokeSessionsRequest_Identifier {
+	if m != nil {
+		return m.Identifier
+	}
+	return nil
+}
+
+func (m *RevokeSessionsRequest) GetSessionUuid() string {
+	if x, ok := m.GetIdentifier().(*RevokeSessionsRequest_SessionUuid); ok {
+		return x.SessionUuid
+	}
+	return ""
+}
+
+func (m *RevokeSessionsRequest) GetUserUuid() string {
+	if x, ok := m.GetIdentifier().(*RevokeSessionsRequest_UserUuid); ok {
+		return x.UserUuid
+	}
+	return ""
+}
+
+func (m *RevokeSessionsRequest) GetReason() string {
+	if m != nil {
+		return m.Reason
+	}
+	return ""
+}
+
+// XXX_OneofWrappers is for the internal use of the proto package.
+func (*RevokeSessionsRequest) XXX_OneofWrappers() []interface{} {
+	return []interface{}{
+		(*RevokeSessionsRequest_SessionUuid)(nil),
+		(*RevokeSessionsRequest_UserUuid)(nil),
+	}
+}
+
+type RevokeSessionsResponse struct {
+	RevokedSessionUuids  []string `protobuf:"bytes,1,rep,name=revoked_session_uuids,json=revokedSessionUuids,proto3" json:"revoked_session_uuids,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *RevokeSessionsResponse) Reset()         { *m = RevokeSessionsResponse{} }
+func (m *RevokeSessionsResponse) String() string { return proto.CompactTextString(m) }
+func (*RevokeSessionsResponse) ProtoMessage()    {}
+func (*RevokeSessionsResponse) Descriptor() ([]byte, []int) {
+	return fileDescriptor_958480b1a11f31b5, []int{23}
+}
+
+func (m *RevokeSessionsResponse) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_RevokeSessionsResponse.Unmarshal(m, b)
+}
+func (m *RevokeSessionsResponse) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_RevokeSessionsResponse.Marshal(b, m, deterministic)
+}
+func (m *RevokeSessionsResponse) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_RevokeSessionsResponse.Merge(m, src)
+}
+func (m *RevokeSessionsResponse) XXX_Size() int {
+	return xxx_messageInfo_RevokeSessionsResponse.Size(m)
+}
+func (m *RevokeSessionsResponse) XXX_DiscardUnknown() {
+	xxx_messageInfo_RevokeSessionsResponse.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_RevokeSessionsResponse proto.InternalMessageInfo
+
+func (m *RevokeSessionsResponse) GetRevokedSessionUuids() []string {
+	if m != nil {
+		return m.RevokedSessionUuids
+	}
+	return nil
+}
+
+type GetVerificationKeysRequest struct {
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *GetVerificationKeysRequest) Reset()         { *m = GetVerificationKeysRequest{} }
+func (m *GetVerificationKeysRequest) String() string { return proto.CompactTextString(m) }
+func (*GetVerificationKeysRequest) ProtoMessage()    {}
+func (*GetVerificationKeysRequest) Descriptor() ([]byte, []int) {
+	return fileDescriptor_958480b1a11f31b5, []int{24}
+}
+
+func (m *GetVerificationKeysRequest) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_GetVerificationKeysRequest.Unmarshal(m, b)
+}
+func (m *GetVerificationKeysRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_GetVerificationKeysRequest.Marshal(b, m, deterministic)
+}
+func (m *GetVerificationKeysRequest) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_GetVerificationKeysRequest.Merge(m, src)
+}
+func (m *GetVerificationKeysRequest) XXX_Size() int {
+	return xxx_messageInfo_GetVerificationKeysRequest.Size(m)
+}
+func (m *GetVerificationKeysRequest) XXX_DiscardUnknown() {
+	xxx_messageInfo_GetVerificationKeysRequest.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_GetVerificationKeysRequest proto.InternalMessageInfo
+
+type GetVerificationKeysResponse struct {
+	Keys                 []*VerificationKey `protobuf:"bytes,1,rep,name=keys,proto3" json:"keys,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}           `json:"-"`
+	XXX_unrecognized     []byte             `json:"-"`
+	XXX_sizecache        int32              `json:"-"`
+}
+
+func (m *GetVerificationKeysResponse) Reset()         { *m = GetVerificationKeysResponse{} }
+func (m *GetVerificationKeysResponse) String() string { return proto.CompactTextString(m) }
+func (*GetVerificationKeysResponse) ProtoMessage()    {}
+func (*GetVerificationKeysResponse) Descriptor() ([]byte, []int) {
+	return fileDescriptor_958480b1a11f31b5, []int{25}
+}
+
+func (m *GetVerificationKeysResponse) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_GetVerificationKeysResponse.Unmarshal(m, b)
+}
+func (m *GetVerificationKeysResponse) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_GetVerificationKeysResponse.Marshal(b, m, deterministic)
+}
+func (m *GetVerificationKeysResponse) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_GetVerificationKeysResponse.Merge(m, src)
+}
+func (m *GetVerificationKeysResponse) XXX_Size() int {
+	return xxx_messageInfo_GetVerificationKeysResponse.Size(m)
+}
+func (m *GetVerificationKeysResponse) XXX_DiscardUnknown() {
+	xxx_messageInfo_GetVerificationKeysResponse.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_GetVerificationKeysResponse proto.InternalMessageInfo
+
+func (m *GetVerificationKeysResponse) GetKeys() []*VerificationKey {
+	if m != nil {
+		return m.Keys
+	}
+	return nil
+}
+
+type ListJobsRequest struct {
+	// How many of each job's latest runs to return, 10 when unset
+	RunLimit             int32    `protobuf:"varint,1,opt,name=run_limit,json=runLimit,proto3" json:"run_limit,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *ListJobsRequest) Reset()         { *m = ListJobsRequest{} }
+func (m *ListJobsRequest) String() string { return proto.CompactTextString(m) }
+func (*ListJobsRequest) ProtoMessage()    {}
+func (*ListJobsRequest) Descriptor() ([]byte, []int) {
+	return fileDescriptor_958480b1a11f31b5, []int{26}
+}
+
+func (m *ListJobsRequest) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_ListJobsRequest.Unmarshal(m, b)
+}
+func (m *ListJobsRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_ListJobsRequest.Marshal(b, m, deterministic)
+}
+func (m *ListJobsRequest) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_ListJobsRequest.Merge(m, src)
+}
+func (m *ListJobsRequest) XXX_Size() int {
+	return xxx_messageInfo_ListJobsRequest.Size(m)
+}
+func (m *ListJobsRequest) XXX_DiscardUnknown() {
+	xxx_messageInfo_ListJobsRequest.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_ListJobsRequest proto.InternalMessageInfo
+
+func (m *ListJobsRequest) GetRunLimit() int32 {
+	if m != nil {
+		return m.RunLimit
+	}
+	return 0
+}
+
+type ListJobsResponse struct {
+	Jobs                 []*Job   `protobuf:"bytes,1,rep,name=jobs,proto3" json:"jobs,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *ListJobsResponse) Reset()         { *m = ListJobsResponse{} }
+func (m *ListJobsResponse) String() string { return proto.CompactTextString(m) }
+func (*ListJobsResponse) ProtoMessage()    {}
+func (*ListJobsResponse) Descriptor() ([]byte, []int) {
+	return fileDescriptor_958480b1a11f31b5, []int{27}
+}
+
+func (m *ListJobsResponse) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_ListJobsResponse.Unmarshal(m, b)
+}
+func (m *ListJobsResponse) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_ListJobsResponse.Marshal(b, m, deterministic)
+}
+func (m *ListJobsResponse) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_ListJobsResponse.Merge(m, src)
+}
+func (m *ListJobsResponse) XXX_Size() int {
+	return xxx_messageInfo_ListJobsResponse.Size(m)
+}
+func (m *ListJobsResponse) XXX_DiscardUnknown() {
+	xxx_messageInfo_ListJobsResponse.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_ListJobsResponse proto.InternalMessageInfo
+
+func (m *ListJobsResponse) GetJobs() []*Job {
+	if m != nil {
+		return m.Jobs
+	}
+	return nil
+}
+
+type RunJobRequest struct {
+	Name                 string   `protobuf:"bytes,1,opt,name=name,proto3" json:"name,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *RunJobRequest) Reset()         { *m = RunJobRequest{} }
+func (m *RunJobRequest) String() string { return proto.CompactTextString(m) }
+func (*RunJobRequest) ProtoMessage()    {}
+func (*RunJobRequest) Descriptor() ([]byte, []int) {
+	return fileDescriptor_958480b1a11f31b5, []int{28}
+}
+
+func (m *RunJobRequest) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_RunJobRequest.Unmarshal(m, b)
+}
+func (m *RunJobRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_RunJobRequest.Marshal(b, m, deterministic)
+}
+func (m *RunJobRequest) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_RunJobRequest.Merge(m, src)
+}
+func (m *RunJobRequest) XXX_Size() int {
+	return xxx_messageInfo_RunJobRequest.Size(m)
+}
+func (m *RunJobRequest) XXX_DiscardUnknown() {
+	xxx_messageInfo_RunJobRequest.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_RunJobRequest proto.InternalMessageInfo
+
+func (m *RunJobRequest) GetName() string {
+	if m != nil {
+		return m.Name
+	}
+	return ""
+}
+
+type RunJobResponse struct {
+	// The finished run, it failed when error is set
+	Run                  *JobRun  `protobuf:"bytes,1,opt,name=run,proto3" json:"run,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *RunJobResponse) Reset()         { *m = RunJobResponse{} }
+func (m *RunJobResponse) String() string { return proto.CompactTextString(m) }
+func (*RunJobResponse) ProtoMessage()    {}
+func (*RunJobResponse) Descriptor() ([]byte, []int) {
+	return fileDescriptor_958480b1a11f31b5, []int{29}
+}
+
+func (m *RunJobResponse) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_RunJobResponse.Unmarshal(m, b)
+}
+func (m *RunJobResponse) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_RunJobResponse.Marshal(b, m, deterministic)
+}
+func (m *RunJobResponse) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_RunJobResponse.Merge(m, src)
+}
+func (m *RunJobResponse) XXX_Size() int {
+	return xxx_messageInfo_RunJobResponse.Size(m)
+}
+func (m *RunJobResponse) XXX_DiscardUnknown() {
+	xxx_messageInfo_RunJobResponse.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_RunJobResponse proto.InternalMessageInfo
+
+func (m *RunJobResponse) GetRun() *JobRun {
+	if m != nil {
+		return m.Run
+	}
+	return nil
+}
+
+type User struct {
+	Uuid                 string   `protobuf:"bytes,1,opt,name=uuid,proto3" json:"uuid,omitempty"`
+	Email                string   `protobuf:"bytes,2,opt,name=email,proto3" json:"email,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *User) Reset()         { *m = User{} }
+func (m *User) String() string { return proto.CompactTextString(m) }
+func (*User) ProtoMessage()    {}
+func (*User) Descriptor() ([]byte, []int) {
+	return fileDescriptor_958480b1a11f31b5, []int{30}
+}
+
+func (m *User) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_User.Unmarshal(m, b)
+}
+func (m *User) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_User.Marshal(b, m, deterministic)
+}
+func (m *User) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_User.Merge(m, src)
+}
+func (m *User) XXX_Size() int {
+	return xxx_messageInfo_User.Size(m)
+}
+func (m *User) XXX_DiscardUnknown() {
+	xxx_messageInfo_User.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_User proto.InternalMessageInfo
+
+func (m *User) GetUuid() string {
+	if m != nil {
+		return m.Uuid
+	}
+	return ""
+}
+
+func (m *User) GetEmail() string {
+	if m != nil {
+		return m.Email
+	}
+	return ""
+}
+
+type ScopeGrouping struct {
+	Scopes               []string             `protobuf:"bytes,1,rep,name=scopes,proto3" json:"scopes,omitempty"`
+	Expiration           *timestamp.Timestamp `protobuf:"bytes,2,opt,name=expiration,proto3" json:"expiration,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}             `json:"-"`
+	XXX_unrecognized     []byte               `json:"-"`
+	XXX_sizecache        int32                `json:"-"`
+}
+
+func (m *ScopeGrouping) Reset()         { *m = ScopeGrouping{} }
+func (m *ScopeGrouping) String() string { return proto.CompactTextString(m) }
+func (*ScopeGrouping) ProtoMessage()    {}
+func (*ScopeGrouping) Descriptor() ([]byte, []int) {
+	return fileDescriptor_958480b1a11f31b5, []int{31}
+}
+
+func (m *ScopeGrouping) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_ScopeGrouping.Unmarshal(m, b)
+}
+func (m *ScopeGrouping) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_ScopeGrouping.Marshal(b, m, deterministic)
+}
+func (m *ScopeGrouping) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_ScopeGrouping.Merge(m, src)
+}
+func (m *ScopeGrouping) XXX_Size() int {
+	return xxx_messageInfo_ScopeGrouping.Size(m)
+}
+func (m *ScopeGrouping) XXX_DiscardUnknown() {
+	xxx_messageInfo_ScopeGrouping.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_ScopeGrouping proto.InternalMessageInfo
+
+func (m *ScopeGrouping) GetScopes() []string {
+	if m != nil {
+		return m.Scopes
+	}
+	return nil
+}
+
+func (m *ScopeGrouping) GetExpiration() *timestamp.Timestamp {
+	if m != nil {
+		return m.Expiration
+	}
+	return nil
+}
+
+type Session struct {
+	Uuid  string `protobuf:"bytes,1,opt,name=uuid,proto3" json:"uuid,omitempty"`
+	Token string `protobuf:"bytes,2,opt,name=token,proto3" json:"token,omitempty"`
+	Json  string `protobuf:"bytes,3,opt,name=json,proto3" json:"json,omitempty"`
+	// Only set when a session is created or refreshed
+	RefreshToken         string   `protobuf:"bytes,4,opt,name=refresh_token,json=refreshToken,proto3" json:"refresh_token,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *Session) Reset()         { *m = Session{} }
+func (m *Session) String() string { return proto.CompactTextString(m) }
+func (*Session) ProtoMessage()    {}
+func (*Session) Descriptor() ([]byte, []int) {
+	return fileDescriptor_958480b1a11f31b5, []int{32}
+}
+
+func (m *Session) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_Session.Unmarshal(m, b)
+}
+func (m *Session) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_Session.Marshal(b, m, deterministic)
+}
+func (m *Session) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_Session.Merge(m, src)
+}
+func (m *Session) XXX_Size() int {
+	return xxx_messageInfo_Session.Size(m)
+}
+func (m *Session) XXX_DiscardUnknown() {
+	xxx_messageInfo_Session.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_Session proto.InternalMessageInfo
+
+func (m *Session) GetUuid() string {
+	if m != nil {
+		return m.Uuid
+	}
+	return ""
+}
+
+func (m *Session) GetToken() string {
+	if m != nil {
+		return m.Token
+	}
+	return ""
+}
+
+func (m *Session) GetJson() string {
+	if m != nil {
+		return m.Json
+	}
+	return ""
+}
+
+func (m *Session) GetRefreshToken() string {
+	if m != nil {
+		return m.RefreshToken
+	}
+	return ""
+}
+
+type VerificationKey struct {
+	Kid       string `protobuf:"bytes,1,opt,name=kid,proto3" json:"kid,omitempty"`
+	Algorithm string `protobuf:"bytes,2,opt,name=algorithm,proto3" json:"algorithm,omitempty"`
+	// Raw Ed25519 public key, base64url without padding
+	PublicKey string `protobuf:"bytes,3,opt,name=public_key,json=publicKey,proto3" json:"public_key,omitempty"`
+	// The same key as a PASERK, k2.public.<key>
+	Paserk    string               `protobuf:"bytes,4,opt,name=paserk,proto3" json:"paserk,omitempty"`
+	NotBefore *timestamp.Timestamp `protobuf:"bytes,5,opt,name=not_before,json=notBefore,proto3" json:"not_before,omitempty"`
+	// Unset while the key is active, otherwise the end of its grace window
+	NotAfter             *timestamp.Timestamp `protobuf:"bytes,6,opt,name=not_after,json=notAfter,proto3" json:"not_after,omitempty"`
+	Primary              bool                 `protobuf:"varint,7,opt,name=primary,proto3" json:"primary,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}             `json:"-"`
+	XXX_unrecognized     []byte               `json:"-"`
+	XXX_sizecache        int32                `json:"-"`
+}
+
+func (m *VerificationKey) Reset()         { *m = VerificationKey{} }
+func (m *VerificationKey) String() string { return proto.CompactTextString(m) }
+func (*VerificationKey) ProtoMessage()    {}
+func (*VerificationKey) Descriptor() ([]byte, []int) {
+	return fileDescriptor_958480b1a11f31b5, []int{33}
+}
+
+func (m *VerificationKey) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_VerificationKey.Unmarshal(m, b)
+}
+func (m *VerificationKey) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_VerificationKey.Marshal(b, m, deterministic)
+}
+func (m *VerificationKey) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_VerificationKey.Merge(m, src)
+}
+func (m *VerificationKey) XXX_Size() int {
+	return xxx_messageInfo_VerificationKey.Size(m)
+}
+func (m *VerificationKey) XXX_DiscardUnknown() {
+	xxx_messageInfo_VerificationKey.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_VerificationKey proto.InternalMessageInfo
+
+func (m *VerificationKey) GetKid() string {
+	if m != nil {
+		return m.Kid
+	}
+	return ""
+}
+
+func (m *VerificationKey) GetAlgorithm() string {
+	if m != nil {
+		return m.Algorithm
+	}
+	return ""
+}
+
+func (m *VerificationKey) GetPublicKey() string {
+	if m != nil {
+		return m.PublicKey
+	}
+	return ""
+}
+
+func (m *VerificationKey) GetPaserk() string {
+	if m != nil {
+		return m.Paserk
+	}
+	return ""
+}
+
+func (m *VerificationKey) GetNotBefore() *timestamp.Timestamp {
+	if m != nil {
+		return m.NotBefore
+	}
+	return nil
+}
+
+func (m *VerificationKey) GetNotAfter() *timestamp.Timestamp {
+	if m != nil {
+		return m.NotAfter
+	}
+	return nil
+}
+
+func (m *VerificationKey) GetPrimary() bool {
+	if m != nil {
+		return m.Primary
+	}
+	return false
+}
+
+type Job struct {
+	Name string `protobuf:"bytes,1,opt,name=name,proto3" json:"name,omitempty"`
+	// Empty when the job only runs through RunJob
+	Schedule string               `protobuf:"bytes,2,opt,name=schedule,proto3" json:"schedule,omitempty"`
+	NextRun  *timestamp.Timestamp `protobuf:"bytes,3,opt,name=next_run,json=nextRun,proto3" json:"next_run,omitempty"`
+	// Latest first
+	Runs                 []*JobRun `protobuf:"bytes,4,rep,name=runs,proto3" json:"runs,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}  `json:"-"`
+	XXX_unrecognized     []byte    `json:"-"`
+	XXX_sizecache        int32     `json:"-"`
+}
+
+func (m *Job) Reset()         { *m = Job{} }
+func (m *Job) String() string { return proto.CompactTextString(m) }
+func (*Job) ProtoMessage()    {}
+func (*Job) Descriptor() ([]byte, []int) {
+	return fileDescriptor_958480b1a11f31b5, []int{34}
+}
+
+func (m *Job) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_Job.Unmarshal(m, b)
+}
+func (m *Job) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_Job.Marshal(b, m, deterministic)
+}
+func (m *Job) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_Job.Merge(m, src)
+}
+func (m *Job) XXX_Size() int {
+	return xxx_messageInfo_Job.Size(m)
+}
+func (m *Job) XXX_DiscardUnknown() {
+	xxx_messageInfo_Job.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_Job proto.InternalMessageInfo
+
+func (m *Job) GetName() string {
+	if m != nil {
+		return m.Name
+	}
+	return ""
+}
+
+func (m *Job) GetSchedule() string {
+	if m != nil {
+		return m.Schedule
+	}
+	return ""
+}
+
+func (m *Job) GetNextRun() *timestamp.Timestamp {
+	if m != nil {
+		return m.NextRun
+	}
+	return nil
+}
+
+func (m *Job) GetRuns() []*JobRun {
+	if m != nil {
+		return m.Runs
+	}
+	return nil
+}
+
+type JobRun struct {
+	Uuid string `protobuf:"bytes,1,opt,name=uuid,proto3" json:"uuid,omitempty"`
+	Job  string `protobuf:"bytes,2,opt,name=job,proto3" json:"job,omitempty"`
+	// schedule or manual
+	TriggeredBy string `protobuf:"bytes,3,opt,name=triggered_by,json=triggeredBy,proto3" json:"triggered_by,omitempty"`
+	// The host that ran the job
+	Runner string `protobuf:"bytes,4,opt,name=runner,proto3" json:"runner,omitempty"`
+	// The tick a scheduled run was for
+	ScheduledAt *timestamp.Timestamp `protobuf:"bytes,5,opt,name=scheduled_at,json=scheduledAt,proto3" json:"scheduled_at,omitempty"`
+	StartedAt   *timestamp.Timestamp `protobuf:"bytes,6,opt,name=started_at,json=startedAt,proto3" json:"started_at,omitempty"`
+	// Unset while the job is running
+	FinishedAt           *timestamp.Timestamp `protobuf:"bytes,7,opt,name=finished_at,json=finishedAt,proto3" json:"finished_at,omitempty"`
+	Error                string               `protobuf:"bytes,8,opt,name=error,proto3" json:"error,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}             `json:"-"`
+	XXX_unrecognized     []byte               `json:"-"`
+	XXX_sizecache        int32                `json:"-"`
+}
+
+func (m *JobRun) Reset()         { *m = JobRun{} }
+func (m *JobRun) String() string { return proto.CompactTextString(m) }
+func (*JobRun) ProtoMessage()    {}
+func (*JobRun) Descriptor() ([]byte, []int) {
+	return fileDescriptor_958480b1a11f31b5, []int{35}
+}
+
+func (m *JobRun) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_JobRun.Unmarshal(m, b)
+}
+func (m *JobRun) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_JobRun.Marshal(b, m, deterministic)
+}
+func (m *JobRun) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_JobRun.Merge(m, src)
+}
+func (m *JobRun) XXX_Size() int {
+	return xxx_messageInfo_JobRun.Size(m)
+}
+func (m *JobRun) XXX_DiscardUnknown() {
+	xxx_messageInfo_JobRun.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_JobRun proto.InternalMessageInfo
+
+func (m *JobRun) GetUuid() string {
+	if m != nil {
+		return m.Uuid
+	}
+	return ""
+}
+
+func (m *JobRun) GetJob() string {
+	if m != nil {
+		return m.Job
+	}
+	return ""
+}
+
+func (m *JobRun) GetTriggeredBy() string {
+	if m != nil {
+		return m.TriggeredBy
+	}
+	return ""
+}
+
+func (m *JobRun) GetRunner() string {
+	if m != nil {
+		return m.Runner
+	}
+	return ""
+}
+
+func (m *JobRun) GetScheduledAt() *timestamp.Timestamp {
+	if m != nil {
+		return m.ScheduledAt
+	}
+	return nil
+}
+
+func (m *JobRun) GetStartedAt() *timestamp.Timestamp {
+	if m != nil {
+		return m.StartedAt
+	}
+	return nil
+}
+
+func (m *JobRun) GetFinishedAt() *timestamp.Timestamp {
+	if m != nil {
+		return m.FinishedAt
+	}
+	return nil
+}
+
+func (m *JobRun) GetError() string {
+	if m != nil {
+		return m.Error
+	}
+	return ""
+}
+
+func init() {
+	proto.RegisterEnum("proto.ResetUserPasswordResponse_Status", ResetUserPasswordResponse_Status_name, ResetUserPasswordResponse_Status_value)
+	proto.RegisterEnum("proto.AuthorizeResponse_Decision", AuthorizeResponse_Decision_name, AuthorizeResponse_Decision_value)
+	proto.RegisterEnum("proto.AuthorizeResponse_DenyReason", AuthorizeResponse_DenyReason_name, AuthorizeResponse_DenyReason_value)
+	proto.RegisterType((*GetUserRequest)(nil), "proto.GetUserRequest")
+	proto.RegisterType((*GetUserResponse)(nil), "proto.GetUserResponse")
+	proto.RegisterType((*CreateUserRequest)(nil), "proto.CreateUserRequest")
+	proto.RegisterType((*CreateUserResponse)(nil), "proto.CreateUserResponse")
+	proto.RegisterType((*CreateGuestUserRequest)(nil), "proto.CreateGuestUserRequest")
+	proto.RegisterType((*CreateGuestUserResponse)(nil), "proto.CreateGuestUserResponse")
+	proto.RegisterType((*CreateSessionRequest)(nil), "proto.CreateSessionRequest")
+	proto.RegisterType((*CreateSessionResponse)(nil), "proto.CreateSessionResponse")
+	proto.RegisterType((*CreatePasswordResetTokenRequest)(nil), "proto.CreatePasswordResetTokenRequest")
+	proto.RegisterType((*CreatePasswordResetTokenResponse)(nil), "proto.CreatePasswordResetTokenResponse")
+	proto.RegisterType((*ResetUserPasswordRequest)(nil), "proto.ResetUserPasswordRequest")
+	proto.RegisterType((*ResetUserPasswordResponse)(nil), "proto.ResetUserPasswordResponse")
+	proto.RegisterType((*DeleteSessionRequest)(nil), "proto.DeleteSessionRequest")
+	proto.RegisterType((*DeleteSessionResponse)(nil), "proto.DeleteSessionResponse")
+	proto.RegisterType((*GetSessionRequest)(nil), "proto.GetSessionRequest")
+	proto.RegisterType((*GetSessionResponse)(nil), "proto.GetSessionResponse")
+	proto.RegisterType((*ElevateSessionRequest)(nil), "proto.ElevateSessionRequest")
+	proto.RegisterType((*ElevateSessionResponse)(nil), "proto.ElevateSessionResponse")
+	proto.RegisterType((*RefreshSessionRequest)(nil), "proto.RefreshSessionRequest")
+	proto.RegisterType((*RefreshSessionResponse)(nil), "proto.RefreshSessionResponse")
+	proto.RegisterType((*AuthorizeRequest)(nil), "proto.AuthorizeRequest")
+	proto.RegisterType((*AuthorizeResponse)(nil), "proto.AuthorizeResponse")
+	proto.RegisterType((*RevokeSessionsRequest)(nil), "proto.RevokeSessionsRequest")
+	proto.RegisterType((*RevokeSessionsResponse)(nil), "proto.RevokeSessionsResponse")
+	proto.RegisterType((*GetVerificationKeysRequest)(nil), "proto.GetVerificationKeysRequest")
+	proto.RegisterType((*GetVerificationKeysResponse)(nil), "proto.GetVerificationKeysResponse")
+	proto.RegisterType((*ListJobsRequest)(nil), "proto.ListJobsRequest")
+	proto.RegisterType((*ListJobsResponse)(nil), "proto.ListJobsResponse")
+	proto.RegisterType((*RunJobRequest)(nil), "proto.RunJobRequest")
+	proto.RegisterType((*RunJobResponse)(nil), "proto.RunJobResponse")
+	proto.RegisterType((*User)(nil), "proto.User")
+	proto.RegisterType((*ScopeGrouping)(nil), "proto.ScopeGrouping")
+	proto.RegisterType((*Session)(nil), "proto.Session")
+	proto.RegisterType((*VerificationKey)(nil), "proto.VerificationKey")
+	proto.RegisterType((*Job)(nil), "proto.Job")
+	proto.RegisterType((*JobRun)(nil), "proto.JobRun")
+}
+
+func init() { proto.RegisterFile("fingerprint.proto", fileDescriptor_958480b1a11f31b5) }
+
+var fileDescriptor_958480b1a11f31b5 = []byte{
+	// 2046 bytes of a gzipped FileDescriptorProto
+	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0xbc, 0x58, 0x4f, 0x4f, 0x23, 0xc9,
+	0x15, 0x1f, 0xff, 0xc1, 0xd8, 0xcf, 0xd8, 0x98, 0x02, 0x1b, 0xd3, 0xc0, 0x00, 0x35, 0xda, 0xcc,
+	0x84, 0x03, 0xec, 0xb2, 0x8a, 0x46, 0x61, 0x76, 0x14, 0x19, 0xf0, 0x32, 0xcc, 0x30, 0x30, 0xdb,
+	0x36, 0x3b, 0x4a, 0x0e, 0x69, 0xb5, 0xed, 0xb2, 0xe9, 0xc1, 0xee, 0xf6, 0x56, 0x75, 0x93, 0x25,
+	0xa3, 0x3d, 0x24, 0x87, 0x28, 0x52, 0x0e, 0x39, 0xe4, 0x5b, 0xe4, 0x1a, 0x29, 0x52, 0x4e, 0x39,
+	0xe5, 0x13, 0xe4, 0x2b, 0xe4, 0x53, 0xe4, 0x14, 0xd5, 0x9f, 0x6e, 0x77, 0xb7, 0x6d, 0xbc, 0x44,
+	0xc9, 0x9e, 0xec, 0x7a, 0xef, 0xd5, 0xfb, 0xbd, 0x57, 0xef, 0x4f, 0xbd, 0x6a, 0x58, 0xea, 0x5a,
+	0x76, 0x8f, 0xd0, 0x21, 0xb5, 0x6c, 0x77, 0x6f, 0x48, 0x1d, 0xd7, 0x41, 0x73, 0xe2, 0x47, 0xdb,
+	0xe8, 0x39, 0x4e, 0xaf, 0x4f, 0xf6, 0xcd, 0xa1, 0xb5, 0x6f, 0xda, 0xb6, 0xe3, 0x9a, 0xae, 0xe5,
+	0xd8, 0x4c, 0x0a, 0x69, 0x8f, 0x15, 0x57, 0xac, 0x5a, 0x5e, 0x77, 0xbf, 0xe3, 0x51, 0x21, 0xa0,
+	0xf8, 0x5b, 0x71, 0xbe, 0x6b, 0x0d, 0x08, 0x73, 0xcd, 0xc1, 0x50, 0x0a, 0xe0, 0x73, 0x28, 0x9e,
+	0x12, 0xf7, 0x8a, 0x11, 0xaa, 0x93, 0x6f, 0x3c, 0xc2, 0x5c, 0xb4, 0x02, 0x69, 0xcf, 0xb3, 0x3a,
+	0xd5, 0xc4, 0x76, 0xe2, 0x59, 0xee, 0xd5, 0x23, 0x5d, 0xac, 0x50, 0x05, 0xe6, 0xc8, 0xc0, 0xb4,
+	0xfa, 0xd5, 0xa4, 0x22, 0xcb, 0xe5, 0xd1, 0x02, 0x80, 0xd5, 0x21, 0xb6, 0x6b, 0x75, 0x2d, 0x42,
+	0xf1, 0x01, 0x2c, 0x06, 0xda, 0xd8, 0xd0, 0xb1, 0x19, 0x41, 0x5b, 0x90, 0xf6, 0x18, 0xa1, 0x42,
+	0x5d, 0xfe, 0x20, 0x2f, 0x61, 0xf7, 0x84, 0x88, 0x60, 0xe0, 0xbf, 0x25, 0x60, 0xe9, 0x98, 0x12,
+	0xd3, 0x25, 0x51, 0x2b, 0x14, 0x9e, 0x30, 0x43, 0xa1, 0x21, 0x0d, 0xb2, 0x43, 0x93, 0xb1, 0x5f,
+	0x39, 0xb4, 0x23, 0x0d, 0xd1, 0x83, 0x35, 0xfa, 0x1c, 0xca, 0xfe, 0x7f, 0xa3, 0xed, 0xd8, 0x5d,
+	0x8b, 0x0e, 0xc4, 0x49, 0x54, 0x53, 0x42, 0x70, 0xc5, 0x67, 0x1e, 0x87, 0x78, 0xe8, 0x25, 0x2c,
+	0xb2, 0xb6, 0x33, 0x24, 0x46, 0x8f, 0x3a, 0xde, 0xd0, 0xb2, 0x7b, 0xac, 0x9a, 0xde, 0x4e, 0x3d,
+	0xcb, 0x1f, 0xac, 0x28, 0x43, 0x1b, 0x9c, 0x7b, 0xaa, 0x98, 0x7a, 0x91, 0x85, 0x97, 0x0c, 0x1b,
+	0x80, 0xc2, 0xa6, 0x7f, 0x4f, 0x97, 0xd1, 0x33, 0x98, 0x67, 0x84, 0x31, 0x6e, 0x5c, 0x52, 0xc8,
+	0x14, 0x7d, 0x34, 0x49, 0xd5, 0x7d, 0x36, 0x1e, 0x40, 0x45, 0x02, 0x9c, 0xf2, 0x53, 0x99, 0x7d,
+	0x40, 0x13, 0xfc, 0x49, 0x3e, 0xc0, 0x9f, 0x0e, 0xac, 0x8e, 0xc1, 0xfd, 0xef, 0x9d, 0xfa, 0x47,
+	0x02, 0x56, 0x24, 0x8c, 0xcf, 0xfa, 0xaf, 0x83, 0x3e, 0xc1, 0xdf, 0xd4, 0xf7, 0xf7, 0x17, 0x7d,
+	0x01, 0x0b, 0x56, 0xa7, 0x4f, 0x0c, 0x5e, 0x15, 0x8e, 0xe7, 0x56, 0xd3, 0xc2, 0xf0, 0xb5, 0x3d,
+	0x59, 0x35, 0x7b, 0x7e, 0xd5, 0xec, 0x9d, 0xa8, 0xaa, 0xd2, 0xf3, 0x5c, 0xbc, 0x29, 0xa5, 0x71,
+	0x0d, 0xca, 0x31, 0x37, 0xd4, 0x59, 0x85, 0x8e, 0x22, 0x71, 0xff, 0x51, 0x3c, 0x87, 0x2d, 0xa9,
+	0xe2, 0x9d, 0xf2, 0x48, 0x27, 0x8c, 0xb8, 0x4d, 0xe7, 0x86, 0xdc, 0x7f, 0x28, 0xb8, 0x09, 0xdb,
+	0xd3, 0x37, 0x2a, 0x33, 0x3e, 0x85, 0x20, 0xe9, 0x0d, 0xca, 0xd9, 0x86, 0xcb, 0xf9, 0x4a, 0x11,
+	0x1a, 0x8e, 0xed, 0xc4, 0x7f, 0x49, 0x40, 0x55, 0x2c, 0x79, 0x5c, 0x47, 0x9a, 0x7f, 0xd0, 0x92,
+	0x9c, 0x66, 0x75, 0x7a, 0xaa, 0xd5, 0x7f, 0x4e, 0xc0, 0xda, 0x04, 0xab, 0xd5, 0x29, 0xfc, 0x0c,
+	0x32, 0xcc, 0x35, 0x5d, 0x8f, 0x09, 0xbb, 0x8b, 0x07, 0x4f, 0x55, 0x2c, 0xa6, 0xee, 0xd8, 0x6b,
+	0x08, 0x71, 0x5d, 0x6d, 0xc3, 0xe7, 0x90, 0x91, 0x14, 0x54, 0x04, 0x68, 0x5c, 0x1d, 0x1f, 0xd7,
+	0x1b, 0x8d, 0x2f, 0xaf, 0xce, 0x4b, 0x8f, 0x50, 0x19, 0x96, 0xde, 0xd5, 0x1a, 0x8d, 0xf7, 0x97,
+	0xfa, 0x89, 0xf1, 0xf6, 0xac, 0xf1, 0xb6, 0xd6, 0x3c, 0x7e, 0x55, 0x4a, 0xa0, 0x75, 0x58, 0xbd,
+	0xb8, 0x34, 0xc4, 0xea, 0xec, 0xe2, 0xd4, 0xd0, 0xeb, 0x8d, 0x7a, 0xd3, 0x68, 0x5e, 0xbe, 0xa9,
+	0x5f, 0x94, 0x92, 0x78, 0x17, 0x56, 0x4e, 0x48, 0x9f, 0x8c, 0xe5, 0x3e, 0x0a, 0xb7, 0x5d, 0xd9,
+	0x74, 0xf1, 0x73, 0x28, 0xc7, 0x64, 0x95, 0x4f, 0x8f, 0x01, 0x98, 0xd7, 0x6e, 0x13, 0xc6, 0xba,
+	0x9e, 0x8c, 0x47, 0x56, 0x0f, 0x51, 0xf0, 0x8f, 0x61, 0xe9, 0x94, 0xb8, 0xe3, 0xd5, 0x15, 0x8e,
+	0xbf, 0x5c, 0xe0, 0x7f, 0xa7, 0x00, 0x85, 0x65, 0x1f, 0x9a, 0xc2, 0xa8, 0x0a, 0xf3, 0x94, 0xdc,
+	0x3a, 0x37, 0x44, 0xc6, 0x3f, 0xab, 0xfb, 0x4b, 0xf4, 0x09, 0x14, 0xd5, 0x5f, 0x83, 0x12, 0x93,
+	0x05, 0x71, 0x2f, 0x28, 0xaa, 0x2e, 0x88, 0xe8, 0xa7, 0x00, 0xbe, 0x98, 0xe9, 0x97, 0xa0, 0x36,
+	0x56, 0x82, 0x4d, 0xff, 0xe2, 0xd2, 0x73, 0x4a, 0xba, 0xe6, 0xa2, 0x27, 0x50, 0x30, 0xdb, 0xae,
+	0x75, 0x4b, 0x0c, 0x51, 0xd8, 0xac, 0x3a, 0xb7, 0x9d, 0x7a, 0x96, 0xd3, 0x17, 0x24, 0x51, 0xd4,
+	0x3e, 0x43, 0xaf, 0xa1, 0x12, 0x16, 0x0a, 0xb5, 0x8a, 0xcc, 0x3d, 0xad, 0x62, 0x25, 0xa4, 0x63,
+	0xd4, 0x30, 0xce, 0x61, 0x95, 0x7c, 0x3b, 0xb4, 0x28, 0xe9, 0x8c, 0x29, 0x9b, 0xbf, 0x47, 0x59,
+	0x59, 0x6d, 0x8a, 0x69, 0x3b, 0x04, 0x10, 0x0c, 0x59, 0x14, 0xd9, 0x99, 0x9e, 0x87, 0xa4, 0xd1,
+	0x31, 0x2c, 0x8a, 0xd6, 0x15, 0x52, 0x90, 0x9b, 0xa9, 0xa0, 0xc8, 0xb7, 0xd4, 0x83, 0x1d, 0xf8,
+	0xf7, 0x09, 0x28, 0xd7, 0xfb, 0xe4, 0x76, 0x62, 0x2b, 0x1e, 0x4f, 0x96, 0xff, 0x63, 0x2b, 0xc6,
+	0x47, 0x50, 0x89, 0x5b, 0xf2, 0xe0, 0x6e, 0xfa, 0x05, 0x94, 0x75, 0xd2, 0xa5, 0x84, 0x5d, 0xc7,
+	0xbc, 0x79, 0x02, 0x05, 0x2a, 0x19, 0x91, 0x16, 0xb8, 0xa0, 0x88, 0xb2, 0x8d, 0x1c, 0x41, 0x25,
+	0xbe, 0xfb, 0xc1, 0x16, 0x7c, 0x05, 0xa5, 0x9a, 0xe7, 0x5e, 0x3b, 0xd4, 0xfa, 0x35, 0xb9, 0xff,
+	0x28, 0x9f, 0xc2, 0x22, 0x25, 0xdf, 0x78, 0xa3, 0x54, 0x92, 0x37, 0x75, 0x4e, 0x2f, 0xfa, 0x64,
+	0x99, 0xbe, 0xbc, 0x40, 0x97, 0x42, 0x3a, 0x95, 0x49, 0x2f, 0x21, 0xdb, 0x21, 0x6d, 0x2b, 0xb0,
+	0xa9, 0x78, 0xb0, 0xa3, 0x6c, 0x1a, 0x93, 0xdd, 0x3b, 0x51, 0x82, 0x7a, 0xb0, 0x05, 0x9d, 0x40,
+	0xbe, 0x43, 0xec, 0x3b, 0xbf, 0x2e, 0x93, 0x42, 0xc3, 0x93, 0x7b, 0x34, 0xd8, 0x77, 0xb2, 0x5a,
+	0x75, 0xe8, 0x04, 0xff, 0xd1, 0x0b, 0x28, 0x46, 0x43, 0x2e, 0x0a, 0x7c, 0x5a, 0xc4, 0x0b, 0x91,
+	0x88, 0xa3, 0x17, 0xb0, 0x46, 0x89, 0xe9, 0xb9, 0xd7, 0x7c, 0x7a, 0x6c, 0x8b, 0x7c, 0x34, 0x7c,
+	0xd7, 0x45, 0x17, 0xc8, 0xea, 0xd5, 0xb8, 0x80, 0xae, 0xf8, 0xbc, 0xb5, 0x0c, 0x2c, 0xc6, 0x2c,
+	0xbb, 0x17, 0xad, 0xfc, 0x82, 0xa2, 0xaa, 0xd2, 0xdf, 0x81, 0x05, 0x15, 0x19, 0x43, 0x34, 0xd7,
+	0x8c, 0x88, 0x40, 0x5e, 0xd1, 0xae, 0xf8, 0x60, 0xbb, 0x0e, 0x39, 0x8f, 0x11, 0x2a, 0xf9, 0xf3,
+	0x32, 0xa7, 0x39, 0x81, 0x33, 0xf1, 0x16, 0x64, 0xfd, 0xc3, 0x43, 0x39, 0x98, 0xab, 0x9d, 0x9f,
+	0x5f, 0xbe, 0x2f, 0x3d, 0x42, 0x59, 0x48, 0x9f, 0xd4, 0x2f, 0x7e, 0x5e, 0x4a, 0xe0, 0x5f, 0x02,
+	0x8c, 0xce, 0x86, 0xd3, 0x2f, 0x2e, 0x2f, 0xea, 0xa5, 0x47, 0x08, 0x41, 0xf1, 0xed, 0x59, 0xa3,
+	0xc1, 0xdb, 0x7f, 0xe3, 0xf8, 0xf2, 0x5d, 0xbd, 0x51, 0x4a, 0xa0, 0x4d, 0x58, 0xd3, 0xeb, 0xb5,
+	0xab, 0xe6, 0xab, 0xfa, 0x45, 0xf3, 0xec, 0xb8, 0xd6, 0x3c, 0xbb, 0xbc, 0x30, 0xf4, 0xfa, 0x57,
+	0x57, 0x67, 0x7a, 0xfd, 0xa4, 0x94, 0x44, 0xcb, 0xb0, 0xd8, 0xa8, 0x37, 0x1a, 0x92, 0xfa, 0xf5,
+	0xe5, 0x9b, 0xfa, 0x49, 0x29, 0x85, 0x7f, 0x93, 0xe0, 0x29, 0xcd, 0xdb, 0x9d, 0x4a, 0x35, 0x36,
+	0x4a, 0xe9, 0xa8, 0x6b, 0xfe, 0xb8, 0x1e, 0x71, 0x6e, 0x33, 0xec, 0x9c, 0x3f, 0xb9, 0x07, 0xee,
+	0xa1, 0x0a, 0x64, 0x22, 0x8d, 0x59, 0xad, 0x62, 0x43, 0xfd, 0x39, 0x54, 0xe2, 0x26, 0xa8, 0x24,
+	0x3c, 0x80, 0xb2, 0xdf, 0xb9, 0xc3, 0xb6, 0xf0, 0x9b, 0x96, 0x07, 0x63, 0x59, 0x31, 0x1b, 0x23,
+	0x8b, 0x18, 0xde, 0x00, 0xed, 0x94, 0xb8, 0x5f, 0x13, 0x6a, 0x75, 0x55, 0x50, 0xdf, 0x90, 0x3b,
+	0xdf, 0x2b, 0x7c, 0x06, 0xeb, 0x13, 0xb9, 0x0a, 0x70, 0x17, 0xd2, 0x37, 0xe4, 0x4e, 0xea, 0xcf,
+	0x1f, 0x54, 0x54, 0x9a, 0xc5, 0xc4, 0x75, 0x21, 0x83, 0xf7, 0x60, 0xf1, 0xdc, 0x62, 0xee, 0x6b,
+	0xa7, 0x15, 0x9c, 0xd9, 0x3a, 0xe4, 0xa8, 0x67, 0x1b, 0x7d, 0x6b, 0x60, 0xb9, 0xe2, 0xc0, 0xe6,
+	0xf4, 0x2c, 0xf5, 0xec, 0x73, 0xbe, 0xc6, 0x07, 0x50, 0x1a, 0xc9, 0x07, 0xf7, 0x6c, 0xfa, 0x83,
+	0xd3, 0xf2, 0xf1, 0x40, 0xe1, 0xbd, 0x76, 0x5a, 0xba, 0xa0, 0xe3, 0x27, 0x50, 0xd0, 0x3d, 0x9b,
+	0xaf, 0x47, 0xb7, 0xb8, 0x6d, 0x0e, 0x88, 0x7f, 0x8b, 0xf3, 0xff, 0xf8, 0x33, 0x28, 0xfa, 0x42,
+	0xc1, 0x2c, 0x9d, 0xa2, 0x9e, 0xdf, 0x4b, 0x0a, 0x21, 0xad, 0x9e, 0xad, 0x73, 0x0e, 0xfe, 0x14,
+	0xd2, 0x7c, 0x32, 0x99, 0x34, 0x14, 0x8c, 0xc6, 0xb0, 0x64, 0x78, 0x1e, 0x6c, 0x43, 0x21, 0x52,
+	0x6d, 0x3c, 0xb6, 0xaa, 0x32, 0x64, 0x30, 0xd4, 0x2a, 0x76, 0xe7, 0x24, 0x1f, 0x72, 0xe7, 0xe0,
+	0x3e, 0xcc, 0xab, 0x58, 0x4e, 0xb3, 0x4c, 0x36, 0xba, 0x64, 0xb8, 0xd1, 0x21, 0x48, 0x7f, 0x18,
+	0xa5, 0x98, 0xf8, 0x3f, 0xde, 0x8f, 0xd3, 0x13, 0xfa, 0xf1, 0xef, 0x92, 0xb0, 0x18, 0x0b, 0x2d,
+	0x2a, 0x41, 0xea, 0x26, 0x40, 0xe5, 0x7f, 0xd1, 0x06, 0xe4, 0xcc, 0x7e, 0xcf, 0xa1, 0x96, 0x7b,
+	0x3d, 0x50, 0xc0, 0x23, 0x02, 0xda, 0x04, 0x18, 0x7a, 0xad, 0xbe, 0xd5, 0x36, 0x6e, 0xc8, 0x9d,
+	0x32, 0x21, 0x27, 0x29, 0x5c, 0x5d, 0x05, 0x32, 0x43, 0x93, 0x11, 0x7a, 0xa3, 0x0c, 0x50, 0x2b,
+	0x3e, 0x92, 0xd8, 0x8e, 0x6b, 0xb4, 0x48, 0xd7, 0xa1, 0xa4, 0x3a, 0x37, 0x7b, 0x24, 0xb1, 0x1d,
+	0xf7, 0x48, 0x08, 0xa3, 0xe7, 0xc0, 0x17, 0x86, 0xd9, 0x75, 0x09, 0xad, 0x66, 0x66, 0xee, 0xcc,
+	0xda, 0x8e, 0x5b, 0xe3, 0xb2, 0x7c, 0x8e, 0x1a, 0x52, 0x6b, 0x60, 0xd2, 0x3b, 0xd1, 0x86, 0xb2,
+	0xba, 0xbf, 0xc4, 0x7f, 0x4c, 0x40, 0xea, 0xb5, 0xd3, 0x9a, 0x94, 0x5c, 0xfc, 0x46, 0x66, 0xed,
+	0x6b, 0xd2, 0xf1, 0xfa, 0xc4, 0xbf, 0x91, 0xfd, 0x35, 0xfa, 0x09, 0x64, 0x6d, 0xf2, 0xad, 0x6b,
+	0xf0, 0x5c, 0x4b, 0xcd, 0xb4, 0x64, 0x9e, 0xcb, 0xea, 0x9e, 0x8d, 0x76, 0x20, 0x4d, 0x3d, 0xdb,
+	0x7f, 0x08, 0xc7, 0xd2, 0x53, 0xb0, 0xf0, 0x5f, 0x93, 0x90, 0x91, 0x84, 0x89, 0x89, 0x50, 0x82,
+	0xd4, 0x07, 0xa7, 0xa5, 0xec, 0xe1, 0x7f, 0x79, 0x23, 0x76, 0xa9, 0xd5, 0xeb, 0x11, 0x7e, 0xdd,
+	0xb5, 0xfc, 0x48, 0xe4, 0x03, 0xda, 0x91, 0x88, 0x05, 0xf5, 0x6c, 0x9b, 0x50, 0x3f, 0x16, 0x72,
+	0x85, 0x5e, 0xc2, 0x82, 0xef, 0x91, 0x18, 0x10, 0x67, 0x47, 0x23, 0x1f, 0xc8, 0xd7, 0x5c, 0x1e,
+	0x4a, 0xe6, 0x9a, 0xd4, 0x95, 0x9b, 0x67, 0x07, 0x24, 0xa7, 0xa4, 0x6b, 0x2e, 0x7a, 0x01, 0xf9,
+	0xae, 0x65, 0x5b, 0xec, 0x5a, 0xee, 0x9d, 0x9f, 0x5d, 0x2b, 0xbe, 0x78, 0x4d, 0xbe, 0x96, 0x28,
+	0x75, 0x68, 0x35, 0xab, 0xca, 0x94, 0x2f, 0x0e, 0xfe, 0xbe, 0x00, 0xe8, 0xcb, 0xd1, 0xa7, 0x9e,
+	0x06, 0xa1, 0xb7, 0x56, 0x9b, 0x20, 0x03, 0xe6, 0xd5, 0x77, 0x13, 0x54, 0x56, 0xe7, 0x1d, 0xfd,
+	0x2a, 0xa3, 0x55, 0xe2, 0x64, 0xd9, 0x4a, 0xf0, 0x27, 0xbf, 0xfd, 0xe7, 0xbf, 0xfe, 0x94, 0xdc,
+	0x42, 0xa5, 0xfd, 0xdb, 0xcf, 0xf6, 0x3d, 0x46, 0x28, 0xdb, 0xff, 0xc8, 0x63, 0xf0, 0xdd, 0x2f,
+	0xf2, 0x28, 0x17, 0xd0, 0xd0, 0x7b, 0x80, 0xd1, 0x87, 0x0a, 0x54, 0x55, 0xca, 0xc6, 0x3e, 0xbb,
+	0x68, 0x6b, 0x13, 0x38, 0x0a, 0x69, 0x45, 0x20, 0x15, 0xf1, 0x48, 0xeb, 0x61, 0x62, 0x17, 0xf5,
+	0x60, 0x31, 0xf6, 0xc5, 0x00, 0x6d, 0x46, 0x74, 0xc4, 0x3f, 0x5c, 0x68, 0x8f, 0xa7, 0xb1, 0x15,
+	0x4e, 0x59, 0xe0, 0x2c, 0x62, 0xe0, 0x38, 0x3d, 0xce, 0x16, 0x40, 0x7f, 0x48, 0x40, 0x75, 0xda,
+	0x8b, 0x17, 0xfd, 0x28, 0xa2, 0x73, 0xea, 0x5b, 0x5a, 0x7b, 0x3a, 0x53, 0x4e, 0x19, 0xf1, 0x58,
+	0x18, 0x51, 0xc5, 0xcb, 0xdc, 0x88, 0xe8, 0x73, 0x54, 0x58, 0x33, 0x04, 0x74, 0x35, 0xec, 0xa8,
+	0x23, 0xf2, 0xf5, 0xa0, 0xad, 0xe9, 0x4f, 0x4b, 0x89, 0xbf, 0x3d, 0xeb, 0xed, 0x89, 0x57, 0x05,
+	0xf0, 0x92, 0xb6, 0x10, 0x06, 0xe6, 0x88, 0x6d, 0x28, 0x44, 0x3e, 0x36, 0xa0, 0xf5, 0x88, 0x2f,
+	0xd1, 0x81, 0x57, 0xdb, 0x98, 0xcc, 0x8c, 0x82, 0x60, 0x01, 0xa2, 0x6e, 0x6e, 0x15, 0xcd, 0x42,
+	0xe4, 0xc1, 0x19, 0x80, 0x4c, 0x7a, 0xb2, 0x6a, 0x1b, 0x93, 0x99, 0x0a, 0x64, 0x5d, 0x80, 0x94,
+	0x77, 0x97, 0xc3, 0x20, 0x2a, 0x39, 0x51, 0x1b, 0x60, 0xf4, 0xe8, 0x0c, 0xf2, 0x71, 0xec, 0xcd,
+	0xaa, 0xad, 0x4d, 0xe0, 0x28, 0xfd, 0xdb, 0x42, 0xbf, 0x76, 0x98, 0xd8, 0xc5, 0xe5, 0x88, 0x1f,
+	0xb7, 0x66, 0xdf, 0xe2, 0xa1, 0x41, 0x36, 0x14, 0xa3, 0x4f, 0x0a, 0xe4, 0x5b, 0x3c, 0xf1, 0xcd,
+	0xa3, 0x6d, 0x4e, 0xe1, 0x2a, 0xc0, 0x2d, 0x01, 0xb8, 0x86, 0x57, 0x22, 0x68, 0x44, 0x0a, 0xf3,
+	0xd3, 0xb3, 0xa1, 0x18, 0x7d, 0x40, 0x04, 0x78, 0x13, 0x5f, 0x25, 0xda, 0xe6, 0x14, 0xee, 0xbd,
+	0x78, 0xea, 0x8a, 0xe4, 0x78, 0x03, 0x8e, 0x17, 0x1e, 0xcc, 0x42, 0x78, 0x13, 0x46, 0x46, 0x6d,
+	0x73, 0x0a, 0x37, 0x9a, 0xf3, 0xfc, 0x40, 0x97, 0x63, 0x90, 0x5c, 0x1e, 0x99, 0x90, 0x0b, 0x5e,
+	0x06, 0x68, 0x75, 0xfc, 0xad, 0x20, 0x41, 0xaa, 0xd3, 0x1e, 0x11, 0x78, 0x47, 0xe8, 0x5f, 0xc7,
+	0x95, 0x88, 0x72, 0xd3, 0x97, 0xe3, 0x1e, 0x7d, 0x84, 0xe5, 0x09, 0xe3, 0x1f, 0xda, 0x19, 0x65,
+	0xc1, 0x94, 0xc1, 0x51, 0xc3, 0xf7, 0x89, 0x28, 0x03, 0x36, 0x85, 0x01, 0xab, 0x48, 0xa4, 0xcb,
+	0x6d, 0x48, 0x8a, 0x0f, 0x07, 0x0c, 0xbd, 0x85, 0xac, 0x3f, 0x00, 0x22, 0xbf, 0xdd, 0xc6, 0x26,
+	0x48, 0x6d, 0x75, 0x8c, 0xae, 0x74, 0x97, 0x84, 0x6e, 0x40, 0x59, 0xae, 0x9b, 0xcf, 0x86, 0xe8,
+	0x0a, 0x32, 0x72, 0xec, 0x43, 0xfe, 0x73, 0x28, 0x32, 0x2a, 0x6a, 0xe5, 0x18, 0x75, 0x52, 0xe7,
+	0xe1, 0x8a, 0xf6, 0x3f, 0xf2, 0x8b, 0xfe, 0xbb, 0x43, 0xea, 0xd9, 0x87, 0x89, 0xdd, 0x56, 0x46,
+	0xec, 0xfa, 0xfc, 0x3f, 0x03, 0x00, 0xa7, 0x4a, 0x69, 0x21, 0x32, 0x18, 0x00, 0x00,
+}
+
+// Reference imports to suppress errors if they are not otherwise used.
+var _ context.Context
+var _ grpc.ClientConnInterface
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+const _ = grpc.SupportPackageIsVersion6
+
+// FingerprintServiceClient is the client API for FingerprintService service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://godoc.org/google.golang.org/grpc#ClientConn.NewStream.
 type FingerprintServiceClient interface {
 	GetUser(ctx context.Context, in *GetUserRequest, opts ...grpc.CallOption) (*GetUserResponse, error)
 	CreateUser(ctx context.Context, in *CreateUserRequest, opts ...grpc.CallOption) (*CreateUserResponse, error)
@@ -1109,14 +2212,22 @@ type FingerprintServiceClient interface {
 	UpdateUserPassword(ctx context.Context, in *ResetUserPasswordRequest, opts ...grpc.CallOption) (*ResetUserPasswordResponse, error)
 	CreateSession(ctx context.Context, in *CreateSessionRequest, opts ...grpc.CallOption) (*CreateSessionResponse, error)
 	DeleteSession(ctx context.Context, in *DeleteSessionRequest, opts ...grpc.CallOption) (*DeleteSessionResponse, error)
+	// Tokens are always sent in the body so they don't end up in access logs
 	GetSession(ctx context.Context, in *GetSessionRequest, opts ...grpc.CallOption) (*GetSessionResponse, error)
+	ElevateSession(ctx context.Context, in *ElevateSessionRequest, opts ...grpc.CallOption) (*ElevateSessionResponse, error)
+	RefreshSession(ctx context.Context, in *RefreshSessionRequest, opts ...grpc.CallOption) (*RefreshSessionResponse, error)
+	RevokeSessions(ctx context.Context, in *RevokeSessionsRequest, opts ...grpc.CallOption) (*RevokeSessionsResponse, error)
+	Authorize(ctx context.Context, in *AuthorizeRequest, opts ...grpc.CallOption) (*AuthorizeResponse, error)
+	GetVerificationKeys(ctx context.Context, in *GetVerificationKeysRequest, opts ...grpc.CallOption) (*GetVerificationKeysResponse, error)
+	ListJobs(ctx context.Context, in *ListJobsRequest, opts ...grpc.CallOption) (*ListJobsResponse, error)
+	RunJob(ctx context.Context, in *RunJobRequest, opts ...grpc.CallOption) (*RunJobResponse, error)
 }
 
 type fingerprintServiceClient struct {
-	cc *grpc.ClientConn
+	cc grpc.ClientConnInterface
 }
 
-func NewFingerprintServiceClient(cc *grpc.ClientConn) FingerprintServiceClient {
+func NewFingerprintServiceClient(cc grpc.ClientConnInterface) FingerprintServiceClient {
 	return &fingerprintServiceClient{cc}
 }
 
@@ -1192,6 +2303,69 @@ func (c *fingerprintServiceClient) GetSession(ctx context.Context, in *GetSessio
 	return out, nil
 }
 
+func (c *fingerprintServiceClient) ElevateSession(ctx context.Context, in *ElevateSessionRequest, opts ...grpc.CallOption) (*ElevateSessionResponse, error) {
+	out := new(ElevateSessionResponse)
+	err := c.cc.Invoke(ctx, "/proto.FingerprintService/ElevateSession", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *fingerprintServiceClient) RefreshSession(ctx context.Context, in *RefreshSessionRequest, opts ...grpc.CallOption) (*RefreshSessionResponse, error) {
+	out := new(RefreshSessionResponse)
+	err := c.cc.Invoke(ctx, "/proto.FingerprintService/RefreshSession", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *fingerprintServiceClient) RevokeSessions(ctx context.Context, in *RevokeSessionsRequest, opts ...grpc.CallOption) (*RevokeSessionsResponse, error) {
+	out := new(RevokeSessionsResponse)
+	err := c.cc.Invoke(ctx, "/proto.FingerprintService/RevokeSessions", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *fingerprintServiceClient) Authorize(ctx context.Context, in *AuthorizeRequest, opts ...grpc.CallOption) (*AuthorizeResponse, error) {
+	out := new(AuthorizeResponse)
+	err := c.cc.Invoke(ctx, "/proto.FingerprintService/Authorize", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *fingerprintServiceClient) GetVerificationKeys(ctx context.Context, in *GetVerificationKeysRequest, opts ...grpc.CallOption) (*GetVerificationKeysResponse, error) {
+	out := new(GetVerificationKeysResponse)
+	err := c.cc.Invoke(ctx, "/proto.FingerprintService/GetVerificationKeys", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *fingerprintServiceClient) ListJobs(ctx context.Context, in *ListJobsRequest, opts ...grpc.CallOption) (*ListJobsResponse, error) {
+	out := new(ListJobsResponse)
+	err := c.cc.Invoke(ctx, "/proto.FingerprintService/ListJobs", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *fingerprintServiceClient) RunJob(ctx context.Context, in *RunJobRequest, opts ...grpc.CallOption) (*RunJobResponse, error) {
+	out := new(RunJobResponse)
+	err := c.cc.Invoke(ctx, "/proto.FingerprintService/RunJob", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 // FingerprintServiceServer is the server API for FingerprintService service.
 type FingerprintServiceServer interface {
 	GetUser(context.Context, *GetUserRequest) (*GetUserResponse, error)
@@ -1201,7 +2375,65 @@ type FingerprintServiceServer interface {
 	UpdateUserPassword(context.Context, *ResetUserPasswordRequest) (*ResetUserPasswordResponse, error)
 	CreateSession(context.Context, *CreateSessionRequest) (*CreateSessionResponse, error)
 	DeleteSession(context.Context, *DeleteSessionRequest) (*DeleteSessionResponse, error)
+	// Tokens are always sent in the body so they don't end up in access logs
 	GetSession(context.Context, *GetSessionRequest) (*GetSessionResponse, error)
+	ElevateSession(context.Context, *ElevateSessionRequest) (*ElevateSessionResponse, error)
+	RefreshSession(context.Context, *RefreshSessionRequest) (*RefreshSessionResponse, error)
+	RevokeSessions(context.Context, *RevokeSessionsRequest) (*RevokeSessionsResponse, error)
+	Authorize(context.Context, *AuthorizeRequest) (*AuthorizeResponse, error)
+	GetVerificationKeys(context.Context, *GetVerificationKeysRequest) (*GetVerificationKeysResponse, error)
+	ListJobs(context.Context, *ListJobsRequest) (*ListJobsResponse, error)
+	RunJob(context.Context, *RunJobRequest) (*RunJobResponse, error)
+}
+
+// UnimplementedFingerprintServiceServer can be embedded to have forward compatible implementations.
+type UnimplementedFingerprintServiceServer struct {
+}
+
+func (*UnimplementedFingerprintServiceServer) GetUser(ctx context.Context, req *GetUserRequest) (*GetUserResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetUser not implemented")
+}
+func (*UnimplementedFingerprintServiceServer) CreateUser(ctx context.Context, req *CreateUserRequest) (*CreateUserResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method CreateUser not implemented")
+}
+func (*UnimplementedFingerprintServiceServer) CreateGuestUser(ctx context.Context, req *CreateGuestUserRequest) (*CreateGuestUserResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method CreateGuestUser not implemented")
+}
+func (*UnimplementedFingerprintServiceServer) CreatePasswordResetToken(ctx context.Context, req *CreatePasswordResetTokenRequest) (*CreatePasswordResetTokenResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method CreatePasswordResetToken not implemented")
+}
+func (*UnimplementedFingerprintServiceServer) UpdateUserPassword(ctx context.Context, req *ResetUserPasswordRequest) (*ResetUserPasswordResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method UpdateUserPassword not implemented")
+}
+func (*UnimplementedFingerprintServiceServer) CreateSession(ctx context.Context, req *CreateSessionRequest) (*CreateSessionResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method CreateSession not implemented")
+}
+func (*UnimplementedFingerprintServiceServer) DeleteSession(ctx context.Context, req *DeleteSessionRequest) (*DeleteSessionResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method DeleteSession not implemented")
+}
+func (*UnimplementedFingerprintServiceServer) GetSession(ctx context.Context, req *GetSessionRequest) (*GetSessionResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetSession not implemented")
+}
+func (*UnimplementedFingerprintServiceServer) ElevateSession(ctx context.Context, req *ElevateSessionRequest) (*ElevateSessionResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ElevateSession not implemented")
+}
+func (*UnimplementedFingerprintServiceServer) RefreshSession(ctx context.Context, req *RefreshSessionRequest) (*RefreshSessionResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method RefreshSession not implemented")
+}
+func (*UnimplementedFingerprintServiceServer) RevokeSessions(ctx context.Context, req *RevokeSessionsRequest) (*RevokeSessionsResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method RevokeSessions not implemented")
+}
+func (*UnimplementedFingerprintServiceServer) Authorize(ctx context.Context, req *AuthorizeRequest) (*AuthorizeResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Authorize not implemented")
+}
+func (*UnimplementedFingerprintServiceServer) GetVerificationKeys(ctx context.Context, req *GetVerificationKeysRequest) (*GetVerificationKeysResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetVerificationKeys not implemented")
+}
+func (*UnimplementedFingerprintServiceServer) ListJobs(ctx context.Context, req *ListJobsRequest) (*ListJobsResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ListJobs not implemented")
+}
+func (*UnimplementedFingerprintServiceServer) RunJob(ctx context.Context, req *RunJobRequest) (*RunJobResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method RunJob not implemented")
 }
 
 func RegisterFingerprintServiceServer(s *grpc.Server, srv FingerprintServiceServer) {
@@ -1352,6 +2584,132 @@ func _FingerprintService_GetSession_Handler(srv interface{}, ctx context.Context
 	return interceptor(ctx, in, info, handler)
 }
 
+func _FingerprintService_ElevateSession_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ElevateSessionRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(FingerprintServiceServer).ElevateSession(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/proto.FingerprintService/ElevateSession",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(FingerprintServiceServer).ElevateSession(ctx, req.(*ElevateSessionRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _FingerprintService_RefreshSession_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(RefreshSessionRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(FingerprintServiceServer).RefreshSession(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/proto.FingerprintService/RefreshSession",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(FingerprintServiceServer).RefreshSession(ctx, req.(*RefreshSessionRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _FingerprintService_RevokeSessions_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(RevokeSessionsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(FingerprintServiceServer).RevokeSessions(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/proto.FingerprintService/RevokeSessions",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(FingerprintServiceServer).RevokeSessions(ctx, req.(*RevokeSessionsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _FingerprintService_Authorize_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(AuthorizeRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(FingerprintServiceServer).Authorize(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/proto.FingerprintService/Authorize",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(FingerprintServiceServer).Authorize(ctx, req.(*AuthorizeRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _FingerprintService_GetVerificationKeys_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetVerificationKeysRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(FingerprintServiceServer).GetVerificationKeys(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/proto.FingerprintService/GetVerificationKeys",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(FingerprintServiceServer).GetVerificationKeys(ctx, req.(*GetVerificationKeysRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _FingerprintService_ListJobs_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListJobsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(FingerprintServiceServer).ListJobs(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/proto.FingerprintService/ListJobs",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(FingerprintServiceServer).ListJobs(ctx, req.(*ListJobsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _FingerprintService_RunJob_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(RunJobRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(FingerprintServiceServer).RunJob(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/proto.FingerprintService/RunJob",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(FingerprintServiceServer).RunJob(ctx, req.(*RunJobRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
 var _FingerprintService_serviceDesc = grpc.ServiceDesc{
 	ServiceName: "proto.FingerprintService",
 	HandlerType: (*FingerprintServiceServer)(nil),
@@ -1388,6 +2746,34 @@ var _FingerprintService_serviceDesc = grpc.ServiceDesc{
 			MethodName: "GetSession",
 			Handler:    _FingerprintService_GetSession_Handler,
 		},
+		{
+			MethodName: "ElevateSession",
+			Handler:    _FingerprintService_ElevateSession_Handler,
+		},
+		{
+			MethodName: "RefreshSession",
+			Handler:    _FingerprintService_RefreshSession_Handler,
+		},
+		{
+			MethodName: "RevokeSessions",
+			Handler:    _FingerprintService_RevokeSessions_Handler,
+		},
+		{
+			MethodName: "Authorize",
+			Handler:    _FingerprintService_Authorize_Handler,
+		},
+		{
+			MethodName: "GetVerificationKeys",
+			Handler:    _FingerprintService_GetVerificationKeys_Handler,
+		},
+		{
+			MethodName: "ListJobs",
+			Handler:    _FingerprintService_ListJobs_Handler,
+		},
+		{
+			MethodName: "RunJob",
+			Handler:    _FingerprintService_RunJob_Handler,
+		},
 	},
 	Streams:  []grpc.StreamDesc{},
 	Metadata: "fingerprint.proto",
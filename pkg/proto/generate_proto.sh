@@ -1,3 +1,9 @@
 #!/usr/bin/env bash
 
-protoc ./fingerprint.proto --go_out=plugins=grpc:.
\ No newline at end of file
+# google/api/annotations.proto ships with grpc-gateway under third_party/googleapis
+GOOGLEAPIS=${GOOGLEAPIS:-$(go env GOPATH)/src/github.com/grpc-ecosystem/grpc-gateway/third_party/googleapis}
+
+protoc -I. -I"$GOOGLEAPIS" ./fingerprint.proto \
+    --go_out=plugins=grpc:. \
+    --grpc-gateway_out=logtostderr=true:. \
+    --swagger_out=logtostderr=true:.
@@ -0,0 +1,7 @@
+package proto
+
+import _ "embed"
+
+// OpenAPIDocument describes the REST gateway, it's generated by generate_proto.sh along with the gateway
+//go:embed fingerprint.swagger.json
+var OpenAPIDocument []byte
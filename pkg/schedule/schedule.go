@@ -0,0 +1,226 @@
+// Package schedule parses the cron expressions jobs are scheduled with
+package schedule
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Schedule is when a job runs
+type Schedule interface {
+	// Next is the first time after t the schedule fires, the zero time if it never does
+	Next(t time.Time) time.Time
+	String() string
+}
+
+// descriptors are the shorthands cron implementations commonly accept
+var descriptors = map[string]string{
+	"@yearly":   "0 0 1 1 *",
+	"@annually": "0 0 1 1 *",
+	"@monthly":  "0 0 1 * *",
+	"@weekly":   "0 0 * * 0",
+	"@daily":    "0 0 * * *",
+	"@midnight": "0 0 * * *",
+	"@hourly":   "0 * * * *",
+}
+
+const everyPrefix = "@every "
+
+// Parse reads a five field cron expression, minute hour day-of-month month day-of-week, one of the
+// @hourly style descriptors, or @every followed by a duration. Fields take *, lists, ranges and steps,
+// months and days of the week can also be named. Cron expressions fire in the location of the time
+// passed to Next.
+func Parse(spec string) (Schedule, error) {
+	spec = strings.TrimSpace(spec)
+	if strings.HasPrefix(spec, everyPrefix) {
+		interval, err := time.ParseDuration(strings.TrimSpace(strings.TrimPrefix(spec, everyPrefix)))
+		if err != nil {
+			return nil, fmt.Errorf("%q: %v", spec, err)
+		}
+		if interval < time.Second {
+			return nil, fmt.Errorf("%q: the interval must be at least a second", spec)
+		}
+		return &every{spec: spec, interval: interval}, nil
+	}
+
+	expression := spec
+	if expanded, ok := descriptors[spec]; ok {
+		expression = expanded
+	}
+	fields := strings.Fields(expression)
+	if len(fields) != len(cronFields) {
+		return nil, fmt.Errorf("%q: expected 5 fields, minute hour day-of-month month day-of-week", spec)
+	}
+
+	c := &cron{spec: spec}
+	sets := []*uint64{&c.minute, &c.hour, &c.dayOfMonth, &c.month, &c.dayOfWeek}
+	for i, field := range cronFields {
+		set, err := field.parse(fields[i])
+		if err != nil {
+			return nil, fmt.Errorf("%q: %s: %v", spec, field.name, err)
+		}
+		*sets[i] = set
+	}
+	// 7 is Sunday too
+	if c.dayOfWeek&(1<<7) != 0 {
+		c.dayOfWeek |= 1
+	}
+	c.anyDayOfMonth = strings.HasPrefix(fields[2], "*")
+	c.anyDayOfWeek = strings.HasPrefix(fields[4], "*")
+	return c, nil
+}
+
+// every fires at multiples of its interval since the zero time, so every process running the same
+// schedule agrees on when it fires
+type every struct {
+	spec     string
+	interval time.Duration
+}
+
+func (e *every) Next(t time.Time) time.Time {
+	return t.Truncate(e.interval).Add(e.interval)
+}
+
+func (e *every) String() string {
+	return e.spec
+}
+
+// cron keeps each field as a bit set of the values it matches
+type cron struct {
+	spec                        string
+	minute, hour, dayOfMonth    uint64
+	month, dayOfWeek            uint64
+	anyDayOfMonth, anyDayOfWeek bool
+}
+
+// cronSearchLimit stops Next looking for a date that never comes, like February 30th
+const cronSearchLimit = 5
+
+func (c *cron) Next(t time.Time) time.Time {
+	loc := t.Location()
+	t = t.Truncate(time.Minute).Add(time.Minute)
+	limit := t.AddDate(cronSearchLimit, 0, 0)
+
+	for t.Before(limit) {
+		if !has(c.month, int(t.Month())) {
+			t = time.Date(t.Year(), t.Month()+1, 1, 0, 0, 0, 0, loc)
+			continue
+		}
+		if !c.dayMatches(t) {
+			t = time.Date(t.Year(), t.Month(), t.Day()+1, 0, 0, 0, 0, loc)
+			continue
+		}
+		if !has(c.hour, t.Hour()) {
+			t = time.Date(t.Year(), t.Month(), t.Day(), t.Hour()+1, 0, 0, 0, loc)
+			continue
+		}
+		if !has(c.minute, t.Minute()) {
+			t = t.Add(time.Minute)
+			continue
+		}
+		return t
+	}
+	return time.Time{}
+}
+
+// dayMatches follows cron in matching either day field when both are restricted
+func (c *cron) dayMatches(t time.Time) bool {
+	dayOfMonth := has(c.dayOfMonth, t.Day())
+	dayOfWeek := has(c.dayOfWeek, int(t.Weekday()))
+	if c.anyDayOfMonth || c.anyDayOfWeek {
+		return dayOfMonth && dayOfWeek
+	}
+	return dayOfMonth || dayOfWeek
+}
+
+func (c *cron) String() string {
+	return c.spec
+}
+
+func has(set uint64, value int) bool {
+	return set&(1<<uint(value)) != 0
+}
+
+type cronField struct {
+	name     string
+	min, max int
+	names    []string // names[i] is value min+i
+}
+
+var cronFields = []cronField{
+	{name: "minute", min: 0, max: 59},
+	{name: "hour", min: 0, max: 23},
+	{name: "day-of-month", min: 1, max: 31},
+	{name: "month", min: 1, max: 12, names: []string{"jan", "feb", "mar", "apr", "may", "jun", "jul", "aug", "sep", "oct", "nov", "dec"}},
+	{name: "day-of-week", min: 0, max: 7, names: []string{"sun", "mon", "tue", "wed", "thu", "fri", "sat"}},
+}
+
+// parse turns a comma separated list of values, ranges and steps into a bit set
+func (f cronField) parse(field string) (uint64, error) {
+	var set uint64
+	for _, part := range strings.Split(field, ",") {
+		step := 1
+		if i := strings.Index(part, "/"); i >= 0 {
+			var err error
+			step, err = strconv.Atoi(part[i+1:])
+			if err != nil || step <= 0 {
+				return 0, fmt.Errorf("%q isn't a valid step", part[i+1:])
+			}
+			part = part[:i]
+		}
+
+		var low, high int
+		switch {
+		case part == "*":
+			low, high = f.min, f.max
+		case strings.Contains(part, "-"):
+			bounds := strings.SplitN(part, "-", 2)
+			var err error
+			low, err = f.value(bounds[0])
+			if err != nil {
+				return 0, err
+			}
+			high, err = f.value(bounds[1])
+			if err != nil {
+				return 0, err
+			}
+			if low > high {
+				return 0, fmt.Errorf("range %q is backwards", part)
+			}
+		default:
+			var err error
+			low, err = f.value(part)
+			if err != nil {
+				return 0, err
+			}
+			high = low
+			// 5/15 is every 15 starting at 5
+			if step > 1 {
+				high = f.max
+			}
+		}
+
+		for v := low; v <= high; v += step {
+			set |= 1 << uint(v)
+		}
+	}
+	return set, nil
+}
+
+func (f cronField) value(s string) (int, error) {
+	for i, name := range f.names {
+		if strings.EqualFold(s, name) {
+			return f.min + i, nil
+		}
+	}
+	v, err := strconv.Atoi(s)
+	if err != nil {
+		return 0, fmt.Errorf("%q isn't a number", s)
+	}
+	if v < f.min || v > f.max {
+		return 0, fmt.Errorf("%d is outside %d-%d", v, f.min, f.max)
+	}
+	return v, nil
+}
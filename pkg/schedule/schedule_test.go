@@ -0,0 +1,66 @@
+package schedule
+
+import (
+	"testing"
+	"time"
+)
+
+func at(s string) time.Time {
+	t, err := time.Parse("2006-01-02 15:04", s)
+	if err != nil {
+		panic(err)
+	}
+	return t
+}
+
+func TestNext(t *testing.T) {
+	cases := []struct {
+		spec string
+		from string
+		next string
+	}{
+		{"* * * * *", "2018-11-28 10:15", "2018-11-28 10:16"},
+		{"*/15 * * * *", "2018-11-28 10:15", "2018-11-28 10:30"},
+		{"5/20 * * * *", "2018-11-28 10:50", "2018-11-28 11:05"},
+		{"30 3 * * *", "2018-11-28 10:15", "2018-11-29 03:30"},
+		{"0 9-17/4 * * mon-fri", "2018-11-30 17:00", "2018-12-03 09:00"},
+		{"0 0 1,15 * *", "2018-11-02 00:00", "2018-11-15 00:00"},
+		{"0 0 29 feb *", "2018-03-01 00:00", "2020-02-29 00:00"},
+		// When both day fields are restricted either one matching is enough
+		{"0 0 13 * 5", "2018-11-28 00:00", "2018-11-30 00:00"},
+		{"0 0 * * 7", "2018-11-28 00:00", "2018-12-02 00:00"},
+		{"@hourly", "2018-11-28 10:15", "2018-11-28 11:00"},
+		{"@weekly", "2018-11-28 10:15", "2018-12-02 00:00"},
+		{"@every 10m", "2018-11-28 10:15", "2018-11-28 10:20"},
+	}
+
+	for _, c := range cases {
+		s, err := Parse(c.spec)
+		if err != nil {
+			t.Errorf("%s: %v", c.spec, err)
+			continue
+		}
+		if got := s.Next(at(c.from)); !got.Equal(at(c.next)) {
+			t.Errorf("%s after %s: expected %s, got %s", c.spec, c.from, c.next, got)
+		}
+	}
+}
+
+func TestNextNeverFiring(t *testing.T) {
+	s, err := Parse("0 0 30 2 *")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if next := s.Next(at("2018-11-28 10:15")); !next.IsZero() {
+		t.Errorf("Expected February 30th to never come, got %s", next)
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	for _, spec := range []string{"", "* * * *", "60 * * * *", "* * * 13 *", "5-1 * * * *", "*/0 * * * *", "@every 10", "@every 1ms", "@fortnightly"} {
+		_, err := Parse(spec)
+		if err == nil {
+			t.Errorf("Expected %q to be refused", spec)
+		}
+	}
+}
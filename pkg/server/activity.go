@@ -0,0 +1,105 @@
+package server
+
+import (
+	"github.com/willschroeder/fingerprint/pkg/domain_errors"
+	"github.com/willschroeder/fingerprint/pkg/session_representations"
+	"log"
+	"sync"
+	"time"
+)
+
+// activityTracker keeps last seen times in memory and writes them out in batches, so validating a
+// session doesn't cost a write
+type activityTracker struct {
+	store     SessionStore
+	validator *session_representations.SessionValidator
+	// maxLifetime caps how far activity can keep a session alive past its creation, zero for no cap
+	maxLifetime time.Duration
+
+	mu      sync.Mutex
+	pending map[int]time.Time
+}
+
+func newActivityTracker(store SessionStore, validator *session_representations.SessionValidator, maxLifetime time.Duration) *activityTracker {
+	return &activityTracker{store: store, validator: validator, maxLifetime: maxLifetime, pending: map[int]time.Time{}}
+}
+
+// lastSeen is the latest of what's stored and what's waiting to be written
+func (a *activityTracker) lastSeen(session *Session) time.Time {
+	seen := session.createdAt
+	if session.lastSeenAt.Valid && session.lastSeenAt.Time.After(seen) {
+		seen = session.lastSeenAt.Time
+	}
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if pending, ok := a.pending[session.id]; ok && pending.After(seen) {
+		seen = pending
+	}
+	return seen
+}
+
+// idleExpiration is when the session idles out if it isn't seen again, zero when it never does
+func (a *activityTracker) idleExpiration(session *Session) time.Time {
+	if session.idleTimeout <= 0 {
+		return time.Time{}
+	}
+
+	expiration := a.lastSeen(session).Add(session.idleTimeout)
+	if a.maxLifetime > 0 && expiration.After(session.createdAt.Add(a.maxLifetime)) {
+		expiration = session.createdAt.Add(a.maxLifetime)
+	}
+	return expiration
+}
+
+// seen rejects sessions idle past their timeout, otherwise it slides the idle window forward
+func (a *activityTracker) seen(session *Session) error {
+	idleExpiration := a.idleExpiration(session)
+	if !idleExpiration.IsZero() && a.validator.ValidateExpiration(idleExpiration) != nil {
+		return domain_errors.ExpiredToken("session has been idle too long")
+	}
+	if a.maxLifetime > 0 && a.validator.ValidateExpiration(session.createdAt.Add(a.maxLifetime)) != nil {
+		return domain_errors.ExpiredToken("session has reached its maximum lifetime")
+	}
+
+	a.mu.Lock()
+	a.pending[session.id] = a.validator.Clock()
+	a.mu.Unlock()
+	return nil
+}
+
+func (a *activityTracker) flush() error {
+	a.mu.Lock()
+	batch := a.pending
+	a.pending = map[int]time.Time{}
+	a.mu.Unlock()
+
+	if len(batch) == 0 {
+		return nil
+	}
+
+	err := a.store.UpdateSessionsLastSeen(batch)
+	if err != nil {
+		// Put the batch back so the next flush retries it, newer times win
+		a.mu.Lock()
+		for id, seen := range batch {
+			if pending, ok := a.pending[id]; !ok || seen.After(pending) {
+				a.pending[id] = seen
+			}
+		}
+		a.mu.Unlock()
+	}
+	return err
+}
+
+// run flushes on an interval until the process exits
+func (a *activityTracker) run(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for range ticker.C {
+		err := a.flush()
+		if err != nil {
+			log.Printf("failed to write session last seen times: %v", err)
+		}
+	}
+}
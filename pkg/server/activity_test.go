@@ -0,0 +1,68 @@
+package server
+
+import (
+	"github.com/lib/pq"
+	"github.com/willschroeder/fingerprint/pkg/session_representations"
+	"testing"
+	"time"
+)
+
+func TestActivityTrackerSlidesIdleExpiration(t *testing.T) {
+	now := time.Now()
+	validator := session_representations.NewSessionValidator(0)
+	validator.Clock = func() time.Time { return now }
+	tracker := newActivityTracker(nil, validator, 0)
+
+	session := &Session{id: 1, createdAt: now.Add(-time.Hour), lastSeenAt: pq.NullTime{Time: now.Add(-10 * time.Minute), Valid: true}, idleTimeout: 15 * time.Minute}
+	err := tracker.seen(session)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := tracker.idleExpiration(session); !got.Equal(now.Add(15 * time.Minute)) {
+		t.Errorf("Expected the idle window to slide to %v, got %v", now.Add(15*time.Minute), got)
+	}
+
+	now = now.Add(20 * time.Minute)
+	err = tracker.seen(session)
+	if err == nil {
+		t.Errorf("Expected a session idle for 20 minutes to be rejected")
+	}
+}
+
+func TestActivityTrackerMaxLifetime(t *testing.T) {
+	now := time.Now()
+	validator := session_representations.NewSessionValidator(0)
+	validator.Clock = func() time.Time { return now }
+	tracker := newActivityTracker(nil, validator, time.Hour)
+
+	session := &Session{id: 1, createdAt: now.Add(-50 * time.Minute), lastSeenAt: pq.NullTime{Time: now.Add(-5 * time.Minute), Valid: true}, idleTimeout: 30 * time.Minute}
+	err := tracker.seen(session)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := tracker.idleExpiration(session); !got.Equal(session.createdAt.Add(time.Hour)) {
+		t.Errorf("Expected the idle window to be capped at the maximum lifetime, got %v", got)
+	}
+
+	now = now.Add(11 * time.Minute)
+	err = tracker.seen(session)
+	if err == nil {
+		t.Errorf("Expected a session past its maximum lifetime to be rejected")
+	}
+}
+
+func TestActivityTrackerWithoutIdleTimeout(t *testing.T) {
+	now := time.Now()
+	validator := session_representations.NewSessionValidator(0)
+	validator.Clock = func() time.Time { return now }
+	tracker := newActivityTracker(nil, validator, 0)
+
+	session := &Session{id: 1, createdAt: now.Add(-24 * time.Hour)}
+	err := tracker.seen(session)
+	if err != nil {
+		t.Errorf("Sessions without an idle timeout should never idle out: %v", err)
+	}
+	if !tracker.idleExpiration(session).IsZero() {
+		t.Errorf("Expected no idle expiration")
+	}
+}
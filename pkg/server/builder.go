@@ -1,113 +1,166 @@
 package server
 
 import (
-	"database/sql"
-	"errors"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
 	"github.com/golang/protobuf/ptypes"
 	"github.com/google/uuid"
 	"github.com/thanhpk/randstr"
-	"github.com/willschroeder/fingerprint/pkg/db"
+	"github.com/willschroeder/fingerprint/pkg/domain_errors"
+	"github.com/willschroeder/fingerprint/pkg/passwords"
 	"github.com/willschroeder/fingerprint/pkg/proto"
 	"github.com/willschroeder/fingerprint/pkg/session_representations"
-	"golang.org/x/crypto/bcrypt"
+	"log"
+	"sync"
 	"time"
 )
 
+const refreshTokenBytes = 32
+
 type Builder struct {
-	repo *Repo
-	dao *db.DAO
+	store     Store
+	passwords *passwords.Policy
+	keyring   *session_representations.Keyring
+	// accessTokenTTL cuts tokens short of their session so they're refreshed, zero keeps the session's lifetime
+	accessTokenTTL time.Duration
+	// dummyHash is verified against for unknown emails so they take as long to turn away as wrong passwords
+	dummyHash     string
+	dummyHashOnce sync.Once
 }
 
-func BuildPasswordHash(password string) (string, error) {
-	hash, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+func (b *Builder) buildPasswordHash(password string) (string, error) {
+	hash, err := b.passwords.Hash(password)
 	if err != nil {
-		panic(err)
+		return "", domain_errors.Internal(err)
 	}
 
-	return string(hash), nil
+	return hash, nil
 }
 
-func (b *Builder) buildUser(tx *sql.Tx,  email string, password string, passwordConfirmation string) (*User, error) {
-	if password != passwordConfirmation {
-		return nil, errors.New("password and confirmation don't match")
+// verifyPassword checks the password against the user's stored hash, upgrading the stored hash
+// when it was made with an algorithm or parameters the policy no longer uses
+func (b *Builder) verifyPassword(user *User, password string) error {
+	ok, rehash, err := b.passwords.Verify(user.encryptedPassword, password)
+	if err != nil {
+		return domain_errors.Internal(err)
+	}
+	if !ok {
+		return domain_errors.InvalidCredentials()
 	}
 
-	hash, err := BuildPasswordHash(password)
-	if err != nil {
-		panic(err)
+	if rehash {
+		hash, err := b.buildPasswordHash(password)
+		if err == nil {
+			err = b.store.UpdateUserEncryptedPassword(user.id, hash)
+		}
+		if err != nil {
+			// The password was correct, failing to upgrade the hash shouldn't fail the login
+			log.Printf("failed to rehash password for user %s: %v", user.uuid, err)
+		} else {
+			user.encryptedPassword = hash
+		}
+	}
+
+	return nil
+}
+
+// verifyDummyPassword does the work of a password check that can never succeed
+func (b *Builder) verifyDummyPassword(password string) {
+	b.dummyHashOnce.Do(func() {
+		hash, err := b.passwords.Hash(randstr.String(16))
+		if err != nil {
+			log.Printf("failed to build the dummy password hash: %v", err)
+		}
+		b.dummyHash = hash
+	})
+	b.passwords.Verify(b.dummyHash, password)
+}
+
+func (b *Builder) buildUser(tx Tx, email string, password string, passwordConfirmation string) (*User, error) {
+	if password != passwordConfirmation {
+		return nil, domain_errors.PasswordMismatch()
 	}
 
-	user, err := b.repo.CreateUser(tx, email, hash, false)
+	hash, err := b.buildPasswordHash(password)
 	if err != nil {
-		panic(err)
+		return nil, err
 	}
 
-	return user, nil
+	return b.store.CreateUser(tx, email, hash, false)
 }
 
 func (b *Builder) updateUserPassword(email string, passwordResetToken string, password string, passwordConfirmation string) error {
 	if password != passwordConfirmation {
-		return errors.New("password and confirmation don't match")
+		return domain_errors.PasswordMismatch()
 	}
 
-	hash, err := BuildPasswordHash(password)
+	hash, err := b.buildPasswordHash(password)
 	if err != nil {
-		panic(err)
+		return err
 	}
 
-	user, err := b.repo.GetUserWithEmail(email)
+	user, err := b.store.GetUserWithEmail(email)
 	if err != nil {
-		panic(err)
+		return err
 	}
 
 	if passwordResetToken != user.passwordResetToken {
-		return errors.New("current user reset token does not match given reset token")
+		return domain_errors.InvalidToken(domain_errors.ReasonInvalidResetToken, "current user reset token does not match given reset token")
 	}
 
-	err = b.repo.UpdateUserPassword(email, hash)
-	if err != nil {
-		panic(err)
-	}
-
-	return nil
+	return b.store.UpdateUserPassword(email, hash)
 }
 
-func (b *Builder) buildGuestUser(tx *sql.Tx, email string) (*User, error) {
-	hash, err := BuildPasswordHash(randstr.String(16))
+func (b *Builder) buildGuestUser(tx Tx, email string) (*User, error) {
+	hash, err := b.buildPasswordHash(randstr.String(16))
 	if err != nil {
-		panic(err)
+		return nil, err
 	}
 
 	email = email + "." + randstr.String(16) + ".guest"
 
-	user, err := b.repo.CreateUser(tx, email, hash, true)
-	if err != nil {
-		panic(err)
-	}
+	return b.store.CreateUser(tx, email, hash, true)
+}
 
-	return user, nil
+func (b *Builder) buildSession(tx Tx, newSessionUUID uuid.UUID, userID int, sessionToken string, furthestExpiration time.Time, idleTimeout time.Duration) (*Session, error) {
+	return b.store.CreateSession(tx, newSessionUUID, userID, sessionToken, furthestExpiration, idleTimeout)
 }
 
-func (b *Builder) buildSession(tx *sql.Tx, newSessionUUID uuid.UUID, userID int, sessionToken string, furthestExpiration time.Time) (*Session, error) {
-	session, err := b.repo.CreateSession(tx, newSessionUUID, userID, sessionToken, furthestExpiration)
+// buildRefreshToken stores a new refresh token in the family, only its hash is kept
+func (b *Builder) buildRefreshToken(tx Tx, sessionID int, familyUUID string) (string, error) {
+	raw := make([]byte, refreshTokenBytes)
+	_, err := rand.Read(raw)
 	if err != nil {
-		panic(err)
+		return "", domain_errors.Internal(err)
 	}
+	refreshToken := base64.RawURLEncoding.EncodeToString(raw)
 
-	return session, nil
+	err = b.store.CreateRefreshToken(tx, sessionID, familyUUID, hashRefreshToken(refreshToken))
+	if err != nil {
+		return "", err
+	}
+	return refreshToken, nil
+}
+
+// hashRefreshToken doesn't need a slow hash, the tokens are random rather than picked by people
+func hashRefreshToken(refreshToken string) string {
+	sum := sha256.Sum256([]byte(refreshToken))
+	return hex.EncodeToString(sum[:])
 }
 
-func (b *Builder) buildScopeGroupings(tx *sql.Tx, protoScopeGroupings []*proto.ScopeGrouping, sessionID int) ([]*ScopeGrouping, error) {
+func (b *Builder) buildScopeGroupings(tx Tx, protoScopeGroupings []*proto.ScopeGrouping, sessionID int) ([]*ScopeGrouping, error) {
 	scopeGroupings := make([]*ScopeGrouping, len(protoScopeGroupings))
 	for i, sg := range protoScopeGroupings {
 		exp, err := ptypes.Timestamp(sg.Expiration)
 		if err != nil {
-			panic(err)
+			return nil, domain_errors.InvalidArgument("scope grouping has an invalid expiration")
 		}
 
-		scopeGrouping, err := b.repo.CreateScopeGrouping(tx, sessionID, sg.Scopes, exp)
+		scopeGrouping, err := b.store.CreateScopeGrouping(tx, sessionID, sg.Scopes, exp)
 		if err != nil {
-			panic(err)
+			return nil, err
 		}
 		scopeGroupings[i] = scopeGrouping
 	}
@@ -116,19 +169,28 @@ func (b *Builder) buildScopeGroupings(tx *sql.Tx, protoScopeGroupings []*proto.S
 }
 
 func (b *Builder) buildToken(user *User, sessionUUID uuid.UUID, protoScopeGroupings []*proto.ScopeGrouping) (tokenStr string, json string, furthestExpiration time.Time, err error) {
-	tf := session_representations.NewTokenFactory(user.uuid, sessionUUID.String())
+	tf := session_representations.NewTokenFactory(b.keyring, user.uuid, sessionUUID.String())
 	for _, sg := range protoScopeGroupings {
 		exp, err := ptypes.Timestamp(sg.Expiration)
 		if err != nil {
-			panic(err)
+			return "", "", time.Time{}, domain_errors.InvalidArgument("scope grouping has an invalid expiration")
 		}
 		tf.AddScopeGrouping(sg.Scopes, exp)
 	}
 
+	err = tf.Valid()
+	if err != nil {
+		return "", "", time.Time{}, domain_errors.InvalidArgument(err.Error())
+	}
+
+	if b.accessTokenTTL > 0 {
+		tf.ExpireTokenAt(time.Now().Add(b.accessTokenTTL))
+	}
+
 	sess, err := tf.GenerateSession()
 	if err != nil {
-		panic(err)
+		return "", "", time.Time{}, domain_errors.Internal(err)
 	}
 
-	return  sess.Token, sess.Json, sess.FurthestExpiration, nil
+	return sess.Token, sess.Json, sess.FurthestExpiration, nil
 }
@@ -0,0 +1,174 @@
+package server
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"github.com/willschroeder/fingerprint/pkg/domain_errors"
+	"github.com/willschroeder/fingerprint/pkg/proto"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// apiKeyMetadata carries the caller's API key, the REST gateway forwards the X-Api-Key header into it
+	apiKeyMetadata           = "x-api-key"
+	apiKeyPrefix             = "fpk_"
+	fingerprintServicePrefix = "/proto.FingerprintService/"
+	DefaultCallerCacheTTL    = 30 * time.Second
+)
+
+// publicMethods don't need an API key, the keys they return are published at /.well-known/jwks.json anyway
+var publicMethods = map[string]bool{
+	"GetVerificationKeys": true,
+}
+
+// CallerOptions control how internal services authenticate to the gRPC API
+type CallerOptions struct {
+	// RequireAPIKeys makes every FingerprintService RPC identify its caller with an API key
+	RequireAPIKeys bool
+	// CacheTTL is how long a looked up caller is trusted, so revoking a caller takes up to this long
+	CacheTTL time.Duration
+}
+
+// scopeGroupingRequest is every request that grants scopes to a session
+type scopeGroupingRequest interface {
+	GetScopeGroupings() []*proto.ScopeGrouping
+}
+
+// callerCache keeps API key lookups off the database for every call. Only callers that were found
+// are cached so a new key works right away.
+type callerCache struct {
+	lookup func(apiKeyHash string) (*Caller, error)
+	ttl    time.Duration
+
+	mu      sync.Mutex
+	callers map[string]cachedCaller
+}
+
+type cachedCaller struct {
+	caller    *Caller
+	fetchedAt time.Time
+}
+
+func newCallerCache(lookup func(apiKeyHash string) (*Caller, error), ttl time.Duration) *callerCache {
+	if ttl <= 0 {
+		ttl = DefaultCallerCacheTTL
+	}
+	return &callerCache{lookup: lookup, ttl: ttl, callers: map[string]cachedCaller{}}
+}
+
+func (c *callerCache) get(apiKeyHash string) (*Caller, error) {
+	c.mu.Lock()
+	cached, ok := c.callers[apiKeyHash]
+	c.mu.Unlock()
+	if ok && time.Since(cached.fetchedAt) < c.ttl {
+		return cached.caller, nil
+	}
+
+	caller, err := c.lookup(apiKeyHash)
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if err != nil {
+		delete(c.callers, apiKeyHash)
+		return nil, err
+	}
+	c.callers[apiKeyHash] = cachedCaller{caller: caller, fetchedAt: time.Now()}
+	return caller, nil
+}
+
+// callerInterceptor authenticates FingerprintService callers by API key, then checks the RPC and any
+// scopes it grants against the caller's permissions
+func callerInterceptor(callers *callerCache) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		if !strings.HasPrefix(info.FullMethod, fingerprintServicePrefix) {
+			return handler(ctx, req)
+		}
+		method := strings.TrimPrefix(info.FullMethod, fingerprintServicePrefix)
+		if publicMethods[method] {
+			return handler(ctx, req)
+		}
+
+		apiKey := apiKeyFromContext(ctx)
+		if apiKey == "" {
+			return nil, rpcError(domain_errors.InvalidToken(domain_errors.ReasonInvalidAPIKey, "missing api key"))
+		}
+		caller, err := callers.get(hashAPIKey(apiKey))
+		if err != nil {
+			return nil, rpcError(err)
+		}
+
+		if !caller.mayCall(method) {
+			return nil, rpcError(domain_errors.PermissionDenied(domain_errors.ReasonCallerNotAllowed, caller.name+" isn't allowed to call "+method))
+		}
+		if r, ok := req.(scopeGroupingRequest); ok {
+			var scopes []string
+			for _, sg := range r.GetScopeGroupings() {
+				scopes = append(scopes, sg.Scopes...)
+			}
+			if disallowed := caller.disallowedScopes(scopes); len(disallowed) > 0 {
+				return nil, rpcError(domain_errors.PermissionDenied(domain_errors.ReasonScopeNotAllowed, caller.name+" can't grant "+strings.Join(disallowed, ", ")))
+			}
+		}
+
+		return handler(ctx, req)
+	}
+}
+
+func apiKeyFromContext(ctx context.Context) string {
+	md, ok := metadata.FromIncomingContext(ctx)
+	if !ok {
+		return ""
+	}
+	values := md.Get(apiKeyMetadata)
+	if len(values) == 0 {
+		return ""
+	}
+	return values[0]
+}
+
+// hashAPIKey is a plain digest like hashRefreshToken, keys are random rather than picked by people
+func hashAPIKey(apiKey string) string {
+	sum := sha256.Sum256([]byte(apiKey))
+	return hex.EncodeToString(sum[:])
+}
+
+// CreateCaller registers an internal service and returns its API key, which is only ever shown here.
+// allowedMethods are RPC names like CreateSession, allowedScopes the scopes it may grant, "*" allows any.
+func CreateCaller(store CallerStore, name string, allowedMethods []string, allowedScopes []string) (string, error) {
+	known := fingerprintMethods()
+	for _, method := range allowedMethods {
+		if method != "*" && !known[method] {
+			return "", domain_errors.InvalidArgument("unknown method " + method)
+		}
+	}
+
+	raw := make([]byte, 32)
+	_, err := rand.Read(raw)
+	if err != nil {
+		return "", domain_errors.Internal(err)
+	}
+	apiKey := apiKeyPrefix + base64.RawURLEncoding.EncodeToString(raw)
+
+	_, err = store.CreateCaller(name, hashAPIKey(apiKey), allowedMethods, allowedScopes)
+	if err != nil {
+		return "", err
+	}
+	return apiKey, nil
+}
+
+func fingerprintMethods() map[string]bool {
+	s := grpc.NewServer()
+	proto.RegisterFingerprintServiceServer(s, &GRPCServer{})
+
+	methods := map[string]bool{}
+	for _, method := range s.GetServiceInfo()["proto.FingerprintService"].Methods {
+		methods[method.Name] = true
+	}
+	return methods
+}
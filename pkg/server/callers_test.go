@@ -0,0 +1,114 @@
+package server
+
+import (
+	"context"
+	"github.com/willschroeder/fingerprint/pkg/domain_errors"
+	"github.com/willschroeder/fingerprint/pkg/proto"
+	"github.com/willschroeder/fingerprint/pkg/session_representations"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+const testAPIKey = "fpk_test"
+
+func testCallers(caller *Caller) *callerCache {
+	return newCallerCache(func(apiKeyHash string) (*Caller, error) {
+		if apiKeyHash != hashAPIKey(testAPIKey) {
+			return nil, domain_errors.InvalidToken(domain_errors.ReasonInvalidAPIKey, "api key not recognized")
+		}
+		return caller, nil
+	}, time.Minute)
+}
+
+func withAPIKey(apiKey string) context.Context {
+	return metadata.NewIncomingContext(context.Background(), metadata.Pairs(apiKeyMetadata, apiKey))
+}
+
+func TestCallerInterceptor(t *testing.T) {
+	caller := &Caller{name: "storefront", allowedMethods: []string{"CreateSession", "GetSession"}, allowedScopes: []string{"read", "comment"}}
+	interceptor := callerInterceptor(testCallers(caller))
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) { return "ok", nil }
+	info := func(method string) *grpc.UnaryServerInfo {
+		return &grpc.UnaryServerInfo{FullMethod: fingerprintServicePrefix + method}
+	}
+
+	cases := []struct {
+		name   string
+		ctx    context.Context
+		method string
+		req    interface{}
+		code   codes.Code
+	}{
+		{"missing key", context.Background(), "GetSession", &proto.GetSessionRequest{}, codes.Unauthenticated},
+		{"unknown key", withAPIKey("fpk_other"), "GetSession", &proto.GetSessionRequest{}, codes.Unauthenticated},
+		{"allowed", withAPIKey(testAPIKey), "GetSession", &proto.GetSessionRequest{}, codes.OK},
+		{"method not allowed", withAPIKey(testAPIKey), "CreateUser", &proto.CreateUserRequest{}, codes.PermissionDenied},
+		{"public method", context.Background(), "GetVerificationKeys", &proto.GetVerificationKeysRequest{}, codes.OK},
+		{"allowed scopes", withAPIKey(testAPIKey), "CreateSession", &proto.CreateSessionRequest{ScopeGroupings: []*proto.ScopeGrouping{{Scopes: []string{"read"}}, {Scopes: []string{"comment"}}}}, codes.OK},
+		{"scope not allowed", withAPIKey(testAPIKey), "CreateSession", &proto.CreateSessionRequest{ScopeGroupings: []*proto.ScopeGrouping{{Scopes: []string{"read", "admin"}}}}, codes.PermissionDenied},
+	}
+	for _, c := range cases {
+		_, err := interceptor(c.ctx, c.req, info(c.method), handler)
+		if status.Code(err) != c.code {
+			t.Errorf("%s: expected %s, got %v", c.name, c.code, err)
+		}
+	}
+
+	_, err := interceptor(context.Background(), nil, &grpc.UnaryServerInfo{FullMethod: "/envoy.service.auth.v3.Authorization/Check"}, handler)
+	if err != nil {
+		t.Errorf("Expected other services to be left alone, got %v", err)
+	}
+}
+
+func TestCallerWildcards(t *testing.T) {
+	caller := &Caller{allowedMethods: []string{"*"}, allowedScopes: []string{"*"}}
+	if !caller.mayCall("RevokeSessions") || len(caller.disallowedScopes([]string{"anything"})) != 0 {
+		t.Errorf("Expected * to allow every method and scope")
+	}
+	if len((&Caller{}).disallowedScopes([]string{"read"})) != 1 {
+		t.Errorf("Expected callers without scopes to grant none")
+	}
+}
+
+func TestCallerCacheOnlyKeepsFoundCallers(t *testing.T) {
+	lookups := 0
+	cache := newCallerCache(func(apiKeyHash string) (*Caller, error) {
+		lookups++
+		if apiKeyHash == "missing" {
+			return nil, domain_errors.InvalidToken(domain_errors.ReasonInvalidAPIKey, "api key not recognized")
+		}
+		return &Caller{}, nil
+	}, time.Minute)
+
+	cache.get("found")
+	cache.get("found")
+	cache.get("missing")
+	cache.get("missing")
+	if lookups != 3 {
+		t.Errorf("Expected found callers to be cached and missing ones looked up again, got %d lookups", lookups)
+	}
+}
+
+func TestGatewayForwardsAPIKey(t *testing.T) {
+	server := newKeyringServer(session_representations.DevelopmentKeyring())
+	caller := &Caller{name: "storefront", allowedMethods: []string{"CreateSession"}}
+	handler, err := NewHTTPHandler(server, NewServiceServer(server, grpc.UnaryInterceptor(callerInterceptor(testCallers(caller)))), HTTPOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	req := httptest.NewRequest(http.MethodPost, "/v1/sessions:validate", strings.NewReader(`{"token": "v2.local.garbage"}`))
+	req.Header.Set("X-Api-Key", testAPIKey)
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+	if rec.Code != http.StatusForbidden {
+		t.Errorf("Expected the key to reach the interceptor and the caller to be refused GetSession, got %d %s", rec.Code, rec.Body.String())
+	}
+}
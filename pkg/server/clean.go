@@ -0,0 +1,103 @@
+package server
+
+import (
+	"context"
+	"github.com/willschroeder/fingerprint/pkg/config"
+	"log"
+	"time"
+)
+
+const defaultCleanBatchSize = 1000
+
+// CleanOptions control what Clean removes, a zero TTL or retention leaves those rows alone
+type CleanOptions struct {
+	// BatchSize caps the rows removed per statement so cleaning doesn't hold long locks
+	BatchSize int
+	// DryRun counts what would be removed without removing it
+	DryRun bool
+	// GuestTTL is how long a guest without live sessions is kept
+	GuestTTL time.Duration
+	// ResetTokenTTL is how long a requested password reset token can be used
+	ResetTokenTTL time.Duration
+	// RevocationRetention is how long revoked sessions, spent refresh tokens and revoked callers are kept
+	RevocationRetention time.Duration
+	// JobRunRetention is how long job run history is kept
+	JobRunRetention time.Duration
+}
+
+func NewCleanOptions(cfg config.Clean) CleanOptions {
+	return CleanOptions{
+		BatchSize:           cfg.BatchSize,
+		GuestTTL:            cfg.GuestTTL,
+		ResetTokenTTL:       cfg.ResetTokenTTL,
+		RevocationRetention: cfg.RevocationRetention,
+		JobRunRetention:     cfg.JobRunRetention,
+	}
+}
+
+// CleanResult is how many rows one kind of cleanup removed, or would have on a dry run
+type CleanResult struct {
+	Name string
+	Rows int
+}
+
+type cleanTask struct {
+	name string
+	// age is how old rows must be, tasks with a zero age are skipped unless always is set
+	age    time.Duration
+	always bool
+	clean  func(before time.Time, limit int, dryRun bool) (int, error)
+}
+
+// Clean removes expired and stale rows in batches, stopping at the first error with the results so far
+func Clean(store CleanupStore, options CleanOptions, now time.Time) ([]CleanResult, error) {
+	batchSize := options.BatchSize
+	if batchSize <= 0 {
+		batchSize = defaultCleanBatchSize
+	}
+
+	tasks := []cleanTask{
+		{name: "expired sessions", always: true, clean: store.DeleteExpiredSessions},
+		{name: "expired scope groupings", always: true, clean: store.DeleteExpiredScopeGroupings},
+		{name: "stale guests", age: options.GuestTTL, clean: store.DeleteStaleGuests},
+		{name: "expired password reset tokens", age: options.ResetTokenTTL, clean: store.ExpireResetTokens},
+		{name: "revoked sessions", age: options.RevocationRetention, clean: store.DeleteRevokedSessions},
+		{name: "spent refresh tokens", age: options.RevocationRetention, clean: store.DeleteRetiredRefreshTokens},
+		{name: "revoked callers", age: options.RevocationRetention, clean: store.DeleteRevokedCallers},
+		{name: "old job runs", age: options.JobRunRetention, clean: store.DeleteOldJobRuns},
+	}
+
+	var results []CleanResult
+	for _, task := range tasks {
+		if task.age <= 0 && !task.always {
+			continue
+		}
+		result := CleanResult{Name: task.name}
+		before := now.Add(-task.age).UTC()
+		for {
+			n, err := task.clean(before, batchSize, options.DryRun)
+			if err != nil {
+				return results, err
+			}
+			result.Rows += n
+			if options.DryRun || n < batchSize {
+				break
+			}
+		}
+		results = append(results, result)
+	}
+	return results, nil
+}
+
+// cleanJob is Clean as a scheduled job, logging what it removed
+func cleanJob(store CleanupStore, options CleanOptions) func(context.Context) error {
+	return func(context.Context) error {
+		results, err := Clean(store, options, time.Now())
+		for _, result := range results {
+			if result.Rows > 0 {
+				log.Printf("clean: removed %d %s", result.Rows, result.Name)
+			}
+		}
+		return err
+	}
+}
@@ -0,0 +1,138 @@
+package server
+
+import (
+	"github.com/brianvoe/gofakeit"
+	"github.com/google/uuid"
+	"github.com/willschroeder/fingerprint/pkg/domain_errors"
+	"testing"
+	"time"
+)
+
+func createStoredSession(t *testing.T, user *User, expiration time.Time) *Session {
+	tx, _ := testStore.Begin()
+	session, err := testStore.CreateSession(tx, uuid.New(), user.id, gofakeit.UUID(), expiration, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = testStore.CreateScopeGrouping(tx, session.id, []string{"read"}, expiration)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tx.Commit()
+	return session
+}
+
+func cleaned(results []CleanResult, name string) int {
+	for _, result := range results {
+		if result.Name == name {
+			return result.Rows
+		}
+	}
+	return -1
+}
+
+func TestClean(t *testing.T) {
+	user := createTestUser(false)
+	expired := createStoredSession(t, user, time.Now().Add(-time.Hour))
+	live := createStoredSession(t, user, time.Now().Add(3*time.Hour))
+	revoked := createStoredSession(t, user, time.Now().Add(3*time.Hour))
+	_, err := testStore.RevokeSessionWithUUID(revoked.uuid, "test")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	staleGuest := createTestUser(true)
+	activeGuest := createTestUser(true)
+	createStoredSession(t, activeGuest, time.Now().Add(3*time.Hour))
+
+	resetToken, err := testStore.UpdateUserPasswordResetToken(user.email)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Run an hour ahead so everything created above is older than the TTLs
+	now := time.Now().Add(time.Hour)
+	options := CleanOptions{BatchSize: 1, DryRun: true, GuestTTL: time.Minute, ResetTokenTTL: time.Minute, RevocationRetention: time.Minute}
+	results, err := Clean(testStore, options, now)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cleaned(results, "expired sessions") < 1 || cleaned(results, "stale guests") < 1 || cleaned(results, "revoked sessions") < 1 {
+		t.Errorf("Expected the dry run to count rows, got %+v", results)
+	}
+	_, err = testStore.GetSessionWithUUID(expired.uuid)
+	if err != nil {
+		t.Errorf("Expected a dry run to leave the expired session, got %v", err)
+	}
+
+	options.DryRun = false
+	results, err = Clean(testStore, options, now)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for name, sessionUUID := range map[string]string{"expired": expired.uuid, "revoked": revoked.uuid} {
+		_, err = testStore.GetSessionWithUUID(sessionUUID)
+		if !domain_errors.Is(err, domain_errors.KindNotFound) {
+			t.Errorf("Expected the %s session to be removed, got %v", name, err)
+		}
+	}
+	_, err = testStore.GetSessionWithUUID(live.uuid)
+	if err != nil {
+		t.Errorf("Expected the live session to be kept, got %v", err)
+	}
+
+	_, err = testStore.GetUserWithUUID(staleGuest.uuid)
+	if !domain_errors.Is(err, domain_errors.KindNotFound) {
+		t.Errorf("Expected the stale guest to be removed, got %v", err)
+	}
+	_, err = testStore.GetUserWithUUID(activeGuest.uuid)
+	if err != nil {
+		t.Errorf("Expected the guest with a live session to be kept, got %v", err)
+	}
+
+	got, err := testStore.GetUserWithUUID(user.uuid)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.passwordResetToken == resetToken {
+		t.Errorf("Expected the reset token to be expired")
+	}
+
+	results, err = Clean(testStore, options, now)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, result := range results {
+		if result.Rows != 0 {
+			t.Errorf("Expected a second clean to find nothing, got %+v", results)
+		}
+	}
+}
+
+func TestCleanJobRuns(t *testing.T) {
+	name := "test-" + uuid.New().String()
+	run, err := testStore.CreateJobRun(name, JobTriggerManual, "test", time.Time{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = testStore.FinishJobRun(run, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	results, err := Clean(testStore, CleanOptions{JobRunRetention: time.Minute}, time.Now().Add(time.Hour))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cleaned(results, "old job runs") < 1 {
+		t.Errorf("Expected old job runs to be removed, got %+v", results)
+	}
+	runs, err := testStore.GetJobRuns(name, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(runs) != 0 {
+		t.Errorf("Expected the run history to be gone, got %+v", runs)
+	}
+}
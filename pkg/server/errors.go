@@ -0,0 +1,15 @@
+package server
+
+import (
+	"github.com/willschroeder/fingerprint/pkg/domain_errors"
+	"log"
+)
+
+// rpcError converts err into a gRPC status error, internal causes are logged here since callers never see them
+func rpcError(err error) error {
+	de := domain_errors.From(err)
+	if de.Kind == domain_errors.KindInternal {
+		log.Printf("internal error: %v", err)
+	}
+	return de.GRPCStatus().Err()
+}
@@ -0,0 +1,115 @@
+package server
+
+import (
+	"context"
+	corev3 "github.com/envoyproxy/go-control-plane/envoy/config/core/v3"
+	authv3 "github.com/envoyproxy/go-control-plane/envoy/service/auth/v3"
+	typev3 "github.com/envoyproxy/go-control-plane/envoy/type/v3"
+	"github.com/willschroeder/fingerprint/pkg/domain_errors"
+	"google.golang.org/genproto/googleapis/rpc/status"
+	"google.golang.org/grpc/codes"
+	"strings"
+)
+
+// Headers passed upstream once a request is allowed
+const (
+	userUUIDHeader    = "X-User-UUID"
+	sessionUUIDHeader = "X-Session-UUID"
+	scopesHeader      = "X-Scopes"
+	// reauthenticationHeader is set on denials that a fresh login would fix
+	reauthenticationHeader = "X-Reauthentication-Required"
+)
+
+// requiredScopesExtension is the context extension routes use to list their required scopes, e.g.
+// typed_per_filter_config: check_settings: context_extensions: required_scopes: "read write"
+const requiredScopesExtension = "required_scopes"
+
+// ExtAuthzServer lets Envoy use Fingerprint as its external authorizer, implementing
+// envoy.service.auth.v3.Authorization next to the FingerprintService
+type ExtAuthzServer struct {
+	server *GRPCServer
+}
+
+func NewExtAuthzServer(server *GRPCServer) *ExtAuthzServer {
+	return &ExtAuthzServer{server: server}
+}
+
+// bearerToken pulls the token out of an Authorization header value
+func bearerToken(authorization string) (string, bool) {
+	const prefix = "bearer "
+	if len(authorization) <= len(prefix) || !strings.EqualFold(authorization[:len(prefix)], prefix) {
+		return "", false
+	}
+	token := strings.TrimSpace(authorization[len(prefix):])
+	return token, token != ""
+}
+
+// parseScopes accepts scopes separated by spaces or commas
+func parseScopes(scopes string) []string {
+	return strings.FieldsFunc(scopes, func(r rune) bool {
+		return r == ' ' || r == ','
+	})
+}
+
+func (a *ExtAuthzServer) Check(_ context.Context, request *authv3.CheckRequest) (*authv3.CheckResponse, error) {
+	httpRequest := request.GetAttributes().GetRequest().GetHttp()
+	token, ok := bearerToken(httpRequest.GetHeaders()["authorization"])
+	if !ok {
+		return deniedResponse(codes.Unauthenticated, typev3.StatusCode_Unauthorized, header("WWW-Authenticate", `Bearer realm="fingerprint"`)), nil
+	}
+
+	requiredScopes := parseScopes(request.GetAttributes().GetContextExtensions()[requiredScopesExtension])
+	state, auth, err := a.server.authorize(token, requiredScopes)
+	if err != nil {
+		if domain_errors.KindOf(err) == domain_errors.KindInternal {
+			return nil, rpcError(err)
+		}
+		return deniedResponse(codes.Unauthenticated, typev3.StatusCode_Unauthorized, header("WWW-Authenticate", `Bearer realm="fingerprint", error="invalid_token"`)), nil
+	}
+	if state.revoked {
+		return deniedResponse(codes.Unauthenticated, typev3.StatusCode_Unauthorized, header("WWW-Authenticate", `Bearer realm="fingerprint", error="invalid_token"`)), nil
+	}
+
+	if !auth.Allowed {
+		headers := []*corev3.HeaderValueOption{
+			header("WWW-Authenticate", `Bearer realm="fingerprint", error="insufficient_scope", scope="`+strings.Join(requiredScopes, " ")+`"`),
+		}
+		if auth.ReauthenticationRequired {
+			headers = append(headers, header(reauthenticationHeader, "true"))
+		}
+		return deniedResponse(codes.PermissionDenied, typev3.StatusCode_Forbidden, headers...), nil
+	}
+
+	return &authv3.CheckResponse{
+		Status: &status.Status{Code: int32(codes.OK)},
+		HttpResponse: &authv3.CheckResponse_OkResponse{
+			OkResponse: &authv3.OkHttpResponse{
+				// Overwrite so clients can't smuggle their own identity headers upstream
+				Headers: []*corev3.HeaderValueOption{
+					header(userUUIDHeader, state.validated.Session.CustomerUUID),
+					header(sessionUUIDHeader, state.validated.Session.SessionUUID),
+					header(scopesHeader, strings.Join(state.validated.ActiveScopes(), " ")),
+				},
+			},
+		},
+	}, nil
+}
+
+func header(key string, value string) *corev3.HeaderValueOption {
+	return &corev3.HeaderValueOption{
+		Header:       &corev3.HeaderValue{Key: key, Value: value},
+		AppendAction: corev3.HeaderValueOption_OVERWRITE_IF_EXISTS_OR_ADD,
+	}
+}
+
+func deniedResponse(code codes.Code, httpStatus typev3.StatusCode, headers ...*corev3.HeaderValueOption) *authv3.CheckResponse {
+	return &authv3.CheckResponse{
+		Status: &status.Status{Code: int32(code)},
+		HttpResponse: &authv3.CheckResponse_DeniedResponse{
+			DeniedResponse: &authv3.DeniedHttpResponse{
+				Status:  &typev3.HttpStatus{Code: httpStatus},
+				Headers: headers,
+			},
+		},
+	}
+}
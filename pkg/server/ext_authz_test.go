@@ -0,0 +1,64 @@
+package server
+
+import (
+	"context"
+	authv3 "github.com/envoyproxy/go-control-plane/envoy/service/auth/v3"
+	typev3 "github.com/envoyproxy/go-control-plane/envoy/type/v3"
+	"github.com/willschroeder/fingerprint/pkg/session_representations"
+	"google.golang.org/grpc/codes"
+	"testing"
+)
+
+func checkRequest(headers map[string]string, requiredScopes string) *authv3.CheckRequest {
+	return &authv3.CheckRequest{
+		Attributes: &authv3.AttributeContext{
+			Request: &authv3.AttributeContext_Request{
+				Http: &authv3.AttributeContext_HttpRequest{Headers: headers},
+			},
+			ContextExtensions: map[string]string{requiredScopesExtension: requiredScopes},
+		},
+	}
+}
+
+func TestExtAuthzDeniesMissingToken(t *testing.T) {
+	authz := NewExtAuthzServer(newKeyringServer(session_representations.DevelopmentKeyring()))
+
+	res, err := authz.Check(context.Background(), checkRequest(map[string]string{}, "read"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.Status.Code != int32(codes.Unauthenticated) || res.GetDeniedResponse().Status.Code != typev3.StatusCode_Unauthorized {
+		t.Errorf("Expected a 401 denial, got %v", res)
+	}
+}
+
+func TestExtAuthzDeniesInvalidToken(t *testing.T) {
+	authz := NewExtAuthzServer(newKeyringServer(session_representations.DevelopmentKeyring()))
+
+	res, err := authz.Check(context.Background(), checkRequest(map[string]string{"authorization": "Bearer v2.local.garbage"}, "read"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.Status.Code != int32(codes.Unauthenticated) {
+		t.Errorf("Expected an invalid token to be denied, got %v", res)
+	}
+}
+
+func TestBearerToken(t *testing.T) {
+	if token, ok := bearerToken("bearer abc"); !ok || token != "abc" {
+		t.Errorf("Expected abc, got %q", token)
+	}
+	if _, ok := bearerToken("Basic abc"); ok {
+		t.Errorf("Basic credentials aren't a bearer token")
+	}
+	if _, ok := bearerToken("Bearer "); ok {
+		t.Errorf("An empty bearer token shouldn't be accepted")
+	}
+}
+
+func TestParseScopes(t *testing.T) {
+	scopes := parseScopes("read, write comment")
+	if len(scopes) != 3 || scopes[1] != "write" {
+		t.Errorf("Unexpected scopes %v", scopes)
+	}
+}
@@ -0,0 +1,62 @@
+package server
+
+import (
+	"github.com/willschroeder/fingerprint/pkg/domain_errors"
+	"log"
+	"net/http"
+	"strings"
+)
+
+// DefaultSessionCookie is read by forward auth when a request has no Authorization header
+const DefaultSessionCookie = "fingerprint_session"
+
+// requiredScopesHeader and the scopes query parameter are how proxies say what a route needs
+const requiredScopesHeader = "X-Required-Scopes"
+
+// forwardAuthHandler answers nginx auth_request and Traefik ForwardAuth subrequests. The body is always
+// empty, proxies only look at the status and the identity headers.
+func (s *GRPCServer) forwardAuthHandler(sessionCookie string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		token, ok := bearerToken(r.Header.Get("Authorization"))
+		if !ok {
+			cookie, err := r.Cookie(sessionCookie)
+			if err == nil && cookie.Value != "" {
+				token, ok = cookie.Value, true
+			}
+		}
+		if !ok {
+			w.Header().Set("WWW-Authenticate", `Bearer realm="fingerprint"`)
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+
+		requiredScopes := parseScopes(r.URL.Query().Get("scopes"))
+		requiredScopes = append(requiredScopes, parseScopes(r.Header.Get(requiredScopesHeader))...)
+
+		state, auth, err := s.authorize(token, requiredScopes)
+		if err != nil && domain_errors.KindOf(err) == domain_errors.KindInternal {
+			log.Printf("failed to verify forwarded request: %v", err)
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		if err != nil || state.revoked {
+			w.Header().Set("WWW-Authenticate", `Bearer realm="fingerprint", error="invalid_token"`)
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+
+		if !auth.Allowed {
+			w.Header().Set("WWW-Authenticate", `Bearer realm="fingerprint", error="insufficient_scope", scope="`+strings.Join(requiredScopes, " ")+`"`)
+			if auth.ReauthenticationRequired {
+				w.Header().Set(reauthenticationHeader, "true")
+			}
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+
+		w.Header().Set(userUUIDHeader, state.validated.Session.CustomerUUID)
+		w.Header().Set(sessionUUIDHeader, state.validated.Session.SessionUUID)
+		w.Header().Set(scopesHeader, strings.Join(state.validated.ActiveScopes(), " "))
+		w.WriteHeader(http.StatusOK)
+	}
+}
@@ -0,0 +1,34 @@
+package server
+
+import (
+	"github.com/willschroeder/fingerprint/pkg/session_representations"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func forwardAuth(t *testing.T, req *http.Request) *httptest.ResponseRecorder {
+	rec := httptest.NewRecorder()
+	newTestHTTPHandler(t, newKeyringServer(session_representations.DevelopmentKeyring()), HTTPOptions{}).ServeHTTP(rec, req)
+	return rec
+}
+
+func TestForwardAuthRequiresToken(t *testing.T) {
+	rec := forwardAuth(t, httptest.NewRequest(http.MethodGet, "/forward-auth?scopes=read", nil))
+	if rec.Code != http.StatusUnauthorized || rec.Header().Get("WWW-Authenticate") == "" {
+		t.Errorf("Expected a 401 challenge, got %d", rec.Code)
+	}
+}
+
+func TestForwardAuthRejectsInvalidCookie(t *testing.T) {
+	req := httptest.NewRequest(http.MethodGet, "/forward-auth", nil)
+	req.AddCookie(&http.Cookie{Name: DefaultSessionCookie, Value: "v2.local.garbage"})
+
+	rec := forwardAuth(t, req)
+	if rec.Code != http.StatusUnauthorized {
+		t.Errorf("Expected 401 for a bad cookie token, got %d", rec.Code)
+	}
+	if rec.Header().Get(userUUIDHeader) != "" {
+		t.Errorf("Identity headers should never be set on a denial")
+	}
+}
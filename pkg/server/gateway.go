@@ -0,0 +1,89 @@
+package server
+
+import (
+	"context"
+	gproto "github.com/golang/protobuf/proto"
+	"github.com/grpc-ecosystem/grpc-gateway/runtime"
+	"github.com/willschroeder/fingerprint/pkg/proto"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+	"log"
+	"net"
+	"net/http"
+	"strings"
+)
+
+// newGatewayHandler maps every FingerprintService RPC onto the REST routes declared in fingerprint.proto.
+// Requests go to grpcServer over a loopback connection so they pass through the same interceptors as
+// gRPC callers, grpcServer must not have transport credentials. It only listens on 127.0.0.1, the
+// HTTP listener in front of the gateway is what enforces TLS. Verified client certificate subjects are
+// forwarded with gatewaySecret so admin RPCs can be allowed over REST too.
+func newGatewayHandler(grpcServer *grpc.Server, gatewaySecret string) (http.Handler, error) {
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		return nil, err
+	}
+	go func() {
+		err := grpcServer.Serve(lis)
+		if err != nil {
+			log.Printf("gateway connection closed: %v", err)
+		}
+	}()
+
+	conn, err := grpc.Dial(lis.Addr().String(), grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		return nil, err
+	}
+
+	mux := runtime.NewServeMux(
+		runtime.WithMarshalerOption(runtime.MIMEWildcard, &runtime.JSONPb{OrigName: true, EmitDefaults: true}),
+		runtime.WithForwardResponseOption(restStatus),
+		runtime.WithIncomingHeaderMatcher(gatewayHeader),
+		runtime.WithMetadata(gatewayMetadata(gatewaySecret)),
+	)
+	err = proto.RegisterFingerprintServiceHandler(context.Background(), mux, conn)
+	if err != nil {
+		return nil, err
+	}
+	return mux, nil
+}
+
+// gatewayHeader forwards the caller's API key along with the headers the gateway always forwards, except
+// for the metadata only the gateway itself may set
+func gatewayHeader(key string) (string, bool) {
+	if strings.EqualFold(key, apiKeyMetadata) {
+		return apiKeyMetadata, true
+	}
+	name, ok := runtime.DefaultHeaderMatcher(key)
+	if !ok || isGatewayMetadata(name) {
+		return "", false
+	}
+	return name, true
+}
+
+// restStatus picks the HTTP status for successful responses, errors are already mapped from their gRPC code.
+// Password resets report failures in the response rather than as errors, so those are mapped here too.
+func restStatus(_ context.Context, w http.ResponseWriter, response gproto.Message) error {
+	switch r := response.(type) {
+	case *proto.CreateUserResponse, *proto.CreateGuestUserResponse, *proto.CreateSessionResponse, *proto.CreatePasswordResetTokenResponse:
+		w.WriteHeader(http.StatusCreated)
+	case *proto.ResetUserPasswordResponse:
+		switch r.Status {
+		case proto.ResetUserPasswordResponse_PASSWORD_MISMATCH:
+			w.WriteHeader(http.StatusBadRequest)
+		case proto.ResetUserPasswordResponse_NO_MATCHING_RESET_TOKEN:
+			w.WriteHeader(http.StatusUnauthorized)
+		}
+	}
+	return nil
+}
+
+// openAPIHandler serves the OpenAPI document generated alongside the gateway
+func openAPIHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.Write(proto.OpenAPIDocument)
+}
@@ -1,123 +1,145 @@
 package server
 
 import (
-	"errors"
+	"github.com/golang/protobuf/ptypes"
 	"github.com/google/uuid"
-	"github.com/willschroeder/fingerprint/pkg/db"
+	"github.com/willschroeder/fingerprint/pkg/domain_errors"
+	"github.com/willschroeder/fingerprint/pkg/passwords"
 	"github.com/willschroeder/fingerprint/pkg/proto"
 	"github.com/willschroeder/fingerprint/pkg/session_representations"
+	"time"
 )
 import "context"
 
 type GRPCServer struct{
-	repo *Repo
-	dao *db.DAO
+	store Store
 	builder *Builder
+	validator *session_representations.SessionValidator
+	activity *activityTracker
+	options SessionOptions
+	scheduler *Scheduler
 }
 
-func NewGRPCServer(repo *Repo, dao *db.DAO) *GRPCServer {
-	return &GRPCServer{repo, dao, &Builder{repo:repo, dao:dao}}
+func NewGRPCServer(store Store, policy *passwords.Policy, keyring *session_representations.Keyring, validator *session_representations.SessionValidator, options SessionOptions) *GRPCServer {
+	builder := &Builder{store:store, passwords:policy, keyring:keyring, accessTokenTTL:options.AccessTokenTTL}
+	return &GRPCServer{store, builder, validator, newActivityTracker(store, validator, options.MaxLifetime), options, NewScheduler(store, jobRunner())}
 }
 
 func (s *GRPCServer) CreateUser(_ context.Context, request *proto.CreateUserRequest) (*proto.CreateUserResponse, error) {
-	tx, err :=  s.dao.Conn.Begin()
+	tx, err :=  s.store.Begin()
 	if err != nil {
-		panic(err)
+		return nil, rpcError(err)
 	}
 
 	user, err := s.builder.buildUser(tx, request.Email, request.Password, request.PasswordConfirmation)
 	if err != nil {
 		tx.Rollback()
-		panic(err)
+		return nil, rpcError(err)
 	}
 
 	sessionUUID := uuid.New()
 	sessionToken, json, furthestExpiration, err := s.builder.buildToken(user, sessionUUID, request.ScopeGroupings)
 	if err != nil {
 		tx.Rollback()
-		panic(err)
+		return nil, rpcError(err)
 	}
 
 
-	session, err := s.builder.buildSession(tx, sessionUUID, user.id, sessionToken, furthestExpiration)
+	session, err := s.builder.buildSession(tx, sessionUUID, user.id, sessionToken, furthestExpiration, s.options.IdleTimeout)
 	if err != nil {
 		tx.Rollback()
-		panic(err)
+		return nil, rpcError(err)
 	}
 
 	_, err = s.builder.buildScopeGroupings(tx, request.ScopeGroupings, session.id)
 	if err != nil {
 		tx.Rollback()
-		panic(err)
+		return nil, rpcError(err)
+	}
+
+	refreshToken, err := s.builder.buildRefreshToken(tx, session.id, uuid.New().String())
+	if err != nil {
+		tx.Rollback()
+		return nil, rpcError(err)
 	}
 
 	err = tx.Commit()
 	if err != nil {
-		panic(err)
+		return nil, rpcError(err)
 	}
 
-	return &proto.CreateUserResponse{User:user.ConvertToProtobuff(), Session:session.ConvertToProtobuff(json)}, nil
+	return &proto.CreateUserResponse{User:user.ConvertToProtobuff(), Session:session.ConvertToProtobuff(json, refreshToken)}, nil
 }
 
 func (s *GRPCServer) GetUser(_ context.Context, request *proto.GetUserRequest) (*proto.GetUserResponse, error) {
 	switch ident := request.Identifier.(type) {
 	case *proto.GetUserRequest_Email:
-		user, err := s.repo.GetUserWithEmail(ident.Email)
+		user, err := s.store.GetUserWithEmail(ident.Email)
 		if err != nil {
-			panic(err)
+			return nil, rpcError(err)
 		}
 		return &proto.GetUserResponse{User:user.ConvertToProtobuff()}, nil
 	case *proto.GetUserRequest_Uuid:
-		user, err := s.repo.GetUserWithUUID(ident.Uuid)
+		user, err := s.store.GetUserWithUUID(ident.Uuid)
 		if err != nil {
-			panic(err)
+			return nil, rpcError(err)
 		}
 		return &proto.GetUserResponse{User:user.ConvertToProtobuff()}, nil
 	}
 
-	return nil, errors.New("unknown user identifier")
+	return nil, rpcError(domain_errors.InvalidArgument("unknown user identifier"))
 }
 
 func (s *GRPCServer) CreateGuestUser(_ context.Context, request *proto.CreateGuestUserRequest) (*proto.CreateGuestUserResponse, error) {
-	tx, err :=  s.dao.Conn.Begin()
+	tx, err :=  s.store.Begin()
 	if err != nil {
-		panic(err)
+		return nil, rpcError(err)
 	}
 
 	user, err := s.builder.buildGuestUser(tx,request.Email)
+	if err != nil {
+		tx.Rollback()
+		return nil, rpcError(err)
+	}
 
 	sessionUUID := uuid.New()
 	sessionToken, json, furthestExpiration, err := s.builder.buildToken(user, sessionUUID, request.ScopeGroupings)
 	if err != nil {
 		tx.Rollback()
-		panic(err)
+		return nil, rpcError(err)
 	}
 
 
-	session, err := s.builder.buildSession(tx, sessionUUID, user.id, sessionToken, furthestExpiration)
+	session, err := s.builder.buildSession(tx, sessionUUID, user.id, sessionToken, furthestExpiration, s.options.IdleTimeout)
 	if err != nil {
 		tx.Rollback()
-		panic(err)
+		return nil, rpcError(err)
 	}
 
 	_, err = s.builder.buildScopeGroupings(tx, request.ScopeGroupings, session.id)
 	if err != nil {
 		tx.Rollback()
-		panic(err)
+		return nil, rpcError(err)
+	}
+
+	refreshToken, err := s.builder.buildRefreshToken(tx, session.id, uuid.New().String())
+	if err != nil {
+		tx.Rollback()
+		return nil, rpcError(err)
 	}
 
 	err = tx.Commit()
 	if err != nil {
-		panic(err)
+		return nil, rpcError(err)
 	}
 
-	return &proto.CreateGuestUserResponse{User:user.ConvertToProtobuff(), Session:session.ConvertToProtobuff(json)}, nil
+	return &proto.CreateGuestUserResponse{User:user.ConvertToProtobuff(), Session:session.ConvertToProtobuff(json, refreshToken)}, nil
 }
 
 func (s *GRPCServer) CreatePasswordResetToken(_ context.Context, request *proto.CreatePasswordResetTokenRequest) (*proto.CreatePasswordResetTokenResponse, error) {
-	token, err := s.repo.UpdateUserPasswordResetToken(request.Email)
+	token, err := s.store.UpdateUserPasswordResetToken(request.Email)
 	if err != nil {
-		panic(err)
+		return nil, rpcError(err)
 	}
 
 	return &proto.CreatePasswordResetTokenResponse{PasswordResetToken:token}, nil
@@ -125,76 +147,389 @@ func (s *GRPCServer) CreatePasswordResetToken(_ context.Context, request *proto.
 
 func (s *GRPCServer) UpdateUserPassword(_ context.Context, request *proto.ResetUserPasswordRequest) (*proto.ResetUserPasswordResponse, error) {
 	err := s.builder.updateUserPassword(request.Email,request.PasswordResetToken,request.Password,request.PasswordConfirmation)
-	if err != nil {
-		panic(err)
+	switch {
+	case domain_errors.Is(err, domain_errors.KindPasswordMismatch):
+		return &proto.ResetUserPasswordResponse{Status:proto.ResetUserPasswordResponse_PASSWORD_MISMATCH}, nil
+	case domain_errors.Is(err, domain_errors.KindInvalidToken):
+		return &proto.ResetUserPasswordResponse{Status:proto.ResetUserPasswordResponse_NO_MATCHING_RESET_TOKEN}, nil
+	case err != nil:
+		return nil, rpcError(err)
 	}
 
 	return &proto.ResetUserPasswordResponse{Status:proto.ResetUserPasswordResponse_SUCCESSFUL}, nil
 }
 
 func (s *GRPCServer) CreateSession(_ context.Context, request *proto.CreateSessionRequest) (*proto.CreateSessionResponse, error) {
-	user, err := s.repo.GetUserWithEmail(request.Email)
+	user, err := s.store.GetUserWithEmail(request.Email)
+	if domain_errors.Is(err, domain_errors.KindNotFound) {
+		// Don't tell the caller which half of the credentials was wrong, not even by answering faster
+		s.builder.verifyDummyPassword(request.Password)
+		return nil, rpcError(domain_errors.InvalidCredentials())
+	}
 	if err != nil {
-		panic(err)
+		return nil, rpcError(err)
 	}
 
-	hash, err := BuildPasswordHash(request.Password)
+	err = s.builder.verifyPassword(user, request.Password)
 	if err != nil {
-		panic(err)
+		return nil, rpcError(err)
 	}
 
-	if hash != user.encryptedPassword {
-		return nil, errors.New("incorrect password")
+	idleTimeout := s.options.IdleTimeout
+	if request.IdleTimeout != nil {
+		idleTimeout, err = ptypes.Duration(request.IdleTimeout)
+		if err != nil || idleTimeout < 0 {
+			return nil, rpcError(domain_errors.InvalidArgument("idle timeout is invalid"))
+		}
 	}
 
-	tx, err :=  s.dao.Conn.Begin()
+	tx, err :=  s.store.Begin()
 	if err != nil {
-		panic(err)
+		return nil, rpcError(err)
 	}
 
 	sessionUUID := uuid.New()
 	sessionToken, json, furthestExpiration, err := s.builder.buildToken(user, sessionUUID, request.ScopeGroupings)
 	if err != nil {
 		tx.Rollback()
-		panic(err)
+		return nil, rpcError(err)
 	}
 
 
-	session, err := s.builder.buildSession(tx, sessionUUID, user.id, sessionToken, furthestExpiration)
+	session, err := s.builder.buildSession(tx, sessionUUID, user.id, sessionToken, furthestExpiration, idleTimeout)
 	if err != nil {
 		tx.Rollback()
-		panic(err)
+		return nil, rpcError(err)
 	}
 
 	_, err = s.builder.buildScopeGroupings(tx, request.ScopeGroupings, session.id)
 	if err != nil {
 		tx.Rollback()
-		panic(err)
+		return nil, rpcError(err)
+	}
+
+	refreshToken, err := s.builder.buildRefreshToken(tx, session.id, uuid.New().String())
+	if err != nil {
+		tx.Rollback()
+		return nil, rpcError(err)
 	}
 
 	err = tx.Commit()
 	if err != nil {
-		panic(err)
+		return nil, rpcError(err)
 	}
-	return &proto.CreateSessionResponse{Session: &proto.Session{Uuid:session.uuid, Token:sessionToken, Json:json}}, nil
+	return &proto.CreateSessionResponse{Session: &proto.Session{Uuid:session.uuid, Token:sessionToken, Json:json, RefreshToken:refreshToken}}, nil
 }
 
+// defaultRevokeReason is recorded when a caller revokes sessions without saying why
+const defaultRevokeReason = "revoked"
+
 func (s *GRPCServer) GetSession(_ context.Context, request *proto.GetSessionRequest) (*proto.GetSessionResponse, error) {
-	session, err := s.repo.GetSessionWithToken(request.Token)
+	state, err := s.loadSession(request.Token)
+	if err != nil {
+		return nil, rpcError(err)
+	}
+
+	response, err := state.ConvertToProtobuff()
+	if err != nil {
+		return nil, rpcError(err)
+	}
+	return response, nil
+}
+
+func (s *GRPCServer) ElevateSession(_ context.Context, request *proto.ElevateSessionRequest) (*proto.ElevateSessionResponse, error) {
+	if len(request.ScopeGroupings) == 0 {
+		return nil, rpcError(domain_errors.InvalidArgument("elevating a session requires at least one scope grouping"))
+	}
+
+	state, err := s.loadSession(request.Token)
+	if err != nil {
+		return nil, rpcError(err)
+	}
+	if state.revoked {
+		return nil, rpcError(domain_errors.InvalidToken(domain_errors.ReasonInvalidToken, "session has been revoked"))
+	}
+
+	user, err := s.store.GetUserWithUUID(state.validated.Session.CustomerUUID)
+	if err != nil {
+		return nil, rpcError(err)
+	}
+
+	err = s.builder.verifyPassword(user, request.Password)
+	if err != nil {
+		return nil, rpcError(err)
+	}
+
+	// The new token carries the groupings that are still live alongside the new ones
+	scopeGroupings, err := convertScopeGroupingStatusesToProtobuff(state.validated.ActiveGroupings)
+	if err != nil {
+		return nil, rpcError(err)
+	}
+	scopeGroupings = append(scopeGroupings, request.ScopeGroupings...)
+
+	sessionUUID, err := uuid.Parse(state.session.uuid)
+	if err != nil {
+		return nil, rpcError(err)
+	}
+
+	sessionToken, json, furthestExpiration, err := s.builder.buildToken(user, sessionUUID, scopeGroupings)
 	if err != nil {
-		panic(err)
+		return nil, rpcError(err)
 	}
 
-	json := session_representations.DecodeTokenToJson(session.token)
+	tx, err :=  s.store.Begin()
+	if err != nil {
+		return nil, rpcError(err)
+	}
 
-	return &proto.GetSessionResponse{Session:&proto.Session{Uuid:session.uuid, Token:session.token, Json:json}}, nil
+	_, err = s.builder.buildScopeGroupings(tx, request.ScopeGroupings, state.session.id)
+	if err != nil {
+		tx.Rollback()
+		return nil, rpcError(err)
+	}
+
+	err = s.store.ReplaceSessionToken(tx, state.session.id, request.Token, sessionToken, furthestExpiration)
+	if err != nil {
+		tx.Rollback()
+		return nil, rpcError(err)
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		return nil, rpcError(err)
+	}
+	return &proto.ElevateSessionResponse{Session: &proto.Session{Uuid:state.session.uuid, Token:sessionToken, Json:json}}, nil
+}
+
+// refreshTokenReusedReason is recorded on sessions revoked because a used refresh token came back
+const refreshTokenReusedReason = "refresh token reused"
+
+func (s *GRPCServer) RefreshSession(_ context.Context, request *proto.RefreshSessionRequest) (*proto.RefreshSessionResponse, error) {
+	tx, err :=  s.store.Begin()
+	if err != nil {
+		return nil, rpcError(err)
+	}
+
+	refreshToken, err := s.store.GetRefreshTokenWithHashForUpdate(tx, hashRefreshToken(request.RefreshToken))
+	if err != nil {
+		tx.Rollback()
+		return nil, rpcError(err)
+	}
+
+	if refreshToken.usedAt.Valid || refreshToken.revokedAt.Valid {
+		// Someone else holds a copy of the token, nothing issued from this login can be trusted anymore
+		err = s.store.RevokeRefreshTokenFamily(tx, refreshToken, refreshTokenReusedReason)
+		if err != nil {
+			tx.Rollback()
+			return nil, rpcError(err)
+		}
+		err = tx.Commit()
+		if err != nil {
+			return nil, rpcError(err)
+		}
+		return nil, rpcError(domain_errors.InvalidToken(domain_errors.ReasonRefreshTokenReused, "refresh token has already been used"))
+	}
+
+	session, err := s.store.GetSessionWithIDUsingTx(tx, refreshToken.sessionId)
+	if err != nil {
+		tx.Rollback()
+		return nil, rpcError(err)
+	}
+
+	_, err = s.store.GetSessionRevokeUsingTx(tx, session.id)
+	if err == nil {
+		tx.Rollback()
+		return nil, rpcError(domain_errors.InvalidToken(domain_errors.ReasonInvalidRefreshToken, "session has been revoked"))
+	}
+	if !domain_errors.Is(err, domain_errors.KindNotFound) {
+		tx.Rollback()
+		return nil, rpcError(err)
+	}
+
+	err = s.activity.seen(session)
+	if err != nil {
+		tx.Rollback()
+		return nil, rpcError(err)
+	}
+
+	user, err := s.store.GetUserWithSessionIDUsingTx(tx, session.id)
+	if err != nil {
+		tx.Rollback()
+		return nil, rpcError(err)
+	}
+
+	// The new token is built from the stored groupings, so it includes anything added by an elevation
+	groupings, err := s.store.GetScopeGroupingsForSession(tx, session.id)
+	if err != nil {
+		tx.Rollback()
+		return nil, rpcError(err)
+	}
+
+	var scopeGroupings []*proto.ScopeGrouping
+	for _, sg := range groupings {
+		if s.validator.ValidateExpiration(sg.expiration) != nil {
+			continue
+		}
+		protoGrouping, err := sg.ConvertToProtobuff()
+		if err != nil {
+			tx.Rollback()
+			return nil, rpcError(err)
+		}
+		scopeGroupings = append(scopeGroupings, protoGrouping)
+	}
+	if len(scopeGroupings) == 0 {
+		tx.Rollback()
+		return nil, rpcError(domain_errors.ExpiredToken("session has expired"))
+	}
+
+	sessionUUID, err := uuid.Parse(session.uuid)
+	if err != nil {
+		tx.Rollback()
+		return nil, rpcError(err)
+	}
+
+	sessionToken, json, furthestExpiration, err := s.builder.buildToken(user, sessionUUID, scopeGroupings)
+	if err != nil {
+		tx.Rollback()
+		return nil, rpcError(err)
+	}
+
+	err = s.store.ReplaceSessionToken(tx, session.id, session.token, sessionToken, furthestExpiration)
+	if err != nil {
+		tx.Rollback()
+		return nil, rpcError(err)
+	}
+
+	err = s.store.MarkRefreshTokenUsed(tx, refreshToken.id)
+	if err != nil {
+		tx.Rollback()
+		return nil, rpcError(err)
+	}
+
+	nextRefreshToken, err := s.builder.buildRefreshToken(tx, session.id, refreshToken.familyUUID)
+	if err != nil {
+		tx.Rollback()
+		return nil, rpcError(err)
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		return nil, rpcError(err)
+	}
+	return &proto.RefreshSessionResponse{Session: &proto.Session{Uuid:session.uuid, Token:sessionToken, Json:json, RefreshToken:nextRefreshToken}}, nil
+}
+
+func (s *GRPCServer) Authorize(_ context.Context, request *proto.AuthorizeRequest) (*proto.AuthorizeResponse, error) {
+	state, auth, err := s.authorize(request.Token, request.RequiredScopes)
+	if err != nil {
+		return nil, rpcError(err)
+	}
+
+	response, err := convertAuthorizationToProtobuff(state, auth)
+	if err != nil {
+		return nil, rpcError(err)
+	}
+	return response, nil
+}
+
+func (s *GRPCServer) RevokeSessions(_ context.Context, request *proto.RevokeSessionsRequest) (*proto.RevokeSessionsResponse, error) {
+	reason := request.Reason
+	if reason == "" {
+		reason = defaultRevokeReason
+	}
+
+	var revoked []string
+	var err error
+	switch ident := request.Identifier.(type) {
+	case *proto.RevokeSessionsRequest_SessionUuid:
+		revoked, err = s.store.RevokeSessionWithUUID(ident.SessionUuid, reason)
+	case *proto.RevokeSessionsRequest_UserUuid:
+		revoked, err = s.store.RevokeSessionsForUser(ident.UserUuid, reason)
+	default:
+		err = domain_errors.InvalidArgument("unknown session identifier")
+	}
+	if err != nil {
+		return nil, rpcError(err)
+	}
+
+	return &proto.RevokeSessionsResponse{RevokedSessionUuids:revoked}, nil
 }
 
 func (s *GRPCServer) DeleteSession(_ context.Context, request *proto.DeleteSessionRequest) (*proto.DeleteSessionResponse, error) {
-	successful, err := s.repo.DeleteSessionWithUUID(request.Uuid)
+	successful, err := s.store.DeleteSessionWithUUID(request.Uuid)
 	if err != nil {
-		panic(err)
+		return nil, rpcError(err)
 	}
 
 	return &proto.DeleteSessionResponse{Successful:successful}, nil
-}
\ No newline at end of file
+}
+
+func (s *GRPCServer) GetVerificationKeys(_ context.Context, _ *proto.GetVerificationKeysRequest) (*proto.GetVerificationKeysResponse, error) {
+	vks := s.builder.keyring.VerificationKeys()
+
+	keys := make([]*proto.VerificationKey, len(vks))
+	for i, vk := range vks {
+		key, err := convertVerificationKeyToProtobuff(vk)
+		if err != nil {
+			return nil, rpcError(err)
+		}
+		keys[i] = key
+	}
+
+	return &proto.GetVerificationKeysResponse{Keys:keys}, nil
+}
+
+func (s *GRPCServer) ListJobs(_ context.Context, request *proto.ListJobsRequest) (*proto.ListJobsResponse, error) {
+	limit := int(request.RunLimit)
+	if limit <= 0 {
+		limit = defaultJobRunLimit
+	}
+	if limit > maxJobRunLimit {
+		limit = maxJobRunLimit
+	}
+
+	now := time.Now().UTC()
+	var jobs []*proto.Job
+	for _, job := range s.scheduler.Jobs() {
+		converted := &proto.Job{Name:job.Name}
+		if job.Schedule != nil {
+			converted.Schedule = job.Schedule.String()
+			if next := job.Schedule.Next(now); !next.IsZero() {
+				nextRun, err := ptypes.TimestampProto(next)
+				if err != nil {
+					return nil, rpcError(err)
+				}
+				converted.NextRun = nextRun
+			}
+		}
+
+		runs, err := s.store.GetJobRuns(job.Name, limit)
+		if err != nil {
+			return nil, rpcError(err)
+		}
+		for _, run := range runs {
+			convertedRun, err := run.ConvertToProtobuff()
+			if err != nil {
+				return nil, rpcError(err)
+			}
+			converted.Runs = append(converted.Runs, convertedRun)
+		}
+		jobs = append(jobs, converted)
+	}
+
+	return &proto.ListJobsResponse{Jobs:jobs}, nil
+}
+
+// RunJob waits for the job to finish, a failed job is reported on the run rather than as an error
+func (s *GRPCServer) RunJob(ctx context.Context, request *proto.RunJobRequest) (*proto.RunJobResponse, error) {
+	run, err := s.scheduler.RunJob(ctx, request.Name)
+	if err != nil {
+		return nil, rpcError(err)
+	}
+
+	converted, err := run.ConvertToProtobuff()
+	if err != nil {
+		return nil, rpcError(err)
+	}
+	return &proto.RunJobResponse{Run:converted}, nil
+}
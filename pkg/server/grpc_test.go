@@ -5,6 +5,11 @@ import (
 	"github.com/brianvoe/gofakeit"
 	"github.com/golang/protobuf/ptypes"
 	"github.com/willschroeder/fingerprint/pkg/proto"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"net/http"
+	"net/http/httptest"
+	"strings"
 	"testing"
 	"time"
 )
@@ -14,8 +19,8 @@ func TestCreateUser(t *testing.T) {
 	twoHour, _ := ptypes.TimestampProto(time.Now().Add(time.Hour * time.Duration(2)))
 
 	req := &proto.CreateUserRequest{
-		Email:gofakeit.Email(),
-		Password: "test",
+		Email:                gofakeit.Email(),
+		Password:             "test",
 		PasswordConfirmation: "test",
 		ScopeGroupings: []*proto.ScopeGrouping{
 			{
@@ -42,4 +47,271 @@ func TestGetUserWithUUID(t *testing.T) {
 
 	res, _ := testServer.GetUser(context.Background(), req)
 	print(res.User.Email)
-}
\ No newline at end of file
+}
+
+func createTestSession(t *testing.T) *proto.CreateUserResponse {
+	oneHour, _ := ptypes.TimestampProto(time.Now().Add(time.Hour * time.Duration(1)))
+	req := &proto.CreateUserRequest{
+		Email:                gofakeit.Email(),
+		Password:             "test",
+		PasswordConfirmation: "test",
+		ScopeGroupings: []*proto.ScopeGrouping{
+			{
+				Scopes:     []string{"read"},
+				Expiration: oneHour,
+			},
+		},
+	}
+	res, err := testServer.CreateUser(context.Background(), req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return res
+}
+
+func TestRevokeSessionsForUser(t *testing.T) {
+	created := createTestSession(t)
+
+	req := &proto.RevokeSessionsRequest{
+		Identifier: &proto.RevokeSessionsRequest_UserUuid{UserUuid: created.User.Uuid},
+		Reason:     "password changed",
+	}
+	res, err := testServer.RevokeSessions(context.Background(), req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(res.RevokedSessionUuids) != 1 || res.RevokedSessionUuids[0] != created.Session.Uuid {
+		t.Errorf("Expected session %s to be revoked, got %v", created.Session.Uuid, res.RevokedSessionUuids)
+	}
+
+	got, err := testServer.GetSession(context.Background(), &proto.GetSessionRequest{Token: created.Session.Token})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !got.Revoked || got.RevokedReason != "password changed" {
+		t.Errorf("Expected GetSession to report the revoke, got %v", got)
+	}
+}
+
+func TestGetSessionAfterDelete(t *testing.T) {
+	created := createTestSession(t)
+	testServer.DeleteSession(context.Background(), &proto.DeleteSessionRequest{Uuid: created.Session.Uuid})
+
+	got, err := testServer.GetSession(context.Background(), &proto.GetSessionRequest{Token: created.Session.Token})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !got.Revoked {
+		t.Errorf("Expected a deleted session to report as revoked")
+	}
+}
+
+func TestAuthorize(t *testing.T) {
+	created := createTestSession(t)
+
+	res, err := testServer.Authorize(context.Background(), &proto.AuthorizeRequest{Token: created.Session.Token, RequiredScopes: []string{"read"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.Decision != proto.AuthorizeResponse_ALLOW || res.ScopeGrouping == nil {
+		t.Errorf("Expected read to be allowed, got %v", res)
+	}
+
+	res, err = testServer.Authorize(context.Background(), &proto.AuthorizeRequest{Token: created.Session.Token, RequiredScopes: []string{"write"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.Decision != proto.AuthorizeResponse_DENY || res.DenyReason != proto.AuthorizeResponse_MISSING_SCOPES || res.ReauthenticationRequired {
+		t.Errorf("Expected write to be denied as missing, got %v", res)
+	}
+}
+
+func TestElevateSession(t *testing.T) {
+	created := createTestSession(t)
+	tenMinutes, _ := ptypes.TimestampProto(time.Now().Add(time.Minute * time.Duration(10)))
+
+	req := &proto.ElevateSessionRequest{
+		Token:    created.Session.Token,
+		Password: "test",
+		ScopeGroupings: []*proto.ScopeGrouping{
+			{
+				Scopes:     []string{"write"},
+				Expiration: tenMinutes,
+			},
+		},
+	}
+	res, err := testServer.ElevateSession(context.Background(), req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.Session.Uuid != created.Session.Uuid || res.Session.Token == created.Session.Token {
+		t.Errorf("Expected a new token for session %s, got %v", created.Session.Uuid, res.Session)
+	}
+
+	auth, err := testServer.Authorize(context.Background(), &proto.AuthorizeRequest{Token: res.Session.Token, RequiredScopes: []string{"write"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if auth.Decision != proto.AuthorizeResponse_ALLOW {
+		t.Errorf("Expected the elevated token to allow write, got %v", auth)
+	}
+
+	old, err := testServer.GetSession(context.Background(), &proto.GetSessionRequest{Token: created.Session.Token})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !old.Revoked || old.RevokedReason != sessionTokenReplacedReason {
+		t.Errorf("Expected the previous token to be invalidated, got %v", old)
+	}
+
+	req.Token = created.Session.Token
+	_, err = testServer.ElevateSession(context.Background(), req)
+	if err == nil {
+		t.Errorf("Expected elevating with the replaced token to fail")
+	}
+}
+
+func TestRefreshSessionRotatesTokens(t *testing.T) {
+	created := createTestSession(t)
+
+	res, err := testServer.RefreshSession(context.Background(), &proto.RefreshSessionRequest{RefreshToken: created.Session.RefreshToken})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.Session.Uuid != created.Session.Uuid || res.Session.Token == created.Session.Token || res.Session.RefreshToken == created.Session.RefreshToken {
+		t.Errorf("Expected both tokens to rotate for session %s, got %v", created.Session.Uuid, res.Session)
+	}
+
+	_, err = testServer.RefreshSession(context.Background(), &proto.RefreshSessionRequest{RefreshToken: res.Session.RefreshToken})
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestRefreshSessionRefusesRevokedSessions(t *testing.T) {
+	created := createTestSession(t)
+
+	_, err := testServer.RevokeSessions(context.Background(), &proto.RevokeSessionsRequest{
+		Identifier: &proto.RevokeSessionsRequest_SessionUuid{SessionUuid: created.Session.Uuid},
+		Reason:     "logout",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = testServer.RefreshSession(context.Background(), &proto.RefreshSessionRequest{RefreshToken: created.Session.RefreshToken})
+	if status.Code(err) != codes.Unauthenticated {
+		t.Errorf("Expected a revoked session not to be refreshed, got %v", err)
+	}
+}
+
+func TestRefreshSessionReuseRevokesFamily(t *testing.T) {
+	created := createTestSession(t)
+
+	res, err := testServer.RefreshSession(context.Background(), &proto.RefreshSessionRequest{RefreshToken: created.Session.RefreshToken})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = testServer.RefreshSession(context.Background(), &proto.RefreshSessionRequest{RefreshToken: created.Session.RefreshToken})
+	if err == nil {
+		t.Fatal("Expected replaying a used refresh token to fail")
+	}
+
+	_, err = testServer.RefreshSession(context.Background(), &proto.RefreshSessionRequest{RefreshToken: res.Session.RefreshToken})
+	if err == nil {
+		t.Errorf("Expected the rest of the family to be revoked after a replay")
+	}
+
+	got, err := testServer.GetSession(context.Background(), &proto.GetSessionRequest{Token: res.Session.Token})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !got.Revoked || got.RevokedReason != refreshTokenReusedReason {
+		t.Errorf("Expected the session to be revoked for reuse, got %v", got)
+	}
+}
+
+func TestExtAuthzAllowsWithIdentityHeaders(t *testing.T) {
+	created := createTestSession(t)
+	authz := NewExtAuthzServer(testServer)
+
+	res, err := authz.Check(context.Background(), checkRequest(map[string]string{"authorization": "Bearer " + created.Session.Token}, "read"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	ok := res.GetOkResponse()
+	if ok == nil || len(ok.Headers) != 3 || ok.Headers[0].Header.Value != created.User.Uuid {
+		t.Errorf("Expected an allow with identity headers, got %v", res)
+	}
+
+	res, err = authz.Check(context.Background(), checkRequest(map[string]string{"authorization": "Bearer " + created.Session.Token}, "write"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.GetDeniedResponse() == nil || res.Status.Code != int32(codes.PermissionDenied) {
+		t.Errorf("Expected write to be forbidden, got %v", res)
+	}
+}
+
+func TestForwardAuth(t *testing.T) {
+	created := createTestSession(t)
+	handler := newTestHTTPHandler(t, testServer, HTTPOptions{})
+
+	req := httptest.NewRequest(http.MethodGet, "/forward-auth?scopes=read", nil)
+	req.Header.Set("Authorization", "Bearer "+created.Session.Token)
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+	if rec.Code != http.StatusOK || rec.Header().Get(userUUIDHeader) != created.User.Uuid || rec.Header().Get(sessionUUIDHeader) != created.Session.Uuid {
+		t.Errorf("Expected 200 with identity headers, got %d %v", rec.Code, rec.Header())
+	}
+
+	req = httptest.NewRequest(http.MethodGet, "/forward-auth", nil)
+	req.AddCookie(&http.Cookie{Name: DefaultSessionCookie, Value: created.Session.Token})
+	req.Header.Set(requiredScopesHeader, "write")
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+	if rec.Code != http.StatusForbidden {
+		t.Errorf("Expected 403 for a missing scope, got %d", rec.Code)
+	}
+}
+
+func TestGatewayCreateUser(t *testing.T) {
+	body := `{"email": "` + gofakeit.Email() + `", "password": "test", "password_confirmation": "test", "scope_groupings": [{"scopes": ["read"], "expiration": "` + time.Now().Add(time.Hour).UTC().Format(time.RFC3339) + `"}]}`
+	rec := httptest.NewRecorder()
+	newTestHTTPHandler(t, testServer, HTTPOptions{}).ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/v1/users", strings.NewReader(body)))
+	if rec.Code != http.StatusCreated {
+		t.Errorf("Expected 201, got %d %s", rec.Code, rec.Body.String())
+	}
+}
+
+func TestCreateSessionUnknownEmail(t *testing.T) {
+	_, err := testServer.CreateSession(context.Background(), &proto.CreateSessionRequest{Email: gofakeit.Email(), Password: "test"})
+	if status.Code(err) != codes.Unauthenticated {
+		t.Errorf("Expected an unknown email to look like a wrong password, got %v", err)
+	}
+	if testServer.builder.dummyHash == "" {
+		t.Errorf("Expected an unknown email to pay for a password check")
+	}
+}
+
+func TestCreateSessionRejectsNegativeIdleTimeout(t *testing.T) {
+	email := gofakeit.Email()
+	oneHour, _ := ptypes.TimestampProto(time.Now().Add(time.Hour))
+	scopeGroupings := []*proto.ScopeGrouping{{Scopes: []string{"read"}, Expiration: oneHour}}
+	_, err := testServer.CreateUser(context.Background(), &proto.CreateUserRequest{Email: email, Password: "test", PasswordConfirmation: "test", ScopeGroupings: scopeGroupings})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = testServer.CreateSession(context.Background(), &proto.CreateSessionRequest{Email: email, Password: "test", ScopeGroupings: scopeGroupings, IdleTimeout: ptypes.DurationProto(-time.Minute)})
+	if status.Code(err) != codes.InvalidArgument {
+		t.Errorf("Expected a negative idle timeout to be refused, got %v", err)
+	}
+
+	// A refused request mustn't leave a transaction open, on SQLite it would hold the write lock
+	res, err := testServer.CreateSession(context.Background(), &proto.CreateSessionRequest{Email: email, Password: "test", ScopeGroupings: scopeGroupings, IdleTimeout: ptypes.DurationProto(time.Minute)})
+	if err != nil || res.Session.Token == "" {
+		t.Errorf("Expected a session after the refused one, got %v", err)
+	}
+}
@@ -0,0 +1,35 @@
+package server
+
+import (
+	"github.com/improbable-eng/grpc-web/go/grpcweb"
+	"google.golang.org/grpc"
+	"net/http"
+)
+
+// allowedOrigins answers CORS preflights for gRPC-Web, "*" allows every origin
+func allowedOrigins(origins []string) func(origin string) bool {
+	allowed := map[string]bool{}
+	for _, origin := range origins {
+		allowed[origin] = true
+	}
+	return func(origin string) bool {
+		return allowed["*"] || allowed[origin]
+	}
+}
+
+// newGRPCWebHandler lets browsers call the gRPC server over HTTP/1.1 using the gRPC-Web protocol,
+// anything that isn't a gRPC-Web request goes on to next
+func newGRPCWebHandler(grpcServer *grpc.Server, origins []string, next http.Handler) http.Handler {
+	wrapped := grpcweb.WrapServer(grpcServer,
+		grpcweb.WithOriginFunc(allowedOrigins(origins)),
+		grpcweb.WithAllowedRequestHeaders([]string{"*"}),
+	)
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if wrapped.IsGrpcWebRequest(r) || wrapped.IsAcceptableGrpcCorsRequest(r) {
+			wrapped.ServeHTTP(w, r)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
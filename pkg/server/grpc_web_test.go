@@ -0,0 +1,54 @@
+package server
+
+import (
+	"github.com/willschroeder/fingerprint/pkg/proto"
+	"google.golang.org/grpc"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestAllowedOrigins(t *testing.T) {
+	allowed := allowedOrigins([]string{"https://app.example.com"})
+	if !allowed("https://app.example.com") {
+		t.Errorf("Expected the listed origin to be allowed")
+	}
+	if allowed("https://evil.example.com") {
+		t.Errorf("Expected other origins to be refused")
+	}
+	if !allowedOrigins([]string{"*"})("https://anything.example.com") {
+		t.Errorf("Expected * to allow any origin")
+	}
+}
+
+func TestGRPCWebPreflight(t *testing.T) {
+	next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusTeapot)
+	})
+	grpcServer := grpc.NewServer()
+	proto.RegisterFingerprintServiceServer(grpcServer, &GRPCServer{})
+	handler := newGRPCWebHandler(grpcServer, []string{"https://app.example.com"}, next)
+
+	req := httptest.NewRequest(http.MethodOptions, "/proto.FingerprintService/GetSession", nil)
+	req.Header.Set("Origin", "https://app.example.com")
+	req.Header.Set("Access-Control-Request-Method", "POST")
+	req.Header.Set("Access-Control-Request-Headers", "content-type,x-grpc-web")
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+	if rec.Header().Get("Access-Control-Allow-Origin") != "https://app.example.com" {
+		t.Errorf("Expected the preflight to be allowed, got %d %v", rec.Code, rec.Header())
+	}
+
+	req.Header.Set("Origin", "https://evil.example.com")
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+	if rec.Header().Get("Access-Control-Allow-Origin") != "" {
+		t.Errorf("Expected origins outside the allowlist to be refused, got %v", rec.Header())
+	}
+
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/openapi.json", nil))
+	if rec.Code != http.StatusTeapot {
+		t.Errorf("Expected other requests to reach the next handler, got %d", rec.Code)
+	}
+}
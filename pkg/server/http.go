@@ -0,0 +1,112 @@
+package server
+
+import (
+	"crypto/ecdsa"
+	"encoding/base64"
+	"encoding/json"
+	"github.com/willschroeder/fingerprint/pkg/session_representations"
+	"google.golang.org/grpc"
+	"log"
+	"net/http"
+)
+
+// jwksMaxAge tells verifiers how long they may cache the key set before refreshing it
+const jwksMaxAge = "public, max-age=300"
+
+// jwk is an RFC 7517 key. paserk, primary, not_before and not_after are fingerprint's own members, the
+// validity window is in Unix seconds and deliberately not named after the nbf/exp token claims.
+type jwk struct {
+	Kty       string `json:"kty"`
+	Crv       string `json:"crv"`
+	X         string `json:"x"`
+	Y         string `json:"y,omitempty"`
+	Kid       string `json:"kid"`
+	Alg       string `json:"alg"`
+	Use       string `json:"use"`
+	Paserk    string `json:"paserk,omitempty"`
+	NotBefore int64  `json:"not_before"`
+	NotAfter  int64  `json:"not_after,omitempty"`
+	Primary   bool   `json:"primary"`
+}
+
+type jwks struct {
+	Keys []jwk `json:"keys"`
+}
+
+// HTTPOptions configure the plain HTTP endpoints
+type HTTPOptions struct {
+	// IntrospectionClients may call /introspect, which is only served once there is at least one
+	IntrospectionClients IntrospectionClients
+	// SessionCookie is where /forward-auth looks for a token without an Authorization header
+	SessionCookie string
+	// AllowedOrigins may call the gRPC-Web endpoint from a browser, "*" allows any
+	AllowedOrigins []string
+	// GatewaySecret vouches for the client certificate subjects the gateway forwards, the gateway server's
+	// admin interceptor needs the same secret. Subjects aren't forwarded without one.
+	GatewaySecret string
+}
+
+// NewHTTPHandler serves the REST gateway and the plain HTTP endpoints that live next to the gRPC service.
+// The gateway calls gatewayServer, a plaintext gRPC server with the service registered on it.
+func NewHTTPHandler(server *GRPCServer, gatewayServer *grpc.Server, options HTTPOptions) (http.Handler, error) {
+	if options.SessionCookie == "" {
+		options.SessionCookie = DefaultSessionCookie
+	}
+
+	gateway, err := newGatewayHandler(gatewayServer, options.GatewaySecret)
+	if err != nil {
+		return nil, err
+	}
+
+	mux := http.NewServeMux()
+	mux.Handle("/v1/", gateway)
+	mux.HandleFunc("/openapi.json", openAPIHandler)
+	mux.Handle("/.well-known/jwks.json", verificationKeysHandler(server.builder.keyring))
+	mux.Handle("/forward-auth", server.forwardAuthHandler(options.SessionCookie))
+	if len(options.IntrospectionClients) > 0 {
+		mux.Handle("/introspect", server.introspectionHandler(options.IntrospectionClients))
+	}
+	return mux, nil
+}
+
+// verificationKeysHandler publishes the public token keys as a JWKS document, each key also carries its PASERK
+func verificationKeysHandler(keyring *session_representations.Keyring) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			http.Error(w, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
+			return
+		}
+
+		doc := jwks{Keys: []jwk{}}
+		for _, vk := range keyring.VerificationKeys() {
+			key := jwk{
+				Kty:       "OKP",
+				Crv:       "Ed25519",
+				X:         vk.EncodedPublicKey(),
+				Kid:       vk.ID,
+				Alg:       vk.Algorithm(),
+				Use:       "sig",
+				Paserk:    vk.PASERK(),
+				NotBefore: vk.NotBefore.Unix(),
+				Primary:   vk.Primary,
+			}
+			if pub, ok := vk.PublicKey.(*ecdsa.PublicKey); ok {
+				key.Kty = "EC"
+				key.Crv = "P-256"
+				key.X = base64.RawURLEncoding.EncodeToString(pub.X.FillBytes(make([]byte, 32)))
+				key.Y = base64.RawURLEncoding.EncodeToString(pub.Y.FillBytes(make([]byte, 32)))
+			}
+			if !vk.NotAfter.IsZero() {
+				key.NotAfter = vk.NotAfter.Unix()
+			}
+			doc.Keys = append(doc.Keys, key)
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("Cache-Control", jwksMaxAge)
+		err := json.NewEncoder(w).Encode(doc)
+		if err != nil {
+			log.Printf("failed to write jwks: %v", err)
+		}
+	}
+}
@@ -0,0 +1,79 @@
+package server
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/json"
+	"github.com/willschroeder/fingerprint/pkg/session_representations"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// newKeyringServer is enough of a server for handlers that only decode tokens or publish keys
+func newKeyringServer(keyring *session_representations.Keyring) *GRPCServer {
+	validator := session_representations.NewSessionValidator(session_representations.DefaultClockSkew)
+	return &GRPCServer{builder: &Builder{keyring: keyring}, validator: validator}
+}
+
+func newTestHTTPHandler(t *testing.T, server *GRPCServer, options HTTPOptions) http.Handler {
+	handler, err := NewHTTPHandler(server, NewServiceServer(server), options)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return handler
+}
+
+func TestVerificationKeysDocument(t *testing.T) {
+	_, private, _ := ed25519.GenerateKey(rand.Reader)
+	keyring := session_representations.NewPublicKeyring(0)
+	keyring.AddSigningKey("signing", private)
+
+	rec := httptest.NewRecorder()
+	newTestHTTPHandler(t, newKeyringServer(keyring), HTTPOptions{}).ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/.well-known/jwks.json", nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("Expected 200, got %d", rec.Code)
+	}
+
+	var doc jwks
+	json.Unmarshal(rec.Body.Bytes(), &doc)
+	if len(doc.Keys) != 1 || doc.Keys[0].Kid != "signing" || doc.Keys[0].Crv != "Ed25519" || !doc.Keys[0].Primary {
+		t.Errorf("Unexpected key set %+v", doc)
+	}
+}
+
+func TestVerificationKeysNeverPublishSecrets(t *testing.T) {
+	rec := httptest.NewRecorder()
+	newTestHTTPHandler(t, newKeyringServer(session_representations.DevelopmentKeyring()), HTTPOptions{}).ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/.well-known/jwks.json", nil))
+
+	var doc jwks
+	json.Unmarshal(rec.Body.Bytes(), &doc)
+	if len(doc.Keys) != 0 {
+		t.Errorf("Local keyrings should not publish any keys, got %+v", doc)
+	}
+}
+
+func TestOpenAPIDocument(t *testing.T) {
+	rec := httptest.NewRecorder()
+	newTestHTTPHandler(t, newKeyringServer(session_representations.DevelopmentKeyring()), HTTPOptions{}).ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/openapi.json", nil))
+
+	var doc map[string]interface{}
+	err := json.Unmarshal(rec.Body.Bytes(), &doc)
+	if err != nil {
+		t.Fatal(err)
+	}
+	paths, _ := doc["paths"].(map[string]interface{})
+	if _, ok := paths["/v1/sessions"]; !ok {
+		t.Errorf("Expected the gateway routes to be documented, got %v", paths)
+	}
+}
+
+func TestGatewayMapsErrorsToHTTPStatus(t *testing.T) {
+	rec := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodPost, "/v1/sessions:validate", strings.NewReader(`{"token": "v2.local.garbage"}`))
+	newTestHTTPHandler(t, newKeyringServer(session_representations.DevelopmentKeyring()), HTTPOptions{}).ServeHTTP(rec, req)
+	if rec.Code != http.StatusUnauthorized {
+		t.Errorf("Expected an invalid token to be a 401, got %d %s", rec.Code, rec.Body.String())
+	}
+}
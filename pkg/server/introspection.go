@@ -0,0 +1,111 @@
+package server
+
+import (
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/json"
+	"github.com/willschroeder/fingerprint/pkg/domain_errors"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// IntrospectionClients maps the client ids allowed to call /introspect to their secrets
+type IntrospectionClients map[string]string
+
+// authenticate checks HTTP Basic credentials, comparing digests so neither the length nor the
+// contents of a secret leak through timing
+func (c IntrospectionClients) authenticate(r *http.Request) bool {
+	id, secret, ok := r.BasicAuth()
+	if !ok {
+		return false
+	}
+	expected, ok := c[id]
+	if !ok {
+		return false
+	}
+
+	given := sha256.Sum256([]byte(secret))
+	want := sha256.Sum256([]byte(expected))
+	return subtle.ConstantTimeCompare(given[:], want[:]) == 1
+}
+
+// introspectionResponse follows RFC 7662, scope_groupings is our own extension
+type introspectionResponse struct {
+	Active         bool                    `json:"active"`
+	Scope          string                  `json:"scope,omitempty"`
+	Sub            string                  `json:"sub,omitempty"`
+	Sid            string                  `json:"sid,omitempty"`
+	Exp            int64                   `json:"exp,omitempty"`
+	TokenType      string                  `json:"token_type,omitempty"`
+	ScopeGroupings []introspectionGrouping `json:"scope_groupings,omitempty"`
+}
+
+type introspectionGrouping struct {
+	Scopes []string `json:"scopes"`
+	Exp    int64    `json:"exp"`
+}
+
+// activeUntil is the first moment the token stops validating, whether from its groupings, its own
+// expiration, or idling out
+func (state *sessionState) activeUntil() time.Time {
+	until := state.validated.Expiration
+	if exp := state.validated.Session.TokenExpiration; exp != nil && exp.Before(until) {
+		until = *exp
+	}
+	if !state.idleExpiration.IsZero() && state.idleExpiration.Before(until) {
+		until = state.idleExpiration
+	}
+	return until
+}
+
+// introspectionHandler answers RFC 7662 token introspection requests with the same rules as GetSession.
+// Tokens that fail validation for any reason are reported as inactive, never as an error.
+func (s *GRPCServer) introspectionHandler(clients IntrospectionClients) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			http.Error(w, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
+			return
+		}
+		if !clients.authenticate(r) {
+			w.Header().Set("WWW-Authenticate", `Basic realm="fingerprint"`)
+			http.Error(w, http.StatusText(http.StatusUnauthorized), http.StatusUnauthorized)
+			return
+		}
+
+		token := r.PostFormValue("token")
+		if token == "" {
+			http.Error(w, "token is required", http.StatusBadRequest)
+			return
+		}
+
+		response := introspectionResponse{}
+		state, err := s.loadSession(token)
+		switch {
+		case err == nil && !state.revoked:
+			response = introspectionResponse{
+				Active:    true,
+				Scope:     strings.Join(state.validated.ActiveScopes(), " "),
+				Sub:       state.validated.Session.CustomerUUID,
+				Sid:       state.validated.Session.SessionUUID,
+				Exp:       state.activeUntil().Unix(),
+				TokenType: "access_token",
+			}
+			for _, sg := range state.validated.ActiveGroupings {
+				response.ScopeGroupings = append(response.ScopeGroupings, introspectionGrouping{Scopes: sg.Scopes, Exp: sg.Expiration.Unix()})
+			}
+		case err != nil && domain_errors.KindOf(err) == domain_errors.KindInternal:
+			log.Printf("failed to introspect token: %v", err)
+			http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("Cache-Control", "no-store")
+		err = json.NewEncoder(w).Encode(response)
+		if err != nil {
+			log.Printf("failed to write introspection response: %v", err)
+		}
+	}
+}
@@ -0,0 +1,55 @@
+package server
+
+import (
+	"encoding/json"
+	"github.com/willschroeder/fingerprint/pkg/session_representations"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+)
+
+func introspect(t *testing.T, clientID string, secret string, token string) *httptest.ResponseRecorder {
+	handler := newTestHTTPHandler(t, newKeyringServer(session_representations.DevelopmentKeyring()), HTTPOptions{IntrospectionClients: IntrospectionClients{"gateway": "hunter2"}})
+
+	req := httptest.NewRequest(http.MethodPost, "/introspect", strings.NewReader(url.Values{"token": {token}}.Encode()))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	if clientID != "" {
+		req.SetBasicAuth(clientID, secret)
+	}
+
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+	return rec
+}
+
+func TestIntrospectionRequiresClientCredentials(t *testing.T) {
+	if rec := introspect(t, "", "", "v2.local.x"); rec.Code != http.StatusUnauthorized {
+		t.Errorf("Expected 401 without credentials, got %d", rec.Code)
+	}
+	if rec := introspect(t, "gateway", "wrong", "v2.local.x"); rec.Code != http.StatusUnauthorized {
+		t.Errorf("Expected 401 with the wrong secret, got %d", rec.Code)
+	}
+}
+
+func TestIntrospectionReportsBadTokensInactive(t *testing.T) {
+	rec := introspect(t, "gateway", "hunter2", "v2.local.not-a-real-token")
+	if rec.Code != http.StatusOK {
+		t.Fatalf("Expected 200, got %d", rec.Code)
+	}
+
+	var body map[string]interface{}
+	json.Unmarshal(rec.Body.Bytes(), &body)
+	if len(body) != 1 || body["active"] != false {
+		t.Errorf("Expected only active false, got %v", body)
+	}
+}
+
+func TestIntrospectionDisabledWithoutClients(t *testing.T) {
+	rec := httptest.NewRecorder()
+	newTestHTTPHandler(t, newKeyringServer(session_representations.DevelopmentKeyring()), HTTPOptions{}).ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/introspect", nil))
+	if rec.Code != http.StatusNotFound {
+		t.Errorf("Expected introspection to be off, got %d", rec.Code)
+	}
+}
@@ -0,0 +1,289 @@
+package server
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+	"github.com/willschroeder/fingerprint/pkg/config"
+	"github.com/willschroeder/fingerprint/pkg/domain_errors"
+	"github.com/willschroeder/fingerprint/pkg/schedule"
+	"log"
+	"os"
+	"sort"
+	"sync"
+	"time"
+)
+
+// What started a job run
+const (
+	JobTriggerSchedule = "schedule"
+	JobTriggerManual   = "manual"
+)
+
+const (
+	defaultJobRunLimit = 10
+	maxJobRunLimit     = 100
+)
+
+// Job is periodic work for the Scheduler, one without a Schedule only runs when asked to with RunJob
+type Job struct {
+	Name     string
+	Schedule schedule.Schedule
+	Run      func(ctx context.Context) error
+}
+
+// Scheduler runs jobs on their schedules in every replica. Each run takes the job's lock in the store and
+// is recorded with the tick it was for, so one replica runs a job at a time and every tick runs once.
+// Ticks missed while no replica was up are skipped.
+type Scheduler struct {
+	store   JobStore
+	runner  string
+	mu      sync.Mutex
+	jobs    map[string]*Job
+	started bool
+	ctx     context.Context
+	stop    context.CancelFunc
+}
+
+// NewScheduler records runs as made by runner, which should tell replicas apart
+func NewScheduler(store JobStore, runner string) *Scheduler {
+	ctx, stop := context.WithCancel(context.Background())
+	return &Scheduler{store: store, runner: runner, jobs: map[string]*Job{}, ctx: ctx, stop: stop}
+}
+
+// Register adds a job, it has to be called before Start
+func (s *Scheduler) Register(job *Job) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.started {
+		return errors.New("jobs can't be registered once the scheduler has started")
+	}
+	if _, ok := s.jobs[job.Name]; ok {
+		return fmt.Errorf("a job named %s is already registered", job.Name)
+	}
+	s.jobs[job.Name] = job
+	return nil
+}
+
+// Start runs every scheduled job until Stop is called
+func (s *Scheduler) Start() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.started = true
+	for _, job := range s.jobs {
+		if job.Schedule != nil {
+			go s.loop(job)
+		}
+	}
+}
+
+// Stop stops scheduling and cancels the context jobs run with
+func (s *Scheduler) Stop() {
+	s.stop()
+}
+
+// Jobs returns the registered jobs sorted by name
+func (s *Scheduler) Jobs() []*Job {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	jobs := make([]*Job, 0, len(s.jobs))
+	for _, job := range s.jobs {
+		jobs = append(jobs, job)
+	}
+	sort.Slice(jobs, func(i, j int) bool { return jobs[i].Name < jobs[j].Name })
+	return jobs
+}
+
+// RunJob runs a job now whatever its schedule and waits for it to finish. The job's context is cancelled
+// with ctx or when the scheduler stops. A job that fails still returns its run, with the failure recorded on it.
+func (s *Scheduler) RunJob(ctx context.Context, name string) (*JobRun, error) {
+	s.mu.Lock()
+	job, ok := s.jobs[name]
+	s.mu.Unlock()
+	if !ok {
+		return nil, domain_errors.NotFound(domain_errors.ReasonJobNotFound, "job not found")
+	}
+	ctx, cancel := s.jobContext(ctx)
+	defer cancel()
+	return s.run(ctx, job, JobTriggerManual, time.Time{})
+}
+
+// loop runs a job at each tick of its schedule, schedules are read in UTC so replicas agree on the ticks
+func (s *Scheduler) loop(job *Job) {
+	for {
+		next := job.Schedule.Next(time.Now().UTC())
+		if next.IsZero() {
+			log.Printf("jobs: %s is never scheduled again", job.Name)
+			return
+		}
+
+		timer := time.NewTimer(time.Until(next))
+		select {
+		case <-s.ctx.Done():
+			timer.Stop()
+			return
+		case <-timer.C:
+		}
+
+		run, err := s.run(s.ctx, job, JobTriggerSchedule, next)
+		// Another replica is running the job or has already run this tick
+		if domain_errors.Is(err, domain_errors.KindAborted) || domain_errors.Is(err, domain_errors.KindAlreadyExists) {
+			continue
+		}
+		if err != nil {
+			log.Printf("jobs: %s: %v", job.Name, err)
+			continue
+		}
+		if run.runError.Valid {
+			log.Printf("jobs: %s failed: %s", job.Name, run.runError.String)
+		}
+	}
+}
+
+// jobContext is ctx, also cancelled when the scheduler stops
+func (s *Scheduler) jobContext(ctx context.Context) (context.Context, context.CancelFunc) {
+	ctx, cancel := context.WithCancel(ctx)
+	go func() {
+		select {
+		case <-s.ctx.Done():
+			cancel()
+		case <-ctx.Done():
+		}
+	}()
+	return ctx, cancel
+}
+
+func (s *Scheduler) run(ctx context.Context, job *Job, triggeredBy string, scheduledAt time.Time) (*JobRun, error) {
+	unlock, locked, err := s.store.LockJob(job.Name)
+	if err != nil {
+		return nil, err
+	}
+	if !locked {
+		return nil, domain_errors.Aborted(domain_errors.ReasonJobRunning, "the job is already running")
+	}
+	defer unlock()
+
+	run, err := s.store.CreateJobRun(job.Name, triggeredBy, s.runner, scheduledAt)
+	if err != nil {
+		return nil, err
+	}
+	err = s.store.FinishJobRun(run, callJob(ctx, job))
+	if err != nil {
+		return nil, err
+	}
+	return run, nil
+}
+
+// callJob turns a panicking job into a failed run rather than taking the server down
+func callJob(ctx context.Context, job *Job) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("panic: %v", r)
+		}
+	}()
+	return job.Run(ctx)
+}
+
+// jobRunner names this replica in run history
+func jobRunner() string {
+	hostname, err := os.Hostname()
+	if err != nil {
+		return "unknown"
+	}
+	return hostname
+}
+
+// jobSpec pairs a job with the schedule it runs on when jobs.schedules doesn't name it, empty for none
+type jobSpec struct {
+	job  *Job
+	spec string
+}
+
+// registerJobs gives each job its configured schedule and registers it. Schedules for jobs that don't
+// exist are refused so a typo doesn't silently leave a job unscheduled.
+func registerJobs(scheduler *Scheduler, cfg config.Jobs, specs []jobSpec) error {
+	known := map[string]bool{}
+	for _, js := range specs {
+		known[js.job.Name] = true
+		spec := js.spec
+		if configured, ok := cfg.Schedules[js.job.Name]; ok {
+			spec = configured
+		}
+		if spec != "" && spec != config.JobOff {
+			parsed, err := schedule.Parse(spec)
+			if err != nil {
+				return fmt.Errorf("jobs.schedules.%s: %v", js.job.Name, err)
+			}
+			js.job.Schedule = parsed
+		}
+		err := scheduler.Register(js.job)
+		if err != nil {
+			return err
+		}
+	}
+
+	for name := range cfg.Schedules {
+		if !known[name] {
+			return fmt.Errorf("jobs.schedules.%s: there's no job named %s", name, name)
+		}
+	}
+	return nil
+}
+
+// jobLocks stand in for advisory locks in stores only one process uses at a time
+type jobLocks struct {
+	mu   sync.Mutex
+	held map[string]bool
+}
+
+func (l *jobLocks) lock(name string) (func(), bool, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.held[name] {
+		return nil, false, nil
+	}
+	if l.held == nil {
+		l.held = map[string]bool{}
+	}
+	l.held[name] = true
+	return func() {
+		l.mu.Lock()
+		delete(l.held, name)
+		l.mu.Unlock()
+	}, true, nil
+}
+
+// newJobRun is a run starting now, stores fill in its id once it's saved
+func newJobRun(name string, triggeredBy string, runner string, scheduledAt time.Time) *JobRun {
+	run := &JobRun{uuid: uuid.New().String(), jobName: name, triggeredBy: triggeredBy, runner: runner, startedAt: time.Now().UTC()}
+	if !scheduledAt.IsZero() {
+		run.scheduledAt = pq.NullTime{Time: scheduledAt.UTC(), Valid: true}
+	}
+	return run
+}
+
+// scanJobRuns reads rows selected as id,uuid,job_name,triggered_by,runner,scheduled_at,started_at,finished_at,error
+func scanJobRuns(rows *sql.Rows) ([]*JobRun, error) {
+	defer rows.Close()
+
+	var runs []*JobRun
+	for rows.Next() {
+		var r JobRun
+		err := rows.Scan(&r.id, &r.uuid, &r.jobName, &r.triggeredBy, &r.runner, &r.scheduledAt, &r.startedAt, &r.finishedAt, &r.runError)
+		if err != nil {
+			return nil, domain_errors.Internal(err)
+		}
+		runs = append(runs, &r)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, domain_errors.Internal(err)
+	}
+	return runs, nil
+}
@@ -0,0 +1,262 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"github.com/google/uuid"
+	"github.com/willschroeder/fingerprint/pkg/config"
+	"github.com/willschroeder/fingerprint/pkg/domain_errors"
+	"github.com/willschroeder/fingerprint/pkg/proto"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// soon fires every few milliseconds so tests don't wait on a real schedule
+type soon struct{}
+
+func (soon) Next(t time.Time) time.Time {
+	return t.Add(10 * time.Millisecond)
+}
+
+func (soon) String() string {
+	return "soon"
+}
+
+func testJob(run func(ctx context.Context) error) *Job {
+	return &Job{Name: "test-" + uuid.New().String(), Run: run}
+}
+
+func TestSchedulerRunJob(t *testing.T) {
+	scheduler := NewScheduler(testStore, "test")
+	ok := testJob(func(context.Context) error { return nil })
+	failing := testJob(func(context.Context) error { return errors.New("boom") })
+	panicking := testJob(func(context.Context) error { panic("oops") })
+	for _, job := range []*Job{ok, failing, panicking} {
+		if err := scheduler.Register(job); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	run, err := scheduler.RunJob(context.Background(), ok.Name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !run.finishedAt.Valid || run.runError.Valid || run.triggeredBy != JobTriggerManual || run.scheduledAt.Valid {
+		t.Errorf("Expected a finished manual run, got %+v", run)
+	}
+
+	for job, expected := range map[*Job]string{failing: "boom", panicking: "panic: oops"} {
+		run, err = scheduler.RunJob(context.Background(), job.Name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if run.runError.String != expected {
+			t.Errorf("Expected %s to fail with %q, got %+v", job.Name, expected, run.runError)
+		}
+	}
+
+	runs, err := testStore.GetJobRuns(failing.Name, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(runs) != 1 || runs[0].runError.String != "boom" || !runs[0].finishedAt.Valid {
+		t.Errorf("Expected the failed run to be recorded, got %+v", runs)
+	}
+
+	_, err = scheduler.RunJob(context.Background(), "missing")
+	if !domain_errors.Is(err, domain_errors.KindNotFound) {
+		t.Errorf("Expected an unknown job to be not found, got %v", err)
+	}
+	if err := scheduler.Register(ok); err == nil {
+		t.Errorf("Expected a second job with the same name to be refused")
+	}
+}
+
+func TestSchedulerRunJobIsCancelled(t *testing.T) {
+	waiting := testJob(func(ctx context.Context) error {
+		<-ctx.Done()
+		return ctx.Err()
+	})
+	scheduler := NewScheduler(testStore, "test")
+	scheduler.Register(waiting)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	run, err := scheduler.RunJob(ctx, waiting.Name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if run.runError.String != context.DeadlineExceeded.Error() {
+		t.Errorf("Expected the caller's deadline to reach the job, got %+v", run.runError)
+	}
+
+	go func() {
+		time.Sleep(10 * time.Millisecond)
+		scheduler.Stop()
+	}()
+	run, err = scheduler.RunJob(context.Background(), waiting.Name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if run.runError.String != context.Canceled.Error() {
+		t.Errorf("Expected stopping the scheduler to cancel the job, got %+v", run.runError)
+	}
+}
+
+func TestSchedulerLocksJobs(t *testing.T) {
+	started := make(chan struct{})
+	release := make(chan struct{})
+	job := testJob(func(context.Context) error {
+		close(started)
+		<-release
+		return nil
+	})
+	// Two replicas sharing a store
+	first := NewScheduler(testStore, "first")
+	second := NewScheduler(testStore, "second")
+	first.Register(job)
+	second.Register(job)
+
+	done := make(chan error)
+	go func() {
+		_, err := first.RunJob(context.Background(), job.Name)
+		done <- err
+	}()
+	<-started
+
+	_, err := second.RunJob(context.Background(), job.Name)
+	if !domain_errors.Is(err, domain_errors.KindAborted) {
+		t.Errorf("Expected the job to be locked while it runs, got %v", err)
+	}
+	close(release)
+	if err := <-done; err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = second.RunJob(context.Background(), job.Name)
+	if err != nil {
+		t.Errorf("Expected the lock to be released once the run finished, got %v", err)
+	}
+}
+
+func TestSchedulerRunsEachTickOnce(t *testing.T) {
+	var runs int32
+	job := testJob(func(context.Context) error {
+		atomic.AddInt32(&runs, 1)
+		return nil
+	})
+	first := NewScheduler(testStore, "first")
+	second := NewScheduler(testStore, "second")
+	first.Register(job)
+	second.Register(job)
+
+	tick := time.Now().Truncate(time.Minute)
+	_, err := first.run(context.Background(), job, JobTriggerSchedule, tick)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = second.run(context.Background(), job, JobTriggerSchedule, tick)
+	if !domain_errors.Is(err, domain_errors.KindAlreadyExists) {
+		t.Errorf("Expected a tick that already ran to be refused, got %v", err)
+	}
+	if atomic.LoadInt32(&runs) != 1 {
+		t.Errorf("Expected the job to run once, ran %d times", runs)
+	}
+
+	_, err = second.run(context.Background(), job, JobTriggerSchedule, tick.Add(time.Minute))
+	if err != nil {
+		t.Errorf("Expected the next tick to run, got %v", err)
+	}
+}
+
+func TestSchedulerStart(t *testing.T) {
+	var runs int32
+	job := testJob(func(context.Context) error {
+		atomic.AddInt32(&runs, 1)
+		return nil
+	})
+	job.Schedule = soon{}
+	scheduler := NewScheduler(testStore, "test")
+	scheduler.Register(job)
+	scheduler.Start()
+	defer scheduler.Stop()
+
+	deadline := time.Now().Add(5 * time.Second)
+	for atomic.LoadInt32(&runs) < 2 && time.Now().Before(deadline) {
+		time.Sleep(5 * time.Millisecond)
+	}
+	if atomic.LoadInt32(&runs) < 2 {
+		t.Fatalf("Expected the job to run on its schedule, ran %d times", runs)
+	}
+
+	recorded, err := testStore.GetJobRuns(job.Name, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(recorded) != 1 || recorded[0].triggeredBy != JobTriggerSchedule || !recorded[0].scheduledAt.Valid {
+		t.Errorf("Expected scheduled runs to be recorded with their tick, got %+v", recorded)
+	}
+
+	if scheduler.Register(testJob(nil)) == nil {
+		t.Errorf("Expected jobs to be refused once the scheduler started")
+	}
+}
+
+func TestRegisterJobs(t *testing.T) {
+	scheduler := NewScheduler(testStore, "test")
+	hourly := testJob(nil)
+	off := testJob(nil)
+	cfg := config.Jobs{Schedules: map[string]string{hourly.Name: "@hourly", off.Name: config.JobOff}}
+	err := registerJobs(scheduler, cfg, []jobSpec{{job: hourly}, {job: off, spec: "@every 1m"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if hourly.Schedule == nil || hourly.Schedule.String() != "@hourly" || off.Schedule != nil {
+		t.Errorf("Expected configured schedules to win, got %v and %v", hourly.Schedule, off.Schedule)
+	}
+
+	cfg.Schedules["typo"] = "@daily"
+	err = registerJobs(NewScheduler(testStore, "test"), cfg, []jobSpec{{job: testJob(nil)}})
+	if err == nil {
+		t.Errorf("Expected a schedule for a job that doesn't exist to be refused")
+	}
+}
+
+func TestListAndRunJobs(t *testing.T) {
+	job := testJob(func(context.Context) error { return nil })
+	job.Schedule = soon{}
+	err := testServer.scheduler.Register(job)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	response, err := testServer.RunJob(context.Background(), &proto.RunJobRequest{Name: job.Name})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if response.Run.Job != job.Name || response.Run.FinishedAt == nil || response.Run.Error != "" || response.Run.Runner == "" {
+		t.Errorf("Expected a finished run, got %+v", response.Run)
+	}
+
+	list, err := testServer.ListJobs(context.Background(), &proto.ListJobsRequest{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var listed *proto.Job
+	for _, j := range list.Jobs {
+		if j.Name == job.Name {
+			listed = j
+		}
+	}
+	if listed == nil || listed.Schedule != "soon" || listed.NextRun == nil || len(listed.Runs) != 1 || listed.Runs[0].Uuid != response.Run.Uuid {
+		t.Errorf("Expected the job to be listed with its run, got %+v", listed)
+	}
+
+	_, err = testServer.RunJob(context.Background(), &proto.RunJobRequest{Name: "missing"})
+	if status.Code(err) != codes.NotFound {
+		t.Errorf("Expected an unknown job to be reported, got %v", err)
+	}
+}
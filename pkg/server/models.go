@@ -1,8 +1,11 @@
 package server
 
 import (
+	"database/sql"
 	"github.com/golang/protobuf/ptypes"
+	"github.com/lib/pq"
 	"github.com/willschroeder/fingerprint/pkg/proto"
+	"github.com/willschroeder/fingerprint/pkg/session_representations"
 	"time"
 )
 
@@ -28,16 +31,73 @@ type Session struct {
 	token string
 	customerId int
 	expiration time.Time
+	createdAt time.Time
+	lastSeenAt pq.NullTime
+	// idleTimeout is zero when the session never idles out
+	idleTimeout time.Duration
 }
 
-func (s *Session) ConvertToProtobuff(json string) *proto.Session {
+func (s *Session) ConvertToProtobuff(json string, refreshToken string) *proto.Session {
 	return &proto.Session{
 		Uuid: s.uuid,
 		Token: s.token,
 		Json: json,
+		RefreshToken: refreshToken,
 	}
 }
 
+type SessionRevoke struct {
+	id int
+	uuid string
+	sessionId int
+	reason string
+	createdAt time.Time
+}
+
+type RefreshToken struct {
+	id int
+	uuid string
+	sessionId int
+	familyUUID string
+	usedAt pq.NullTime
+	revokedAt pq.NullTime
+}
+
+// Caller is an internal service allowed to call the gRPC API with an API key
+type Caller struct {
+	id int
+	uuid string
+	name string
+	allowedMethods []string
+	allowedScopes []string
+	revokedAt pq.NullTime
+}
+
+// mayCall takes the method name without its service, "*" allows every method
+func (c *Caller) mayCall(method string) bool {
+	return containsOrWildcard(c.allowedMethods, method)
+}
+
+// disallowedScopes returns the scopes the caller can't put in a session, "*" allows any
+func (c *Caller) disallowedScopes(scopes []string) []string {
+	var disallowed []string
+	for _, scope := range scopes {
+		if !containsOrWildcard(c.allowedScopes, scope) {
+			disallowed = append(disallowed, scope)
+		}
+	}
+	return disallowed
+}
+
+func containsOrWildcard(allowed []string, value string) bool {
+	for _, a := range allowed {
+		if a == "*" || a == value {
+			return true
+		}
+	}
+	return false
+}
+
 type ScopeGrouping struct {
 	id int
 	uuid string
@@ -56,4 +116,83 @@ func (sg *ScopeGrouping) ConvertToProtobuff() (*proto.ScopeGrouping, error) {
 		Scopes: sg.scopes,
 		Expiration: timestamp,
 	}, nil
-}
\ No newline at end of file
+}
+
+// JobRun is one run of a scheduled job
+type JobRun struct {
+	id int
+	uuid string
+	jobName string
+	triggeredBy string
+	runner string
+	// scheduledAt is the tick a scheduled run was for, manual runs leave it unset
+	scheduledAt pq.NullTime
+	startedAt time.Time
+	finishedAt pq.NullTime
+	// runError is set when the job failed
+	runError sql.NullString
+}
+
+// finish marks the run finished now, failed when runErr is set
+func (r *JobRun) finish(runErr error) {
+	r.finishedAt = pq.NullTime{Time: time.Now().UTC(), Valid: true}
+	if runErr != nil {
+		r.runError = sql.NullString{String: runErr.Error(), Valid: true}
+	}
+}
+
+func (r *JobRun) ConvertToProtobuff() (*proto.JobRun, error) {
+	startedAt, err := ptypes.TimestampProto(r.startedAt)
+	if err != nil {
+		return nil, err
+	}
+
+	run := &proto.JobRun{
+		Uuid: r.uuid,
+		Job: r.jobName,
+		TriggeredBy: r.triggeredBy,
+		Runner: r.runner,
+		StartedAt: startedAt,
+		Error: r.runError.String,
+	}
+
+	if r.scheduledAt.Valid {
+		run.ScheduledAt, err = ptypes.TimestampProto(r.scheduledAt.Time)
+		if err != nil {
+			return nil, err
+		}
+	}
+	if r.finishedAt.Valid {
+		run.FinishedAt, err = ptypes.TimestampProto(r.finishedAt.Time)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return run, nil
+}
+
+func convertVerificationKeyToProtobuff(vk *session_representations.VerificationKey) (*proto.VerificationKey, error) {
+	notBefore, err := ptypes.TimestampProto(vk.NotBefore)
+	if err != nil {
+		return nil, err
+	}
+
+	key := &proto.VerificationKey{
+		Kid: vk.ID,
+		Algorithm: vk.Algorithm(),
+		PublicKey: vk.EncodedPublicKey(),
+		Paserk: vk.PASERK(),
+		NotBefore: notBefore,
+		Primary: vk.Primary,
+	}
+
+	if !vk.NotAfter.IsZero() {
+		key.NotAfter, err = ptypes.TimestampProto(vk.NotAfter)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return key, nil
+}
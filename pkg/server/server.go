@@ -1,34 +1,147 @@
 package server
 
 import (
+	authv3 "github.com/envoyproxy/go-control-plane/envoy/service/auth/v3"
+	"github.com/thanhpk/randstr"
+	"github.com/willschroeder/fingerprint/pkg/config"
 	"github.com/willschroeder/fingerprint/pkg/db"
 	"github.com/willschroeder/fingerprint/pkg/proto"
+	"github.com/willschroeder/fingerprint/pkg/session_representations"
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
 	"google.golang.org/grpc/reflection"
 	"log"
 	"net"
+	"net/http"
+	"time"
 )
 
-const (
-	port = ":50051"
-)
 
+// SessionOptions control how long issued sessions and tokens live, zero values turn each limit off
+type SessionOptions struct {
+	// AccessTokenTTL cuts tokens short of their session so they have to be refreshed
+	AccessTokenTTL time.Duration
+	// IdleTimeout is used for sessions created without one of their own
+	IdleTimeout time.Duration
+	// MaxLifetime caps how long activity can keep a session alive
+	MaxLifetime time.Duration
+	// LastSeenFlushInterval is how often batched last seen times are written
+	LastSeenFlushInterval time.Duration
+}
+
+const defaultLastSeenFlushInterval = 10 * time.Second
+
+// NewServiceServer registers the fingerprint services on a new gRPC server
+func NewServiceServer(server *GRPCServer, opts ...grpc.ServerOption) *grpc.Server {
+	s := grpc.NewServer(opts...)
+	proto.RegisterFingerprintServiceServer(s, server)
+	authv3.RegisterAuthorizationServer(s, NewExtAuthzServer(server))
+	return s
+}
 
-func NewServer() {
-	dao := db.ConnectToDatabase()
+// NewServer serves gRPC and HTTP as configured until either listener fails
+func NewServer(cfg *config.Config) {
+	keyring, err := cfg.Tokens.Keyring()
+	if err != nil {
+		log.Fatalf("failed to load token keys: %v", err)
+	}
+	policy, err := cfg.Passwords.Policy()
+	if err != nil {
+		log.Fatalf("failed to build the password policy: %v", err)
+	}
+	validator := session_representations.NewSessionValidator(cfg.Tokens.ClockSkew)
+	options := SessionOptions{
+		AccessTokenTTL: cfg.Sessions.AccessTokenTTL,
+		IdleTimeout: cfg.Sessions.IdleTimeout,
+		MaxLifetime: cfg.Sessions.MaxLifetime,
+		LastSeenFlushInterval: cfg.Sessions.LastSeenFlushInterval,
+	}
+	httpOptions := HTTPOptions{
+		IntrospectionClients: IntrospectionClients(cfg.HTTP.IntrospectionClients),
+		SessionCookie: cfg.HTTP.SessionCookie,
+		AllowedOrigins: cfg.HTTP.CORSAllowedOrigins,
+	}
+	tlsOptions := TLSOptions{
+		CertFile: cfg.TLS.Cert,
+		KeyFile: cfg.TLS.Key,
+		ClientCAFile: cfg.TLS.ClientCA,
+		RequireClientCert: cfg.TLS.RequireClientCert,
+		AdminSubjects: cfg.TLS.AdminSubjects,
+	}
+	callerOptions := CallerOptions{RequireAPIKeys: cfg.Callers.RequireAPIKeys, CacheTTL: cfg.Callers.CacheTTL}
+	cleanOptions := NewCleanOptions(cfg.Clean)
+
+	dao := db.ConnectToDatabase(cfg.Database)
 	defer dao.Conn.Close()
-	repo := &Repo{dao:dao}
-	server := NewGRPCServer(repo,dao)
+	store := NewStore(dao)
+	server := NewGRPCServer(store,policy,keyring,validator,options)
+
+	flushInterval := options.LastSeenFlushInterval
+	if flushInterval <= 0 {
+		flushInterval = defaultLastSeenFlushInterval
+	}
+	go server.activity.run(flushInterval)
+
+	cleanSchedule := ""
+	if cfg.Clean.Interval > 0 {
+		cleanSchedule = "@every " + cfg.Clean.Interval.String()
+	}
+	err = registerJobs(server.scheduler, cfg.Jobs, []jobSpec{
+		{job: &Job{Name: "clean", Run: cleanJob(store, cleanOptions)}, spec: cleanSchedule},
+	})
+	if err != nil {
+		log.Fatalf("failed to schedule jobs: %v", err)
+	}
+	server.scheduler.Start()
+	defer server.scheduler.Stop()
+
+	tlsConfig, err := tlsOptions.serverConfig()
+	if err != nil {
+		log.Fatalf("failed to load tls certificates: %v", err)
+	}
+	// Only the gateway server trusts subjects forwarded in metadata, and only with this process's secret
+	httpOptions.GatewaySecret = randstr.String(32)
+	interceptors := []grpc.UnaryServerInterceptor{adminInterceptor(tlsOptions.AdminSubjects, "")}
+	gatewayInterceptors := []grpc.UnaryServerInterceptor{adminInterceptor(tlsOptions.AdminSubjects, httpOptions.GatewaySecret)}
+	if callerOptions.RequireAPIKeys {
+		callers := callerInterceptor(newCallerCache(store.GetCallerWithAPIKeyHash, callerOptions.CacheTTL))
+		interceptors = append(interceptors, callers)
+		gatewayInterceptors = append(gatewayInterceptors, callers)
+	}
+	interceptor := grpc.ChainUnaryInterceptor(interceptors...)
+	gatewayInterceptor := grpc.ChainUnaryInterceptor(gatewayInterceptors...)
 
 	// GRPC Setup, taken from google's Hello World example
-	lis, err := net.Listen("tcp", port)
+	lis, err := net.Listen("tcp", cfg.Server.GRPCAddress)
 	if err != nil {
 		log.Fatalf("failed to listen: %v", err)
 	}
-	s := grpc.NewServer()
-	proto.RegisterFingerprintServiceServer(s, server)
+	opts := []grpc.ServerOption{interceptor}
+	if tlsConfig != nil {
+		opts = append(opts, grpc.Creds(credentials.NewTLS(tlsConfig.Clone())))
+	}
+	s := NewServiceServer(server, opts...)
 	reflection.Register(s)
+
+	go func() {
+		handler, err := NewHTTPHandler(server, NewServiceServer(server, gatewayInterceptor), httpOptions)
+		if err != nil {
+			log.Fatalf("failed to build http handler: %v", err)
+		}
+		// The HTTP port takes the same certificate and client certificate requirements as the gRPC port
+		httpServer := &http.Server{Addr: cfg.Server.HTTPAddress, Handler: newGRPCWebHandler(s, httpOptions.AllowedOrigins, handler)}
+		if tlsConfig != nil {
+			httpServer.TLSConfig = tlsConfig.Clone()
+			err = httpServer.ListenAndServeTLS("", "")
+		} else {
+			err = httpServer.ListenAndServe()
+		}
+		if err != nil {
+			log.Fatalf("failed to serve http: %v", err)
+		}
+	}()
+
 	if err := s.Serve(lis); err != nil {
 		log.Fatalf("failed to serve: %v", err)
 	}
-}
\ No newline at end of file
+}
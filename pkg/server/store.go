@@ -0,0 +1,107 @@
+package server
+
+import (
+	"github.com/google/uuid"
+	"time"
+)
+
+// Tx groups writes that succeed or fail together, it must be finished with Commit or Rollback
+// unless a method documents that it finishes the transaction itself
+type Tx interface {
+	Commit() error
+	Rollback() error
+}
+
+// Store is everything the server persists. Methods ending in UsingTx, and the ones taking a Tx,
+// see the transaction's uncommitted writes. Errors are domain errors.
+type Store interface {
+	Begin() (Tx, error)
+	UserStore
+	SessionStore
+	ScopeGroupingStore
+	RefreshTokenStore
+	CallerStore
+	CleanupStore
+	JobStore
+}
+
+type UserStore interface {
+	CreateUser(tx Tx, email string, encryptedPassword string, isGuest bool) (*User, error)
+	GetUserWithUUID(userUUID string) (*User, error)
+	GetUserWithUUIDUsingTx(tx Tx, userUUID string) (*User, error)
+	GetUserWithEmail(email string) (*User, error)
+	GetUserWithSessionIDUsingTx(tx Tx, sessionID int) (*User, error)
+	UpdateUserPasswordResetToken(email string) (string, error)
+	// UpdateUserPassword also replaces the reset token so it can't be used again
+	UpdateUserPassword(email string, encryptedPassword string) error
+	UpdateUserEncryptedPassword(userID int, encryptedPassword string) error
+}
+
+type SessionStore interface {
+	CreateSession(tx Tx, newSessionUUID uuid.UUID, userId int, token string, expiration time.Time, idleTimeout time.Duration) (*Session, error)
+	GetSessionWithUUID(sessionUUID string) (*Session, error)
+	GetSessionWithUUIDUsingTx(tx Tx, sessionUUID string) (*Session, error)
+	GetSessionWithIDUsingTx(tx Tx, sessionID int) (*Session, error)
+	GetSessionWithToken(token string) (*Session, error)
+	// ReplaceSessionToken only succeeds while the session still holds previousToken
+	ReplaceSessionToken(tx Tx, sessionID int, previousToken string, token string, expiration time.Time) error
+	// UpdateSessionsLastSeen never moves a last seen time backwards
+	UpdateSessionsLastSeen(lastSeen map[int]time.Time) error
+	DeleteSessionWithUUID(sessionUUID string) (bool, error)
+	RevokeSessionWithUUID(sessionUUID string, reason string) ([]string, error)
+	RevokeSessionsForUser(userUUID string, reason string) ([]string, error)
+	GetSessionRevoke(sessionID int) (*SessionRevoke, error)
+	// GetSessionRevokeUsingTx keeps the session from being revoked until tx finishes
+	GetSessionRevokeUsingTx(tx Tx, sessionID int) (*SessionRevoke, error)
+}
+
+type ScopeGroupingStore interface {
+	CreateScopeGrouping(tx Tx, sessionId int, scopes []string, expiration time.Time) (*ScopeGrouping, error)
+	GetScopeGroupingsForSession(tx Tx, sessionID int) ([]*ScopeGrouping, error)
+}
+
+type RefreshTokenStore interface {
+	CreateRefreshToken(tx Tx, sessionID int, familyUUID string, tokenHash string) error
+	// GetRefreshTokenWithHashForUpdate holds the token until tx finishes so concurrent refreshes are serialized
+	GetRefreshTokenWithHashForUpdate(tx Tx, tokenHash string) (*RefreshToken, error)
+	MarkRefreshTokenUsed(tx Tx, refreshTokenID int) error
+	// RevokeRefreshTokenFamily also revokes the family's session, tx is left for the caller to commit
+	RevokeRefreshTokenFamily(tx Tx, refreshToken *RefreshToken, reason string) error
+}
+
+type CallerStore interface {
+	CreateCaller(name string, apiKeyHash string, allowedMethods []string, allowedScopes []string) (*Caller, error)
+	GetCallerWithAPIKeyHash(apiKeyHash string) (*Caller, error)
+	RevokeCaller(name string) error
+}
+
+// CleanupStore removes rows fingerprint no longer needs. Each method handles at most limit rows and
+// returns how many it handled, or with dryRun counts every row it would handle without changing any.
+type CleanupStore interface {
+	DeleteExpiredSessions(before time.Time, limit int, dryRun bool) (int, error)
+	DeleteExpiredScopeGroupings(before time.Time, limit int, dryRun bool) (int, error)
+	// DeleteStaleGuests removes guests created before the cutoff that have no unexpired sessions
+	DeleteStaleGuests(createdBefore time.Time, limit int, dryRun bool) (int, error)
+	// ExpireResetTokens replaces reset tokens requested before the cutoff so they can't be used
+	ExpireResetTokens(requestedBefore time.Time, limit int, dryRun bool) (int, error)
+	// DeleteRevokedSessions removes sessions revoked before the cutoff along with their revokes
+	DeleteRevokedSessions(revokedBefore time.Time, limit int, dryRun bool) (int, error)
+	// DeleteRetiredRefreshTokens removes refresh tokens used or revoked before the cutoff
+	DeleteRetiredRefreshTokens(retiredBefore time.Time, limit int, dryRun bool) (int, error)
+	DeleteRevokedCallers(revokedBefore time.Time, limit int, dryRun bool) (int, error)
+	// DeleteOldJobRuns removes job run history started before the cutoff
+	DeleteOldJobRuns(startedBefore time.Time, limit int, dryRun bool) (int, error)
+}
+
+// JobStore keeps the history of scheduled jobs and decides which replica runs each one
+type JobStore interface {
+	// LockJob holds the job's lock until unlock is called, locked is false while another runner holds it
+	LockJob(name string) (unlock func(), locked bool, err error)
+	// CreateJobRun records a run starting now, a zero scheduledAt is a manual run. A scheduled run fails
+	// with AlreadyExists when some runner has already started the same tick.
+	CreateJobRun(name string, triggeredBy string, runner string, scheduledAt time.Time) (*JobRun, error)
+	// FinishJobRun records when run finished and runErr if it failed, on run as well
+	FinishJobRun(run *JobRun, runErr error) error
+	// GetJobRuns returns the job's latest runs first
+	GetJobRuns(name string, limit int) ([]*JobRun, error)
+}
@@ -0,0 +1,742 @@
+package server
+
+import (
+	"database/sql"
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+	"github.com/thanhpk/randstr"
+	"github.com/willschroeder/fingerprint/pkg/domain_errors"
+	"sort"
+	"sync"
+	"time"
+)
+
+// MemoryStore keeps everything in process, for tests and for embedding fingerprint without a database.
+// Writes made in a transaction are applied right away and undone on Rollback, so unlike Postgres other
+// callers can see them before the transaction commits.
+type MemoryStore struct {
+	mu            sync.Mutex
+	nextID        int
+	users         map[int]*User
+	userCreatedAt map[int]time.Time
+	sessions      map[int]*Session
+	// sessionRevokes are keyed by session id, a session is only ever revoked once
+	sessionRevokes map[int]*SessionRevoke
+	scopeGroupings map[int]*ScopeGrouping
+	refreshTokens  map[int]*memoryRefreshToken
+	callers        map[int]*memoryCaller
+	// resetRequestedAt is when each user last asked for a password reset token
+	resetRequestedAt map[int]time.Time
+	// rowLocks stand in for SELECT ... FOR UPDATE, keyed by refresh token hash
+	rowLocks map[string]*rowLock
+	jobRuns  map[int]*JobRun
+	jobs     jobLocks
+}
+
+type memoryRefreshToken struct {
+	RefreshToken
+	tokenHash string
+}
+
+type memoryCaller struct {
+	Caller
+	apiKeyHash string
+}
+
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{
+		users:            map[int]*User{},
+		userCreatedAt:    map[int]time.Time{},
+		sessions:         map[int]*Session{},
+		sessionRevokes:   map[int]*SessionRevoke{},
+		scopeGroupings:   map[int]*ScopeGrouping{},
+		refreshTokens:    map[int]*memoryRefreshToken{},
+		callers:          map[int]*memoryCaller{},
+		resetRequestedAt: map[int]time.Time{},
+		rowLocks:         map[string]*rowLock{},
+		jobRuns:          map[int]*JobRun{},
+	}
+}
+
+// rowLock counts the transactions holding or waiting for it, so it can be forgotten once there are none
+type rowLock struct {
+	mu   sync.Mutex
+	refs int
+}
+
+type memoryTx struct {
+	store  *MemoryStore
+	undo   []func()
+	locked map[string]*rowLock
+	done   bool
+}
+
+func (s *MemoryStore) Begin() (Tx, error) {
+	return &memoryTx{store: s, locked: map[string]*rowLock{}}, nil
+}
+
+func (tx *memoryTx) Commit() error {
+	if tx.done {
+		return sql.ErrTxDone
+	}
+	tx.finish()
+	return nil
+}
+
+func (tx *memoryTx) Rollback() error {
+	if tx.done {
+		return sql.ErrTxDone
+	}
+	tx.store.mu.Lock()
+	for i := len(tx.undo) - 1; i >= 0; i-- {
+		tx.undo[i]()
+	}
+	tx.store.mu.Unlock()
+	tx.finish()
+	return nil
+}
+
+func (tx *memoryTx) finish() {
+	tx.done = true
+	tx.store.mu.Lock()
+	defer tx.store.mu.Unlock()
+	for key, lock := range tx.locked {
+		lock.refs--
+		if lock.refs == 0 {
+			delete(tx.store.rowLocks, key)
+		}
+		lock.mu.Unlock()
+	}
+}
+
+// record keeps a way to undo a write, it must be called with the store locked
+func (tx *memoryTx) record(undo func()) {
+	tx.undo = append(tx.undo, undo)
+}
+
+func memTx(tx Tx) *memoryTx {
+	return tx.(*memoryTx)
+}
+
+func (s *MemoryStore) newID() int {
+	s.nextID++
+	return s.nextID
+}
+
+func (s *MemoryStore) CreateUser(tx Tx, email string, encryptedPassword string, isGuest bool) (*User, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, u := range s.users {
+		if u.email == email {
+			return nil, domain_errors.AlreadyExists(domain_errors.ReasonEmailTaken, "a user with that email already exists")
+		}
+	}
+
+	user := &User{id: s.newID(), uuid: uuid.New().String(), email: email, encryptedPassword: encryptedPassword, isGuest: isGuest, passwordResetToken: randstr.String(16)}
+	s.users[user.id] = user
+	s.userCreatedAt[user.id] = time.Now().UTC()
+	memTx(tx).record(func() {
+		delete(s.users, user.id)
+		delete(s.userCreatedAt, user.id)
+	})
+
+	copied := *user
+	return &copied, nil
+}
+
+func (s *MemoryStore) findUser(match func(*User) bool) (*User, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.findUserLocked(match)
+}
+
+func (s *MemoryStore) findUserLocked(match func(*User) bool) (*User, error) {
+	for _, u := range s.users {
+		if match(u) {
+			copied := *u
+			return &copied, nil
+		}
+	}
+	return nil, domain_errors.NotFound(domain_errors.ReasonUserNotFound, "user not found")
+}
+
+func (s *MemoryStore) GetUserWithUUID(userUUID string) (*User, error) {
+	return s.findUser(func(u *User) bool { return u.uuid == userUUID })
+}
+
+func (s *MemoryStore) GetUserWithUUIDUsingTx(_ Tx, userUUID string) (*User, error) {
+	return s.GetUserWithUUID(userUUID)
+}
+
+func (s *MemoryStore) GetUserWithEmail(email string) (*User, error) {
+	return s.findUser(func(u *User) bool { return u.email == email })
+}
+
+func (s *MemoryStore) GetUserWithSessionIDUsingTx(_ Tx, sessionID int) (*User, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	session, ok := s.sessions[sessionID]
+	if !ok {
+		return nil, domain_errors.NotFound(domain_errors.ReasonUserNotFound, "user not found")
+	}
+	return s.findUserLocked(func(u *User) bool { return u.id == session.customerId })
+}
+
+func (s *MemoryStore) UpdateUserPasswordResetToken(email string) (string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, u := range s.users {
+		if u.email == email {
+			u.passwordResetToken = randstr.String(16)
+			s.resetRequestedAt[u.id] = time.Now().UTC()
+			return u.passwordResetToken, nil
+		}
+	}
+	return "", domain_errors.NotFound(domain_errors.ReasonUserNotFound, "user not found")
+}
+
+func (s *MemoryStore) UpdateUserPassword(email string, encryptedPassword string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, u := range s.users {
+		if u.email == email {
+			u.encryptedPassword = encryptedPassword
+			u.passwordResetToken = randstr.String(16)
+			delete(s.resetRequestedAt, u.id)
+			return nil
+		}
+	}
+	return domain_errors.NotFound(domain_errors.ReasonUserNotFound, "user not found")
+}
+
+func (s *MemoryStore) UpdateUserEncryptedPassword(userID int, encryptedPassword string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	u, ok := s.users[userID]
+	if !ok {
+		return domain_errors.NotFound(domain_errors.ReasonUserNotFound, "user not found")
+	}
+	u.encryptedPassword = encryptedPassword
+	return nil
+}
+
+func (s *MemoryStore) CreateSession(tx Tx, newSessionUUID uuid.UUID, userId int, token string, expiration time.Time, idleTimeout time.Duration) (*Session, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now().UTC()
+	session := &Session{
+		id:         s.newID(),
+		uuid:       newSessionUUID.String(),
+		token:      token,
+		customerId: userId,
+		expiration: expiration.UTC(),
+		createdAt:  now,
+		lastSeenAt: pq.NullTime{Time: now, Valid: true},
+		// Postgres keeps whole seconds
+		idleTimeout: idleTimeout.Truncate(time.Second),
+	}
+	s.sessions[session.id] = session
+	memTx(tx).record(func() { delete(s.sessions, session.id) })
+
+	copied := *session
+	return &copied, nil
+}
+
+func (s *MemoryStore) findSession(match func(*Session) bool) (*Session, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, session := range s.sessions {
+		if match(session) {
+			copied := *session
+			return &copied, nil
+		}
+	}
+	return nil, domain_errors.NotFound(domain_errors.ReasonSessionNotFound, "session not found")
+}
+
+func (s *MemoryStore) GetSessionWithUUID(sessionUUID string) (*Session, error) {
+	return s.findSession(func(session *Session) bool { return session.uuid == sessionUUID })
+}
+
+func (s *MemoryStore) GetSessionWithUUIDUsingTx(_ Tx, sessionUUID string) (*Session, error) {
+	return s.GetSessionWithUUID(sessionUUID)
+}
+
+func (s *MemoryStore) GetSessionWithIDUsingTx(_ Tx, sessionID int) (*Session, error) {
+	return s.findSession(func(session *Session) bool { return session.id == sessionID })
+}
+
+func (s *MemoryStore) GetSessionWithToken(token string) (*Session, error) {
+	return s.findSession(func(session *Session) bool { return session.token == token })
+}
+
+func (s *MemoryStore) ReplaceSessionToken(tx Tx, sessionID int, previousToken string, token string, expiration time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	session, ok := s.sessions[sessionID]
+	if !ok || session.token != previousToken {
+		return domain_errors.InvalidToken(domain_errors.ReasonInvalidToken, "session token has already been replaced")
+	}
+
+	previous := *session
+	session.token = token
+	session.expiration = expiration.UTC()
+	memTx(tx).record(func() { *session = previous })
+	return nil
+}
+
+func (s *MemoryStore) UpdateSessionsLastSeen(lastSeen map[int]time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for id, seen := range lastSeen {
+		session, ok := s.sessions[id]
+		if ok && (!session.lastSeenAt.Valid || session.lastSeenAt.Time.Before(seen)) {
+			session.lastSeenAt = pq.NullTime{Time: seen.UTC(), Valid: true}
+		}
+	}
+	return nil
+}
+
+// DeleteSessionWithUUID removes everything belonging to the session, like the cascades in Postgres
+func (s *MemoryStore) DeleteSessionWithUUID(sessionUUID string) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for id, session := range s.sessions {
+		if session.uuid == sessionUUID {
+			s.deleteSessionLocked(id)
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func (s *MemoryStore) deleteSessionLocked(id int) {
+	delete(s.sessions, id)
+	delete(s.sessionRevokes, id)
+	for sgID, sg := range s.scopeGroupings {
+		if sg.sessionId == id {
+			delete(s.scopeGroupings, sgID)
+		}
+	}
+	for rtID, rt := range s.refreshTokens {
+		if rt.sessionId == id {
+			delete(s.refreshTokens, rtID)
+		}
+	}
+}
+
+func (s *MemoryStore) RevokeSessionWithUUID(sessionUUID string, reason string) ([]string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, session := range s.sessions {
+		if session.uuid == sessionUUID {
+			return s.revokeSessionsLocked(nil, []*Session{session}, reason), nil
+		}
+	}
+	return nil, domain_errors.NotFound(domain_errors.ReasonSessionNotFound, "session not found")
+}
+
+func (s *MemoryStore) RevokeSessionsForUser(userUUID string, reason string) ([]string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	user, err := s.findUserLocked(func(u *User) bool { return u.uuid == userUUID })
+	if err != nil {
+		return nil, err
+	}
+
+	var sessions []*Session
+	for _, session := range s.sessions {
+		if session.customerId == user.id {
+			sessions = append(sessions, session)
+		}
+	}
+	sort.Slice(sessions, func(i, j int) bool { return sessions[i].id < sessions[j].id })
+	return s.revokeSessionsLocked(nil, sessions, reason), nil
+}
+
+// revokeSessionsLocked revokes the sessions that aren't revoked yet, returning their uuids. The revokes
+// are undone with tx when there is one.
+func (s *MemoryStore) revokeSessionsLocked(tx *memoryTx, sessions []*Session, reason string) []string {
+	var uuids []string
+	for _, session := range sessions {
+		if _, revoked := s.sessionRevokes[session.id]; revoked {
+			continue
+		}
+		sessionID := session.id
+		s.sessionRevokes[sessionID] = &SessionRevoke{id: s.newID(), uuid: uuid.New().String(), sessionId: sessionID, reason: reason, createdAt: time.Now().UTC()}
+		if tx != nil {
+			tx.record(func() { delete(s.sessionRevokes, sessionID) })
+		}
+		uuids = append(uuids, session.uuid)
+	}
+	return uuids
+}
+
+func (s *MemoryStore) GetSessionRevoke(sessionID int) (*SessionRevoke, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	revoke, ok := s.sessionRevokes[sessionID]
+	if !ok {
+		return nil, domain_errors.NotFound(domain_errors.ReasonSessionRevokeNotFound, "session has not been revoked")
+	}
+	copied := *revoke
+	return &copied, nil
+}
+
+func (s *MemoryStore) GetSessionRevokeUsingTx(tx Tx, sessionID int) (*SessionRevoke, error) {
+	return s.GetSessionRevoke(sessionID)
+}
+
+func (s *MemoryStore) CreateScopeGrouping(tx Tx, sessionId int, scopes []string, expiration time.Time) (*ScopeGrouping, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	sg := &ScopeGrouping{id: s.newID(), uuid: uuid.New().String(), sessionId: sessionId, scopes: append([]string(nil), scopes...), expiration: expiration.UTC()}
+	s.scopeGroupings[sg.id] = sg
+	memTx(tx).record(func() { delete(s.scopeGroupings, sg.id) })
+
+	copied := *sg
+	return &copied, nil
+}
+
+func (s *MemoryStore) GetScopeGroupingsForSession(_ Tx, sessionID int) ([]*ScopeGrouping, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var groupings []*ScopeGrouping
+	for _, sg := range s.scopeGroupings {
+		if sg.sessionId == sessionID {
+			copied := *sg
+			groupings = append(groupings, &copied)
+		}
+	}
+	sort.Slice(groupings, func(i, j int) bool { return groupings[i].id < groupings[j].id })
+	return groupings, nil
+}
+
+func (s *MemoryStore) CreateRefreshToken(tx Tx, sessionID int, familyUUID string, tokenHash string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	rt := &memoryRefreshToken{RefreshToken: RefreshToken{id: s.newID(), uuid: uuid.New().String(), sessionId: sessionID, familyUUID: familyUUID}, tokenHash: tokenHash}
+	s.refreshTokens[rt.id] = rt
+	memTx(tx).record(func() { delete(s.refreshTokens, rt.id) })
+	return nil
+}
+
+func (s *MemoryStore) GetRefreshTokenWithHashForUpdate(tx Tx, tokenHash string) (*RefreshToken, error) {
+	mtx := memTx(tx)
+	if _, held := mtx.locked[tokenHash]; !held {
+		s.mu.Lock()
+		lock, ok := s.rowLocks[tokenHash]
+		if !ok {
+			lock = &rowLock{}
+			s.rowLocks[tokenHash] = lock
+		}
+		lock.refs++
+		s.mu.Unlock()
+
+		lock.mu.Lock()
+		mtx.locked[tokenHash] = lock
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, rt := range s.refreshTokens {
+		if rt.tokenHash == tokenHash {
+			copied := rt.RefreshToken
+			return &copied, nil
+		}
+	}
+	return nil, domain_errors.InvalidToken(domain_errors.ReasonInvalidRefreshToken, "refresh token not recognized")
+}
+
+func (s *MemoryStore) MarkRefreshTokenUsed(tx Tx, refreshTokenID int) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	rt, ok := s.refreshTokens[refreshTokenID]
+	if !ok {
+		return domain_errors.InvalidToken(domain_errors.ReasonInvalidRefreshToken, "refresh token not recognized")
+	}
+	previous := rt.usedAt
+	rt.usedAt = pq.NullTime{Time: time.Now().UTC(), Valid: true}
+	memTx(tx).record(func() { rt.usedAt = previous })
+	return nil
+}
+
+func (s *MemoryStore) RevokeRefreshTokenFamily(tx Tx, refreshToken *RefreshToken, reason string) error {
+	mtx := memTx(tx)
+	s.mu.Lock()
+	now := time.Now().UTC()
+	for _, rt := range s.refreshTokens {
+		if rt.familyUUID == refreshToken.familyUUID && !rt.revokedAt.Valid {
+			rt.revokedAt = pq.NullTime{Time: now, Valid: true}
+		}
+	}
+	if session, ok := s.sessions[refreshToken.sessionId]; ok {
+		s.revokeSessionsLocked(mtx, []*Session{session}, reason)
+	}
+	s.mu.Unlock()
+	return nil
+}
+
+func (s *MemoryStore) CreateCaller(name string, apiKeyHash string, allowedMethods []string, allowedScopes []string) (*Caller, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, c := range s.callers {
+		if c.name == name {
+			return nil, domain_errors.AlreadyExists(domain_errors.ReasonCallerNameTaken, "a caller with that name already exists")
+		}
+	}
+
+	c := &memoryCaller{Caller: Caller{id: s.newID(), uuid: uuid.New().String(), name: name, allowedMethods: allowedMethods, allowedScopes: allowedScopes}, apiKeyHash: apiKeyHash}
+	s.callers[c.id] = c
+	copied := c.Caller
+	return &copied, nil
+}
+
+func (s *MemoryStore) GetCallerWithAPIKeyHash(apiKeyHash string) (*Caller, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, c := range s.callers {
+		if c.apiKeyHash == apiKeyHash && !c.revokedAt.Valid {
+			copied := c.Caller
+			return &copied, nil
+		}
+	}
+	return nil, domain_errors.InvalidToken(domain_errors.ReasonInvalidAPIKey, "api key not recognized")
+}
+
+func (s *MemoryStore) RevokeCaller(name string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, c := range s.callers {
+		if c.name == name && !c.revokedAt.Valid {
+			c.revokedAt = pq.NullTime{Time: time.Now().UTC(), Valid: true}
+			return nil
+		}
+	}
+	return domain_errors.NotFound(domain_errors.ReasonCallerNotFound, "caller not found")
+}
+
+// cleanLocked calls remove with the ids of up to limit matches, lowest first, or counts every match on a
+// dry run. ids are the candidate rows, the store must be locked.
+func cleanLocked(ids []int, matches func(id int) bool, limit int, dryRun bool, remove func(id int)) int {
+	sort.Ints(ids)
+	n := 0
+	for _, id := range ids {
+		if !matches(id) {
+			continue
+		}
+		if !dryRun {
+			if n == limit {
+				break
+			}
+			remove(id)
+		}
+		n++
+	}
+	return n
+}
+
+func (s *MemoryStore) sessionIDs() []int {
+	ids := make([]int, 0, len(s.sessions))
+	for id := range s.sessions {
+		ids = append(ids, id)
+	}
+	return ids
+}
+
+func (s *MemoryStore) DeleteExpiredSessions(before time.Time, limit int, dryRun bool) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	matches := func(id int) bool { return s.sessions[id].expiration.Before(before) }
+	return cleanLocked(s.sessionIDs(), matches, limit, dryRun, s.deleteSessionLocked), nil
+}
+
+func (s *MemoryStore) DeleteExpiredScopeGroupings(before time.Time, limit int, dryRun bool) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var ids []int
+	for id := range s.scopeGroupings {
+		ids = append(ids, id)
+	}
+	matches := func(id int) bool { return s.scopeGroupings[id].expiration.Before(before) }
+	return cleanLocked(ids, matches, limit, dryRun, func(id int) { delete(s.scopeGroupings, id) }), nil
+}
+
+func (s *MemoryStore) DeleteStaleGuests(createdBefore time.Time, limit int, dryRun bool) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+	live := map[int]bool{}
+	for _, session := range s.sessions {
+		if !session.expiration.Before(now) {
+			live[session.customerId] = true
+		}
+	}
+
+	var ids []int
+	for id := range s.users {
+		ids = append(ids, id)
+	}
+	matches := func(id int) bool {
+		return s.users[id].isGuest && s.userCreatedAt[id].Before(createdBefore) && !live[id]
+	}
+	remove := func(id int) {
+		for sessionID, session := range s.sessions {
+			if session.customerId == id {
+				s.deleteSessionLocked(sessionID)
+			}
+		}
+		delete(s.users, id)
+		delete(s.userCreatedAt, id)
+		delete(s.resetRequestedAt, id)
+	}
+	return cleanLocked(ids, matches, limit, dryRun, remove), nil
+}
+
+func (s *MemoryStore) ExpireResetTokens(requestedBefore time.Time, limit int, dryRun bool) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var ids []int
+	for id := range s.resetRequestedAt {
+		ids = append(ids, id)
+	}
+	matches := func(id int) bool { return s.resetRequestedAt[id].Before(requestedBefore) }
+	remove := func(id int) {
+		s.users[id].passwordResetToken = randstr.String(16)
+		delete(s.resetRequestedAt, id)
+	}
+	return cleanLocked(ids, matches, limit, dryRun, remove), nil
+}
+
+func (s *MemoryStore) DeleteRevokedSessions(revokedBefore time.Time, limit int, dryRun bool) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	matches := func(id int) bool {
+		revoke, ok := s.sessionRevokes[id]
+		return ok && revoke.createdAt.Before(revokedBefore)
+	}
+	return cleanLocked(s.sessionIDs(), matches, limit, dryRun, s.deleteSessionLocked), nil
+}
+
+func (s *MemoryStore) DeleteRetiredRefreshTokens(retiredBefore time.Time, limit int, dryRun bool) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var ids []int
+	for id := range s.refreshTokens {
+		ids = append(ids, id)
+	}
+	matches := func(id int) bool {
+		rt := s.refreshTokens[id]
+		return (rt.usedAt.Valid && rt.usedAt.Time.Before(retiredBefore)) || (rt.revokedAt.Valid && rt.revokedAt.Time.Before(retiredBefore))
+	}
+	return cleanLocked(ids, matches, limit, dryRun, func(id int) { delete(s.refreshTokens, id) }), nil
+}
+
+func (s *MemoryStore) DeleteRevokedCallers(revokedBefore time.Time, limit int, dryRun bool) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var ids []int
+	for id := range s.callers {
+		ids = append(ids, id)
+	}
+	matches := func(id int) bool {
+		c := s.callers[id]
+		return c.revokedAt.Valid && c.revokedAt.Time.Before(revokedBefore)
+	}
+	return cleanLocked(ids, matches, limit, dryRun, func(id int) { delete(s.callers, id) }), nil
+}
+
+func (s *MemoryStore) DeleteOldJobRuns(startedBefore time.Time, limit int, dryRun bool) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var ids []int
+	for id := range s.jobRuns {
+		ids = append(ids, id)
+	}
+	matches := func(id int) bool {
+		return s.jobRuns[id].startedAt.Before(startedBefore)
+	}
+	return cleanLocked(ids, matches, limit, dryRun, func(id int) { delete(s.jobRuns, id) }), nil
+}
+
+func (s *MemoryStore) LockJob(name string) (func(), bool, error) {
+	return s.jobs.lock(name)
+}
+
+func (s *MemoryStore) CreateJobRun(name string, triggeredBy string, runner string, scheduledAt time.Time) (*JobRun, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	run := newJobRun(name, triggeredBy, runner, scheduledAt)
+	for _, r := range s.jobRuns {
+		if run.scheduledAt.Valid && r.jobName == name && r.scheduledAt.Valid && r.scheduledAt.Time.Equal(run.scheduledAt.Time) {
+			return nil, domain_errors.AlreadyExists(domain_errors.ReasonJobRunExists, "the job has already run for that tick")
+		}
+	}
+
+	run.id = s.newID()
+	copied := *run
+	s.jobRuns[run.id] = &copied
+	return run, nil
+}
+
+func (s *MemoryStore) FinishJobRun(run *JobRun, runErr error) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	run.finish(runErr)
+	copied := *run
+	s.jobRuns[run.id] = &copied
+	return nil
+}
+
+func (s *MemoryStore) GetJobRuns(name string, limit int) ([]*JobRun, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var runs []*JobRun
+	for _, r := range s.jobRuns {
+		if r.jobName == name {
+			copied := *r
+			runs = append(runs, &copied)
+		}
+	}
+	sort.Slice(runs, func(i, j int) bool {
+		if !runs[i].startedAt.Equal(runs[j].startedAt) {
+			return runs[i].startedAt.After(runs[j].startedAt)
+		}
+		return runs[i].id > runs[j].id
+	})
+	if len(runs) > limit {
+		runs = runs[:limit]
+	}
+	return runs, nil
+}
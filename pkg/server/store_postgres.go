@@ -0,0 +1,688 @@
+package server
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"fmt"
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+	"github.com/thanhpk/randstr"
+	"github.com/willschroeder/fingerprint/pkg/db"
+	"github.com/willschroeder/fingerprint/pkg/domain_errors"
+	"hash/fnv"
+	"log"
+	"time"
+)
+
+const uniqueViolation = "23505"
+
+// PostgresStore is the Store fingerprint runs on in production
+type PostgresStore struct {
+	dao *db.DAO
+}
+
+func NewPostgresStore(dao *db.DAO) *PostgresStore {
+	return &PostgresStore{dao:dao}
+}
+
+func (r *PostgresStore) Begin() (Tx, error) {
+	tx, err := r.dao.Conn.Begin()
+	if err != nil {
+		return nil, domain_errors.Internal(err)
+	}
+	return tx, nil
+}
+
+// sqlTx unwraps a transaction from Begin, every Tx a PostgresStore is handed came from it
+func sqlTx(tx Tx) *sql.Tx {
+	return tx.(*sql.Tx)
+}
+
+func (r *PostgresStore) CreateUser(tx Tx, email string, encryptedPassword string, isGuest bool) (*User, error) {
+	userUUID := uuid.New().String()
+
+	sqlStatement := "INSERT INTO users (uuid, email, encrypted_password, is_guest, password_reset_token, created_at) VALUES ($1, $2, $3, $4, $5, $6)"
+	_, err := sqlTx(tx).Exec(sqlStatement, userUUID, email, encryptedPassword, isGuest, randstr.String(16), time.Now().UTC())
+	if isUniqueViolation(err) {
+		return nil, domain_errors.AlreadyExists(domain_errors.ReasonEmailTaken, "a user with that email already exists")
+	}
+	if err != nil {
+		return nil, domain_errors.Internal(err)
+	}
+
+	return r.GetUserWithUUIDUsingTx(tx, userUUID)
+}
+
+func (r *PostgresStore) UpdateUserPasswordResetToken(email string) (string, error) {
+	newResetToken:= randstr.String(16)
+	sqlStatement := "UPDATE users SET password_reset_token=$1,password_reset_requested_at=$2 WHERE email=$3"
+	res, err := r.dao.Conn.Exec(sqlStatement, newResetToken, time.Now().UTC(), email)
+	if err != nil {
+		return "", domain_errors.Internal(err)
+	}
+
+	err = requireAffected(res, domain_errors.NotFound(domain_errors.ReasonUserNotFound, "user not found"))
+	if err != nil {
+		return "", err
+	}
+
+	return newResetToken, nil
+}
+
+func (r *PostgresStore) GetUserWithUUID(userUUID string) (*User, error) {
+	sqlStatement := "SELECT id,uuid,email,encrypted_password,is_guest,password_reset_token FROM users WHERE uuid=$1"
+
+	row := r.dao.Conn.QueryRow(sqlStatement, userUUID)
+	return scanUser(row)
+}
+
+func (r *PostgresStore) UpdateUserPassword(email string, encryptedPassword string) error {
+	// Generate new token so the old one cant be used again
+	newResetToken:= randstr.String(16)
+	sqlStatement := "UPDATE users SET encrypted_password=$1,password_reset_token=$2,password_reset_requested_at=NULL WHERE email=$3"
+	res, err := r.dao.Conn.Exec(sqlStatement, encryptedPassword, newResetToken, email)
+	if err != nil {
+		return domain_errors.Internal(err)
+	}
+
+	return requireAffected(res, domain_errors.NotFound(domain_errors.ReasonUserNotFound, "user not found"))
+}
+
+// UpdateUserEncryptedPassword swaps the stored hash without touching the reset token, used when rehashing on login
+func (r *PostgresStore) UpdateUserEncryptedPassword(userID int, encryptedPassword string) error {
+	sqlStatement := "UPDATE users SET encrypted_password=$1 WHERE id=$2"
+	res, err := r.dao.Conn.Exec(sqlStatement, encryptedPassword, userID)
+	if err != nil {
+		return domain_errors.Internal(err)
+	}
+
+	return requireAffected(res, domain_errors.NotFound(domain_errors.ReasonUserNotFound, "user not found"))
+}
+
+func (r *PostgresStore) GetUserWithSessionIDUsingTx(tx Tx, sessionID int) (*User, error) {
+	sqlStatement := "SELECT users.id,users.uuid,users.email,users.encrypted_password,users.is_guest,users.password_reset_token FROM users JOIN sessions ON sessions.user_id=users.id WHERE sessions.id=$1"
+
+	row := sqlTx(tx).QueryRow(sqlStatement, sessionID)
+	return scanUser(row)
+}
+
+func (r *PostgresStore) GetUserWithEmail(email string) (*User, error) {
+	sqlStatement := "SELECT id,uuid,email,encrypted_password,is_guest,password_reset_token FROM users WHERE email=$1"
+
+	row := r.dao.Conn.QueryRow(sqlStatement, email)
+	return scanUser(row)
+}
+
+
+func (r *PostgresStore) GetUserWithUUIDUsingTx(tx Tx, userUUID string) (*User, error) {
+	sqlStatement := "SELECT id,uuid,email,encrypted_password,is_guest,password_reset_token FROM users WHERE uuid=$1"
+
+	row := sqlTx(tx).QueryRow(sqlStatement, userUUID)
+	return scanUser(row)
+}
+
+func scanUser(row *sql.Row) (*User, error) {
+	var user User
+	err := row.Scan(&user.id, &user.uuid, &user.email, &user.encryptedPassword, &user.isGuest, &user.passwordResetToken)
+	if err == sql.ErrNoRows {
+		return nil, domain_errors.NotFound(domain_errors.ReasonUserNotFound, "user not found")
+	}
+	if err != nil {
+		return nil, domain_errors.Internal(err)
+	}
+
+	return &user, nil
+}
+
+func (r *PostgresStore) CreateSession(tx Tx, newSessionUUID uuid.UUID, userId int, token string, expiration time.Time, idleTimeout time.Duration) (*Session, error) {
+	sessionUUID := newSessionUUID.String()
+
+	var idleTimeoutSeconds sql.NullInt64
+	if idleTimeout > 0 {
+		idleTimeoutSeconds = sql.NullInt64{Int64: int64(idleTimeout / time.Second), Valid: true}
+	}
+
+	now := time.Now().UTC()
+	sqlStatement := "INSERT INTO sessions (uuid, user_id, token, expiration, created_at, last_seen_at, idle_timeout_seconds) VALUES ($1, $2, $3, $4, $5, $6, $7)"
+	_, err := sqlTx(tx).Exec(sqlStatement, sessionUUID, userId, token, expiration.UTC(), now, now, idleTimeoutSeconds)
+	if err != nil {
+		return nil, domain_errors.Internal(err)
+	}
+
+	return r.GetSessionWithUUIDUsingTx(tx, sessionUUID)
+}
+
+func (r *PostgresStore) GetSessionWithUUIDUsingTx(tx Tx, sessionUUID string) (*Session, error) {
+	sqlStatement := "SELECT id,uuid,user_id,token,expiration,created_at,last_seen_at,idle_timeout_seconds FROM sessions WHERE uuid=$1"
+
+	row := sqlTx(tx).QueryRow(sqlStatement, sessionUUID)
+	return scanSession(row)
+}
+
+func (r *PostgresStore) GetSessionWithUUID(sessionUUID string) (*Session, error) {
+	sqlStatement := "SELECT id,uuid,user_id,token,expiration,created_at,last_seen_at,idle_timeout_seconds FROM sessions WHERE uuid=$1"
+
+	row := r.dao.Conn.QueryRow(sqlStatement, sessionUUID)
+	return scanSession(row)
+}
+
+// ReplaceSessionToken swaps the session's token, only if it still holds previousToken, so two
+// concurrent elevations can't both succeed off the same token
+func (r *PostgresStore) ReplaceSessionToken(tx Tx, sessionID int, previousToken string, token string, expiration time.Time) error {
+	sqlStatement := "UPDATE sessions SET token=$1, expiration=$2 WHERE id=$3 AND token=$4"
+	res, err := sqlTx(tx).Exec(sqlStatement, token, expiration.UTC(), sessionID, previousToken)
+	if err != nil {
+		return domain_errors.Internal(err)
+	}
+
+	return requireAffected(res, domain_errors.InvalidToken(domain_errors.ReasonInvalidToken, "session token has already been replaced"))
+}
+
+func (r *PostgresStore) GetSessionWithIDUsingTx(tx Tx, sessionID int) (*Session, error) {
+	sqlStatement := "SELECT id,uuid,user_id,token,expiration,created_at,last_seen_at,idle_timeout_seconds FROM sessions WHERE id=$1"
+
+	row := sqlTx(tx).QueryRow(sqlStatement, sessionID)
+	return scanSession(row)
+}
+
+func (r *PostgresStore) GetSessionWithToken(token string) (*Session, error) {
+	sqlStatement := "SELECT id,uuid,user_id,token,expiration,created_at,last_seen_at,idle_timeout_seconds FROM sessions WHERE token=$1"
+
+	row := r.dao.Conn.QueryRow(sqlStatement, token)
+	return scanSession(row)
+}
+
+func scanSession(row *sql.Row) (*Session, error) {
+	var session Session
+	var idleTimeoutSeconds sql.NullInt64
+	err := row.Scan(&session.id, &session.uuid, &session.customerId, &session.token, &session.expiration, &session.createdAt, &session.lastSeenAt, &idleTimeoutSeconds)
+	if err == sql.ErrNoRows {
+		return nil, domain_errors.NotFound(domain_errors.ReasonSessionNotFound, "session not found")
+	}
+	if err != nil {
+		return nil, domain_errors.Internal(err)
+	}
+	session.idleTimeout = time.Duration(idleTimeoutSeconds.Int64) * time.Second
+
+	return &session, nil
+}
+
+// UpdateSessionsLastSeen writes a batch of last seen times, never moving one backwards
+func (r *PostgresStore) UpdateSessionsLastSeen(lastSeen map[int]time.Time) error {
+	ids := make([]int64, 0, len(lastSeen))
+	times := make([]string, 0, len(lastSeen))
+	for id, seen := range lastSeen {
+		ids = append(ids, int64(id))
+		times = append(times, seen.UTC().Format(time.RFC3339Nano))
+	}
+
+	sqlStatement := `UPDATE sessions SET last_seen_at=seen.at
+		FROM (SELECT unnest($1::integer[]) AS id, unnest($2::timestamptz[]) AS at) AS seen
+		WHERE sessions.id=seen.id AND (sessions.last_seen_at IS NULL OR sessions.last_seen_at < seen.at)`
+	_, err := r.dao.Conn.Exec(sqlStatement, pq.Array(ids), pq.Array(times))
+	if err != nil {
+		return domain_errors.Internal(err)
+	}
+	return nil
+}
+
+func (r *PostgresStore) CreateScopeGrouping(tx Tx, sessionId int, scopes []string, expiration time.Time) (*ScopeGrouping, error) {
+	groupingUUID := uuid.New().String()
+
+	sqlStatement := "INSERT INTO scope_groupings (uuid, session_id, scopes, expiration, created_at) VALUES ($1, $2, $3, $4, $5)"
+	_, err := sqlTx(tx).Exec(sqlStatement, groupingUUID, sessionId, pq.Array(scopes), expiration, time.Now().UTC())
+	if err != nil {
+		return nil, domain_errors.Internal(err)
+	}
+
+	return r.GetScopeGroupingWithUUID(tx, groupingUUID)
+}
+
+func (r *PostgresStore) GetScopeGroupingsForSession(tx Tx, sessionID int) ([]*ScopeGrouping, error) {
+	sqlStatement := "SELECT id,uuid,session_id,scopes,expiration FROM scope_groupings WHERE session_id=$1 ORDER BY id"
+	rows, err := sqlTx(tx).Query(sqlStatement, sessionID)
+	if err != nil {
+		return nil, domain_errors.Internal(err)
+	}
+	defer rows.Close()
+
+	var groupings []*ScopeGrouping
+	for rows.Next() {
+		var sg ScopeGrouping
+		err = rows.Scan(&sg.id,&sg.uuid,&sg.sessionId,pq.Array(&sg.scopes),&sg.expiration)
+		if err != nil {
+			return nil, domain_errors.Internal(err)
+		}
+		groupings = append(groupings, &sg)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, domain_errors.Internal(err)
+	}
+
+	return groupings, nil
+}
+
+func (r *PostgresStore) GetScopeGroupingWithUUID(tx Tx, groupingUUID string) (*ScopeGrouping, error) {
+	sqlStatement := "SELECT id,uuid,scopes,expiration FROM scope_groupings WHERE uuid=$1"
+	row := sqlTx(tx).QueryRow(sqlStatement, groupingUUID)
+	var sg ScopeGrouping
+	err := row.Scan(&sg.id,&sg.uuid,pq.Array(&sg.scopes),&sg.expiration)
+	if err != nil {
+		return nil, domain_errors.Internal(err)
+	}
+
+	return &sg, nil
+}
+
+func (r *PostgresStore) DeleteSessionWithUUID(sessionUUID string) (bool, error) {
+	sqlStatement := "DELETE FROM sessions WHERE uuid=$1"
+	res, err := r.dao.Conn.Exec(sqlStatement, sessionUUID)
+	if err != nil {
+		return false, domain_errors.Internal(err)
+	}
+
+	deleted, err := res.RowsAffected()
+	if err != nil {
+		return false, domain_errors.Internal(err)
+	}
+	return deleted > 0, nil
+}
+
+// RevokeSessionWithUUID revokes one session, returning its uuid unless it was already revoked
+func (r *PostgresStore) RevokeSessionWithUUID(sessionUUID string, reason string) ([]string, error) {
+	tx, err := r.dao.Conn.Begin()
+	if err != nil {
+		return nil, domain_errors.Internal(err)
+	}
+
+	session, err := r.GetSessionWithUUIDUsingTx(tx, sessionUUID)
+	if err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+
+	sqlStatement := "SELECT id,uuid FROM sessions WHERE id=$1 AND NOT EXISTS (SELECT 1 FROM session_revokes WHERE session_id=sessions.id)"
+	return r.revokeSessions(tx, reason, sqlStatement, session.id)
+}
+
+// RevokeSessionsForUser revokes every live session the user has, returning the uuids revoked
+func (r *PostgresStore) RevokeSessionsForUser(userUUID string, reason string) ([]string, error) {
+	tx, err := r.dao.Conn.Begin()
+	if err != nil {
+		return nil, domain_errors.Internal(err)
+	}
+
+	user, err := r.GetUserWithUUIDUsingTx(tx, userUUID)
+	if err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+
+	sqlStatement := "SELECT id,uuid FROM sessions WHERE user_id=$1 AND NOT EXISTS (SELECT 1 FROM session_revokes WHERE session_id=sessions.id)"
+	return r.revokeSessions(tx, reason, sqlStatement, user.id)
+}
+
+// revokeSessions writes a revoke for every session the query selects, then commits tx
+func (r *PostgresStore) revokeSessions(tx *sql.Tx, reason string, selectStatement string, args ...interface{}) ([]string, error) {
+	uuids, err := r.insertSessionRevokes(tx, reason, selectStatement, args...)
+	if err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		return nil, domain_errors.Internal(err)
+	}
+	return uuids, nil
+}
+
+// insertSessionRevokes writes a revoke for every session the query selects, leaving tx open
+func (r *PostgresStore) insertSessionRevokes(tx *sql.Tx, reason string, selectStatement string, args ...interface{}) ([]string, error) {
+	rows, err := tx.Query(selectStatement, args...)
+	if err != nil {
+		return nil, domain_errors.Internal(err)
+	}
+
+	var ids []int
+	var uuids []string
+	for rows.Next() {
+		var id int
+		var sessionUUID string
+		err = rows.Scan(&id, &sessionUUID)
+		if err != nil {
+			rows.Close()
+			return nil, domain_errors.Internal(err)
+		}
+		ids = append(ids, id)
+		uuids = append(uuids, sessionUUID)
+	}
+	rows.Close()
+
+	sqlStatement := "INSERT INTO session_revokes (uuid, session_id, reason, created_at) VALUES ($1, $2, $3, $4)"
+	for _, id := range ids {
+		_, err = tx.Exec(sqlStatement, uuid.New().String(), id, reason, time.Now().UTC())
+		if err != nil {
+			return nil, domain_errors.Internal(err)
+		}
+	}
+	return uuids, nil
+}
+
+func (r *PostgresStore) GetSessionRevoke(sessionID int) (*SessionRevoke, error) {
+	sqlStatement := "SELECT id,uuid,session_id,reason,created_at FROM session_revokes WHERE session_id=$1"
+
+	return scanSessionRevoke(r.dao.Conn.QueryRow(sqlStatement, sessionID))
+}
+
+// GetSessionRevokeUsingTx locks the session row first, a revoke references the session so it can't be
+// written until tx finishes
+func (r *PostgresStore) GetSessionRevokeUsingTx(tx Tx, sessionID int) (*SessionRevoke, error) {
+	_, err := sqlTx(tx).Exec("SELECT id FROM sessions WHERE id=$1 FOR UPDATE", sessionID)
+	if err != nil {
+		return nil, domain_errors.Internal(err)
+	}
+
+	sqlStatement := "SELECT id,uuid,session_id,reason,created_at FROM session_revokes WHERE session_id=$1"
+
+	return scanSessionRevoke(sqlTx(tx).QueryRow(sqlStatement, sessionID))
+}
+
+func scanSessionRevoke(row *sql.Row) (*SessionRevoke, error) {
+	var revoke SessionRevoke
+	err := row.Scan(&revoke.id, &revoke.uuid, &revoke.sessionId, &revoke.reason, &revoke.createdAt)
+	if err == sql.ErrNoRows {
+		return nil, domain_errors.NotFound(domain_errors.ReasonSessionRevokeNotFound, "session has not been revoked")
+	}
+	if err != nil {
+		return nil, domain_errors.Internal(err)
+	}
+
+	return &revoke, nil
+}
+
+func (r *PostgresStore) CreateRefreshToken(tx Tx, sessionID int, familyUUID string, tokenHash string) error {
+	sqlStatement := "INSERT INTO refresh_tokens (uuid, session_id, family_uuid, token_hash, created_at) VALUES ($1, $2, $3, $4, $5)"
+	_, err := sqlTx(tx).Exec(sqlStatement, uuid.New().String(), sessionID, familyUUID, tokenHash, time.Now().UTC())
+	if err != nil {
+		return domain_errors.Internal(err)
+	}
+	return nil
+}
+
+// GetRefreshTokenWithHashForUpdate locks the row so two refreshes with the same token are serialized,
+// the second one then sees it as used
+func (r *PostgresStore) GetRefreshTokenWithHashForUpdate(tx Tx, tokenHash string) (*RefreshToken, error) {
+	sqlStatement := "SELECT id,uuid,session_id,family_uuid,used_at,revoked_at FROM refresh_tokens WHERE token_hash=$1 FOR UPDATE"
+
+	row := sqlTx(tx).QueryRow(sqlStatement, tokenHash)
+	var rt RefreshToken
+	err := row.Scan(&rt.id, &rt.uuid, &rt.sessionId, &rt.familyUUID, &rt.usedAt, &rt.revokedAt)
+	if err == sql.ErrNoRows {
+		return nil, domain_errors.InvalidToken(domain_errors.ReasonInvalidRefreshToken, "refresh token not recognized")
+	}
+	if err != nil {
+		return nil, domain_errors.Internal(err)
+	}
+
+	return &rt, nil
+}
+
+func (r *PostgresStore) MarkRefreshTokenUsed(tx Tx, refreshTokenID int) error {
+	sqlStatement := "UPDATE refresh_tokens SET used_at=$1 WHERE id=$2"
+	res, err := sqlTx(tx).Exec(sqlStatement, time.Now().UTC(), refreshTokenID)
+	if err != nil {
+		return domain_errors.Internal(err)
+	}
+
+	return requireAffected(res, domain_errors.InvalidToken(domain_errors.ReasonInvalidRefreshToken, "refresh token not recognized"))
+}
+
+// RevokeRefreshTokenFamily revokes every refresh token descended from the same login along with their
+// session. Like revokeSessions it finishes the transaction.
+func (r *PostgresStore) RevokeRefreshTokenFamily(tx Tx, refreshToken *RefreshToken, reason string) error {
+	sqlStatement := "UPDATE refresh_tokens SET revoked_at=$1 WHERE family_uuid=$2 AND revoked_at IS NULL"
+	_, err := sqlTx(tx).Exec(sqlStatement, time.Now().UTC(), refreshToken.familyUUID)
+	if err != nil {
+		return domain_errors.Internal(err)
+	}
+
+	sqlStatement = "SELECT id,uuid FROM sessions WHERE id=$1 AND NOT EXISTS (SELECT 1 FROM session_revokes WHERE session_id=sessions.id)"
+	_, err = r.insertSessionRevokes(sqlTx(tx), reason, sqlStatement, refreshToken.sessionId)
+	return err
+}
+
+func (r *PostgresStore) CreateCaller(name string, apiKeyHash string, allowedMethods []string, allowedScopes []string) (*Caller, error) {
+	callerUUID := uuid.New().String()
+
+	sqlStatement := "INSERT INTO callers (uuid, name, api_key_hash, allowed_methods, allowed_scopes, created_at) VALUES ($1, $2, $3, $4, $5, $6)"
+	_, err := r.dao.Conn.Exec(sqlStatement, callerUUID, name, apiKeyHash, pq.Array(allowedMethods), pq.Array(allowedScopes), time.Now().UTC())
+	if isUniqueViolation(err) {
+		return nil, domain_errors.AlreadyExists(domain_errors.ReasonCallerNameTaken, "a caller with that name already exists")
+	}
+	if err != nil {
+		return nil, domain_errors.Internal(err)
+	}
+
+	return r.GetCallerWithAPIKeyHash(apiKeyHash)
+}
+
+// GetCallerWithAPIKeyHash only finds callers that haven't been revoked
+func (r *PostgresStore) GetCallerWithAPIKeyHash(apiKeyHash string) (*Caller, error) {
+	sqlStatement := "SELECT id,uuid,name,allowed_methods,allowed_scopes,revoked_at FROM callers WHERE api_key_hash=$1 AND revoked_at IS NULL"
+
+	row := r.dao.Conn.QueryRow(sqlStatement, apiKeyHash)
+	var c Caller
+	err := row.Scan(&c.id, &c.uuid, &c.name, pq.Array(&c.allowedMethods), pq.Array(&c.allowedScopes), &c.revokedAt)
+	if err == sql.ErrNoRows {
+		return nil, domain_errors.InvalidToken(domain_errors.ReasonInvalidAPIKey, "api key not recognized")
+	}
+	if err != nil {
+		return nil, domain_errors.Internal(err)
+	}
+
+	return &c, nil
+}
+
+func (r *PostgresStore) RevokeCaller(name string) error {
+	sqlStatement := "UPDATE callers SET revoked_at=$1 WHERE name=$2 AND revoked_at IS NULL"
+	res, err := r.dao.Conn.Exec(sqlStatement, time.Now().UTC(), name)
+	if err != nil {
+		return domain_errors.Internal(err)
+	}
+
+	return requireAffected(res, domain_errors.NotFound(domain_errors.ReasonCallerNotFound, "caller not found"))
+}
+
+func (r *PostgresStore) DeleteExpiredSessions(before time.Time, limit int, dryRun bool) (int, error) {
+	return r.cleanRows("sessions", "expiration < $1", limit, dryRun, before)
+}
+
+func (r *PostgresStore) DeleteExpiredScopeGroupings(before time.Time, limit int, dryRun bool) (int, error) {
+	return r.cleanRows("scope_groupings", "expiration < $1", limit, dryRun, before)
+}
+
+func (r *PostgresStore) DeleteStaleGuests(createdBefore time.Time, limit int, dryRun bool) (int, error) {
+	where := "is_guest AND created_at < $1 AND NOT EXISTS (SELECT 1 FROM sessions WHERE sessions.user_id=users.id AND sessions.expiration >= $2)"
+	return r.cleanRows("users", where, limit, dryRun, createdBefore, time.Now().UTC())
+}
+
+func (r *PostgresStore) ExpireResetTokens(requestedBefore time.Time, limit int, dryRun bool) (int, error) {
+	where := "password_reset_requested_at < $1"
+	if dryRun {
+		return r.countRows("users", where, requestedBefore)
+	}
+
+	tx, err := r.dao.Conn.Begin()
+	if err != nil {
+		return 0, domain_errors.Internal(err)
+	}
+	rows, err := tx.Query("SELECT id FROM users WHERE "+where+" ORDER BY id LIMIT $2", requestedBefore, limit)
+	if err != nil {
+		tx.Rollback()
+		return 0, domain_errors.Internal(err)
+	}
+	var ids []int
+	for rows.Next() {
+		var id int
+		err = rows.Scan(&id)
+		if err != nil {
+			rows.Close()
+			tx.Rollback()
+			return 0, domain_errors.Internal(err)
+		}
+		ids = append(ids, id)
+	}
+	rows.Close()
+
+	// Every user needs a token of their own, it's unique
+	sqlStatement := "UPDATE users SET password_reset_token=$1,password_reset_requested_at=NULL WHERE id=$2"
+	for _, id := range ids {
+		_, err = tx.Exec(sqlStatement, randstr.String(16), id)
+		if err != nil {
+			tx.Rollback()
+			return 0, domain_errors.Internal(err)
+		}
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		return 0, domain_errors.Internal(err)
+	}
+	return len(ids), nil
+}
+
+func (r *PostgresStore) DeleteRevokedSessions(revokedBefore time.Time, limit int, dryRun bool) (int, error) {
+	return r.cleanRows("sessions", "id IN (SELECT session_id FROM session_revokes WHERE created_at < $1)", limit, dryRun, revokedBefore)
+}
+
+func (r *PostgresStore) DeleteRetiredRefreshTokens(retiredBefore time.Time, limit int, dryRun bool) (int, error) {
+	return r.cleanRows("refresh_tokens", "(used_at < $1 OR revoked_at < $1)", limit, dryRun, retiredBefore)
+}
+
+func (r *PostgresStore) DeleteRevokedCallers(revokedBefore time.Time, limit int, dryRun bool) (int, error) {
+	return r.cleanRows("callers", "revoked_at < $1", limit, dryRun, revokedBefore)
+}
+
+func (r *PostgresStore) DeleteOldJobRuns(startedBefore time.Time, limit int, dryRun bool) (int, error) {
+	return r.cleanRows("job_runs", "started_at < $1", limit, dryRun, startedBefore)
+}
+
+// cleanRows deletes up to limit rows of table matching where, or counts every match on a dry run. The
+// limit is bound after args.
+func (r *PostgresStore) cleanRows(table string, where string, limit int, dryRun bool, args ...interface{}) (int, error) {
+	if dryRun {
+		return r.countRows(table, where, args...)
+	}
+
+	sqlStatement := fmt.Sprintf("DELETE FROM %s WHERE id IN (SELECT id FROM %s WHERE %s ORDER BY id LIMIT $%d)", table, table, where, len(args)+1)
+	res, err := r.dao.Conn.Exec(sqlStatement, append(args, limit)...)
+	if err != nil {
+		return 0, domain_errors.Internal(err)
+	}
+	deleted, err := res.RowsAffected()
+	if err != nil {
+		return 0, domain_errors.Internal(err)
+	}
+	return int(deleted), nil
+}
+
+func (r *PostgresStore) countRows(table string, where string, args ...interface{}) (int, error) {
+	var count int
+	err := r.dao.Conn.QueryRow("SELECT COUNT(*) FROM "+table+" WHERE "+where, args...).Scan(&count)
+	if err != nil {
+		return 0, domain_errors.Internal(err)
+	}
+	return count, nil
+}
+
+// LockJob takes a session level advisory lock on a connection of its own, Postgres lets it go by
+// itself if this replica dies mid run
+func (r *PostgresStore) LockJob(name string) (func(), bool, error) {
+	ctx := context.Background()
+	conn, err := r.dao.Conn.Conn(ctx)
+	if err != nil {
+		return nil, false, domain_errors.Internal(err)
+	}
+
+	key := jobLockKey(name)
+	var locked bool
+	err = conn.QueryRowContext(ctx, "SELECT pg_try_advisory_lock($1)", key).Scan(&locked)
+	if err != nil || !locked {
+		conn.Close()
+		if err != nil {
+			return nil, false, domain_errors.Internal(err)
+		}
+		return nil, false, nil
+	}
+
+	unlock := func() {
+		_, err := conn.ExecContext(ctx, "SELECT pg_advisory_unlock($1)", key)
+		if err != nil {
+			log.Printf("jobs: failed to unlock %s: %v", name, err)
+			// Throw the connection away rather than pool it with the lock still held
+			conn.Raw(func(interface{}) error { return driver.ErrBadConn })
+		}
+		conn.Close()
+	}
+	return unlock, true, nil
+}
+
+// jobLockKey is the advisory lock key for a job, namespaced so it's unlikely to collide with other users
+// of advisory locks in the same database
+func jobLockKey(name string) int64 {
+	h := fnv.New64a()
+	h.Write([]byte("fingerprint.jobs." + name))
+	return int64(h.Sum64())
+}
+
+func (r *PostgresStore) CreateJobRun(name string, triggeredBy string, runner string, scheduledAt time.Time) (*JobRun, error) {
+	run := newJobRun(name, triggeredBy, runner, scheduledAt)
+
+	sqlStatement := "INSERT INTO job_runs (uuid, job_name, triggered_by, runner, scheduled_at, started_at) VALUES ($1, $2, $3, $4, $5, $6) RETURNING id"
+	err := r.dao.Conn.QueryRow(sqlStatement, run.uuid, run.jobName, run.triggeredBy, run.runner, run.scheduledAt, run.startedAt).Scan(&run.id)
+	if isUniqueViolation(err) {
+		return nil, domain_errors.AlreadyExists(domain_errors.ReasonJobRunExists, "the job has already run for that tick")
+	}
+	if err != nil {
+		return nil, domain_errors.Internal(err)
+	}
+
+	return run, nil
+}
+
+func (r *PostgresStore) FinishJobRun(run *JobRun, runErr error) error {
+	run.finish(runErr)
+	_, err := r.dao.Conn.Exec("UPDATE job_runs SET finished_at=$1,error=$2 WHERE id=$3", run.finishedAt, run.runError, run.id)
+	if err != nil {
+		return domain_errors.Internal(err)
+	}
+	return nil
+}
+
+func (r *PostgresStore) GetJobRuns(name string, limit int) ([]*JobRun, error) {
+	sqlStatement := "SELECT id,uuid,job_name,triggered_by,runner,scheduled_at,started_at,finished_at,error FROM job_runs WHERE job_name=$1 ORDER BY started_at DESC, id DESC LIMIT $2"
+	rows, err := r.dao.Conn.Query(sqlStatement, name, limit)
+	if err != nil {
+		return nil, domain_errors.Internal(err)
+	}
+	return scanJobRuns(rows)
+}
+
+func isUniqueViolation(err error) bool {
+	pqErr, ok := err.(*pq.Error)
+	return ok && pqErr.Code == uniqueViolation
+}
+
+// requireAffected returns notFound when an UPDATE or DELETE didn't touch any rows
+func requireAffected(res sql.Result, notFound error) error {
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return domain_errors.Internal(err)
+	}
+	if affected == 0 {
+		return notFound
+	}
+	return nil
+}
@@ -0,0 +1,616 @@
+package server
+
+import (
+	"database/sql"
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+	"github.com/google/uuid"
+	"github.com/mattn/go-sqlite3"
+	"github.com/thanhpk/randstr"
+	"github.com/willschroeder/fingerprint/pkg/config"
+	"github.com/willschroeder/fingerprint/pkg/db"
+	"github.com/willschroeder/fingerprint/pkg/domain_errors"
+	"time"
+)
+
+// SQLiteStore is for small deployments that don't want to run Postgres. Its connection takes the write
+// lock when a transaction begins, which is what serializes refreshes in place of SELECT ... FOR UPDATE.
+type SQLiteStore struct {
+	dao  *db.DAO
+	jobs jobLocks
+}
+
+func NewSQLiteStore(dao *db.DAO) *SQLiteStore {
+	return &SQLiteStore{dao: dao}
+}
+
+// NewStore picks the Store for the driver the connection was opened with
+func NewStore(dao *db.DAO) Store {
+	if dao.Driver == config.DriverSQLite {
+		return NewSQLiteStore(dao)
+	}
+	return NewPostgresStore(dao)
+}
+
+// stringList stores a []string as a JSON array, SQLite has no array type
+type stringList []string
+
+func (l stringList) Value() (driver.Value, error) {
+	if l == nil {
+		return "[]", nil
+	}
+	encoded, err := json.Marshal([]string(l))
+	return string(encoded), err
+}
+
+func (l *stringList) Scan(src interface{}) error {
+	switch v := src.(type) {
+	case string:
+		return json.Unmarshal([]byte(v), l)
+	case []byte:
+		return json.Unmarshal(v, l)
+	}
+	return fmt.Errorf("can't scan %T into a string list", src)
+}
+
+func isSQLiteUniqueViolation(err error) bool {
+	sqliteErr, ok := err.(sqlite3.Error)
+	return ok && sqliteErr.ExtendedCode == sqlite3.ErrConstraintUnique
+}
+
+func (r *SQLiteStore) Begin() (Tx, error) {
+	tx, err := r.dao.Conn.Begin()
+	if err != nil {
+		return nil, domain_errors.Internal(err)
+	}
+	return tx, nil
+}
+
+func (r *SQLiteStore) CreateUser(tx Tx, email string, encryptedPassword string, isGuest bool) (*User, error) {
+	userUUID := uuid.New().String()
+
+	sqlStatement := "INSERT INTO users (uuid, email, encrypted_password, is_guest, password_reset_token, created_at) VALUES (?, ?, ?, ?, ?, ?)"
+	_, err := sqlTx(tx).Exec(sqlStatement, userUUID, email, encryptedPassword, isGuest, randstr.String(16), time.Now().UTC())
+	if isSQLiteUniqueViolation(err) {
+		return nil, domain_errors.AlreadyExists(domain_errors.ReasonEmailTaken, "a user with that email already exists")
+	}
+	if err != nil {
+		return nil, domain_errors.Internal(err)
+	}
+
+	return r.GetUserWithUUIDUsingTx(tx, userUUID)
+}
+
+func (r *SQLiteStore) UpdateUserPasswordResetToken(email string) (string, error) {
+	newResetToken := randstr.String(16)
+	sqlStatement := "UPDATE users SET password_reset_token=?,password_reset_requested_at=? WHERE email=?"
+	res, err := r.dao.Conn.Exec(sqlStatement, newResetToken, time.Now().UTC(), email)
+	if err != nil {
+		return "", domain_errors.Internal(err)
+	}
+
+	err = requireAffected(res, domain_errors.NotFound(domain_errors.ReasonUserNotFound, "user not found"))
+	if err != nil {
+		return "", err
+	}
+
+	return newResetToken, nil
+}
+
+func (r *SQLiteStore) GetUserWithUUID(userUUID string) (*User, error) {
+	sqlStatement := "SELECT id,uuid,email,encrypted_password,is_guest,password_reset_token FROM users WHERE uuid=?"
+
+	row := r.dao.Conn.QueryRow(sqlStatement, userUUID)
+	return scanUser(row)
+}
+
+func (r *SQLiteStore) UpdateUserPassword(email string, encryptedPassword string) error {
+	// Generate new token so the old one cant be used again
+	newResetToken := randstr.String(16)
+	sqlStatement := "UPDATE users SET encrypted_password=?,password_reset_token=?,password_reset_requested_at=NULL WHERE email=?"
+	res, err := r.dao.Conn.Exec(sqlStatement, encryptedPassword, newResetToken, email)
+	if err != nil {
+		return domain_errors.Internal(err)
+	}
+
+	return requireAffected(res, domain_errors.NotFound(domain_errors.ReasonUserNotFound, "user not found"))
+}
+
+func (r *SQLiteStore) UpdateUserEncryptedPassword(userID int, encryptedPassword string) error {
+	sqlStatement := "UPDATE users SET encrypted_password=? WHERE id=?"
+	res, err := r.dao.Conn.Exec(sqlStatement, encryptedPassword, userID)
+	if err != nil {
+		return domain_errors.Internal(err)
+	}
+
+	return requireAffected(res, domain_errors.NotFound(domain_errors.ReasonUserNotFound, "user not found"))
+}
+
+func (r *SQLiteStore) GetUserWithSessionIDUsingTx(tx Tx, sessionID int) (*User, error) {
+	sqlStatement := "SELECT users.id,users.uuid,users.email,users.encrypted_password,users.is_guest,users.password_reset_token FROM users JOIN sessions ON sessions.user_id=users.id WHERE sessions.id=?"
+
+	row := sqlTx(tx).QueryRow(sqlStatement, sessionID)
+	return scanUser(row)
+}
+
+func (r *SQLiteStore) GetUserWithEmail(email string) (*User, error) {
+	sqlStatement := "SELECT id,uuid,email,encrypted_password,is_guest,password_reset_token FROM users WHERE email=?"
+
+	row := r.dao.Conn.QueryRow(sqlStatement, email)
+	return scanUser(row)
+}
+
+func (r *SQLiteStore) GetUserWithUUIDUsingTx(tx Tx, userUUID string) (*User, error) {
+	sqlStatement := "SELECT id,uuid,email,encrypted_password,is_guest,password_reset_token FROM users WHERE uuid=?"
+
+	row := sqlTx(tx).QueryRow(sqlStatement, userUUID)
+	return scanUser(row)
+}
+
+func (r *SQLiteStore) CreateSession(tx Tx, newSessionUUID uuid.UUID, userId int, token string, expiration time.Time, idleTimeout time.Duration) (*Session, error) {
+	sessionUUID := newSessionUUID.String()
+
+	var idleTimeoutSeconds sql.NullInt64
+	if idleTimeout > 0 {
+		idleTimeoutSeconds = sql.NullInt64{Int64: int64(idleTimeout / time.Second), Valid: true}
+	}
+
+	now := time.Now().UTC()
+	sqlStatement := "INSERT INTO sessions (uuid, user_id, token, expiration, created_at, last_seen_at, idle_timeout_seconds) VALUES (?, ?, ?, ?, ?, ?, ?)"
+	_, err := sqlTx(tx).Exec(sqlStatement, sessionUUID, userId, token, expiration.UTC(), now, now, idleTimeoutSeconds)
+	if err != nil {
+		return nil, domain_errors.Internal(err)
+	}
+
+	return r.GetSessionWithUUIDUsingTx(tx, sessionUUID)
+}
+
+func (r *SQLiteStore) GetSessionWithUUIDUsingTx(tx Tx, sessionUUID string) (*Session, error) {
+	sqlStatement := "SELECT id,uuid,user_id,token,expiration,created_at,last_seen_at,idle_timeout_seconds FROM sessions WHERE uuid=?"
+
+	row := sqlTx(tx).QueryRow(sqlStatement, sessionUUID)
+	return scanSession(row)
+}
+
+func (r *SQLiteStore) GetSessionWithUUID(sessionUUID string) (*Session, error) {
+	sqlStatement := "SELECT id,uuid,user_id,token,expiration,created_at,last_seen_at,idle_timeout_seconds FROM sessions WHERE uuid=?"
+
+	row := r.dao.Conn.QueryRow(sqlStatement, sessionUUID)
+	return scanSession(row)
+}
+
+func (r *SQLiteStore) ReplaceSessionToken(tx Tx, sessionID int, previousToken string, token string, expiration time.Time) error {
+	sqlStatement := "UPDATE sessions SET token=?, expiration=? WHERE id=? AND token=?"
+	res, err := sqlTx(tx).Exec(sqlStatement, token, expiration.UTC(), sessionID, previousToken)
+	if err != nil {
+		return domain_errors.Internal(err)
+	}
+
+	return requireAffected(res, domain_errors.InvalidToken(domain_errors.ReasonInvalidToken, "session token has already been replaced"))
+}
+
+func (r *SQLiteStore) GetSessionWithIDUsingTx(tx Tx, sessionID int) (*Session, error) {
+	sqlStatement := "SELECT id,uuid,user_id,token,expiration,created_at,last_seen_at,idle_timeout_seconds FROM sessions WHERE id=?"
+
+	row := sqlTx(tx).QueryRow(sqlStatement, sessionID)
+	return scanSession(row)
+}
+
+func (r *SQLiteStore) GetSessionWithToken(token string) (*Session, error) {
+	sqlStatement := "SELECT id,uuid,user_id,token,expiration,created_at,last_seen_at,idle_timeout_seconds FROM sessions WHERE token=?"
+
+	row := r.dao.Conn.QueryRow(sqlStatement, token)
+	return scanSession(row)
+}
+
+// UpdateSessionsLastSeen writes the batch in one transaction, timestamps are stored in a fixed UTC
+// format so comparing them as text orders them by time
+func (r *SQLiteStore) UpdateSessionsLastSeen(lastSeen map[int]time.Time) error {
+	tx, err := r.dao.Conn.Begin()
+	if err != nil {
+		return domain_errors.Internal(err)
+	}
+
+	sqlStatement := "UPDATE sessions SET last_seen_at=?1 WHERE id=?2 AND (last_seen_at IS NULL OR last_seen_at < ?1)"
+	for id, seen := range lastSeen {
+		_, err = tx.Exec(sqlStatement, seen.UTC(), id)
+		if err != nil {
+			tx.Rollback()
+			return domain_errors.Internal(err)
+		}
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		return domain_errors.Internal(err)
+	}
+	return nil
+}
+
+func (r *SQLiteStore) CreateScopeGrouping(tx Tx, sessionId int, scopes []string, expiration time.Time) (*ScopeGrouping, error) {
+	groupingUUID := uuid.New().String()
+
+	sqlStatement := "INSERT INTO scope_groupings (uuid, session_id, scopes, expiration, created_at) VALUES (?, ?, ?, ?, ?)"
+	_, err := sqlTx(tx).Exec(sqlStatement, groupingUUID, sessionId, stringList(scopes), expiration.UTC(), time.Now().UTC())
+	if err != nil {
+		return nil, domain_errors.Internal(err)
+	}
+
+	return r.GetScopeGroupingWithUUID(tx, groupingUUID)
+}
+
+func (r *SQLiteStore) GetScopeGroupingsForSession(tx Tx, sessionID int) ([]*ScopeGrouping, error) {
+	sqlStatement := "SELECT id,uuid,session_id,scopes,expiration FROM scope_groupings WHERE session_id=? ORDER BY id"
+	rows, err := sqlTx(tx).Query(sqlStatement, sessionID)
+	if err != nil {
+		return nil, domain_errors.Internal(err)
+	}
+	defer rows.Close()
+
+	var groupings []*ScopeGrouping
+	for rows.Next() {
+		var sg ScopeGrouping
+		err = rows.Scan(&sg.id, &sg.uuid, &sg.sessionId, (*stringList)(&sg.scopes), &sg.expiration)
+		if err != nil {
+			return nil, domain_errors.Internal(err)
+		}
+		groupings = append(groupings, &sg)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, domain_errors.Internal(err)
+	}
+
+	return groupings, nil
+}
+
+func (r *SQLiteStore) GetScopeGroupingWithUUID(tx Tx, groupingUUID string) (*ScopeGrouping, error) {
+	sqlStatement := "SELECT id,uuid,scopes,expiration FROM scope_groupings WHERE uuid=?"
+	row := sqlTx(tx).QueryRow(sqlStatement, groupingUUID)
+	var sg ScopeGrouping
+	err := row.Scan(&sg.id, &sg.uuid, (*stringList)(&sg.scopes), &sg.expiration)
+	if err != nil {
+		return nil, domain_errors.Internal(err)
+	}
+
+	return &sg, nil
+}
+
+func (r *SQLiteStore) DeleteSessionWithUUID(sessionUUID string) (bool, error) {
+	sqlStatement := "DELETE FROM sessions WHERE uuid=?"
+	res, err := r.dao.Conn.Exec(sqlStatement, sessionUUID)
+	if err != nil {
+		return false, domain_errors.Internal(err)
+	}
+
+	deleted, err := res.RowsAffected()
+	if err != nil {
+		return false, domain_errors.Internal(err)
+	}
+	return deleted > 0, nil
+}
+
+func (r *SQLiteStore) RevokeSessionWithUUID(sessionUUID string, reason string) ([]string, error) {
+	tx, err := r.dao.Conn.Begin()
+	if err != nil {
+		return nil, domain_errors.Internal(err)
+	}
+
+	session, err := r.GetSessionWithUUIDUsingTx(tx, sessionUUID)
+	if err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+
+	sqlStatement := "SELECT id,uuid FROM sessions WHERE id=? AND NOT EXISTS (SELECT 1 FROM session_revokes WHERE session_id=sessions.id)"
+	return r.revokeSessions(tx, reason, sqlStatement, session.id)
+}
+
+func (r *SQLiteStore) RevokeSessionsForUser(userUUID string, reason string) ([]string, error) {
+	tx, err := r.dao.Conn.Begin()
+	if err != nil {
+		return nil, domain_errors.Internal(err)
+	}
+
+	user, err := r.GetUserWithUUIDUsingTx(tx, userUUID)
+	if err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+
+	sqlStatement := "SELECT id,uuid FROM sessions WHERE user_id=? AND NOT EXISTS (SELECT 1 FROM session_revokes WHERE session_id=sessions.id) ORDER BY id"
+	return r.revokeSessions(tx, reason, sqlStatement, user.id)
+}
+
+// revokeSessions writes a revoke for every session the query selects, then commits tx
+func (r *SQLiteStore) revokeSessions(tx *sql.Tx, reason string, selectStatement string, args ...interface{}) ([]string, error) {
+	uuids, err := r.insertSessionRevokes(tx, reason, selectStatement, args...)
+	if err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		return nil, domain_errors.Internal(err)
+	}
+	return uuids, nil
+}
+
+// insertSessionRevokes writes a revoke for every session the query selects, leaving tx open
+func (r *SQLiteStore) insertSessionRevokes(tx *sql.Tx, reason string, selectStatement string, args ...interface{}) ([]string, error) {
+	rows, err := tx.Query(selectStatement, args...)
+	if err != nil {
+		return nil, domain_errors.Internal(err)
+	}
+
+	var ids []int
+	var uuids []string
+	for rows.Next() {
+		var id int
+		var sessionUUID string
+		err = rows.Scan(&id, &sessionUUID)
+		if err != nil {
+			rows.Close()
+			return nil, domain_errors.Internal(err)
+		}
+		ids = append(ids, id)
+		uuids = append(uuids, sessionUUID)
+	}
+	rows.Close()
+
+	sqlStatement := "INSERT INTO session_revokes (uuid, session_id, reason, created_at) VALUES (?, ?, ?, ?)"
+	for _, id := range ids {
+		_, err = tx.Exec(sqlStatement, uuid.New().String(), id, reason, time.Now().UTC())
+		if err != nil {
+			return nil, domain_errors.Internal(err)
+		}
+	}
+	return uuids, nil
+}
+
+func (r *SQLiteStore) GetSessionRevoke(sessionID int) (*SessionRevoke, error) {
+	sqlStatement := "SELECT id,uuid,session_id,reason,created_at FROM session_revokes WHERE session_id=?"
+
+	return scanSessionRevoke(r.dao.Conn.QueryRow(sqlStatement, sessionID))
+}
+
+// GetSessionRevokeUsingTx needs no lock, tx already holds the database's write lock
+func (r *SQLiteStore) GetSessionRevokeUsingTx(tx Tx, sessionID int) (*SessionRevoke, error) {
+	sqlStatement := "SELECT id,uuid,session_id,reason,created_at FROM session_revokes WHERE session_id=?"
+
+	return scanSessionRevoke(sqlTx(tx).QueryRow(sqlStatement, sessionID))
+}
+
+func (r *SQLiteStore) CreateRefreshToken(tx Tx, sessionID int, familyUUID string, tokenHash string) error {
+	sqlStatement := "INSERT INTO refresh_tokens (uuid, session_id, family_uuid, token_hash, created_at) VALUES (?, ?, ?, ?, ?)"
+	_, err := sqlTx(tx).Exec(sqlStatement, uuid.New().String(), sessionID, familyUUID, tokenHash, time.Now().UTC())
+	if err != nil {
+		return domain_errors.Internal(err)
+	}
+	return nil
+}
+
+// GetRefreshTokenWithHashForUpdate doesn't need a row lock, tx already holds the database's write lock
+func (r *SQLiteStore) GetRefreshTokenWithHashForUpdate(tx Tx, tokenHash string) (*RefreshToken, error) {
+	sqlStatement := "SELECT id,uuid,session_id,family_uuid,used_at,revoked_at FROM refresh_tokens WHERE token_hash=?"
+
+	row := sqlTx(tx).QueryRow(sqlStatement, tokenHash)
+	var rt RefreshToken
+	err := row.Scan(&rt.id, &rt.uuid, &rt.sessionId, &rt.familyUUID, &rt.usedAt, &rt.revokedAt)
+	if err == sql.ErrNoRows {
+		return nil, domain_errors.InvalidToken(domain_errors.ReasonInvalidRefreshToken, "refresh token not recognized")
+	}
+	if err != nil {
+		return nil, domain_errors.Internal(err)
+	}
+
+	return &rt, nil
+}
+
+func (r *SQLiteStore) MarkRefreshTokenUsed(tx Tx, refreshTokenID int) error {
+	sqlStatement := "UPDATE refresh_tokens SET used_at=? WHERE id=?"
+	res, err := sqlTx(tx).Exec(sqlStatement, time.Now().UTC(), refreshTokenID)
+	if err != nil {
+		return domain_errors.Internal(err)
+	}
+
+	return requireAffected(res, domain_errors.InvalidToken(domain_errors.ReasonInvalidRefreshToken, "refresh token not recognized"))
+}
+
+func (r *SQLiteStore) RevokeRefreshTokenFamily(tx Tx, refreshToken *RefreshToken, reason string) error {
+	sqlStatement := "UPDATE refresh_tokens SET revoked_at=? WHERE family_uuid=? AND revoked_at IS NULL"
+	_, err := sqlTx(tx).Exec(sqlStatement, time.Now().UTC(), refreshToken.familyUUID)
+	if err != nil {
+		return domain_errors.Internal(err)
+	}
+
+	sqlStatement = "SELECT id,uuid FROM sessions WHERE id=? AND NOT EXISTS (SELECT 1 FROM session_revokes WHERE session_id=sessions.id)"
+	_, err = r.insertSessionRevokes(sqlTx(tx), reason, sqlStatement, refreshToken.sessionId)
+	return err
+}
+
+func (r *SQLiteStore) CreateCaller(name string, apiKeyHash string, allowedMethods []string, allowedScopes []string) (*Caller, error) {
+	callerUUID := uuid.New().String()
+
+	sqlStatement := "INSERT INTO callers (uuid, name, api_key_hash, allowed_methods, allowed_scopes, created_at) VALUES (?, ?, ?, ?, ?, ?)"
+	_, err := r.dao.Conn.Exec(sqlStatement, callerUUID, name, apiKeyHash, stringList(allowedMethods), stringList(allowedScopes), time.Now().UTC())
+	if isSQLiteUniqueViolation(err) {
+		return nil, domain_errors.AlreadyExists(domain_errors.ReasonCallerNameTaken, "a caller with that name already exists")
+	}
+	if err != nil {
+		return nil, domain_errors.Internal(err)
+	}
+
+	return r.GetCallerWithAPIKeyHash(apiKeyHash)
+}
+
+func (r *SQLiteStore) GetCallerWithAPIKeyHash(apiKeyHash string) (*Caller, error) {
+	sqlStatement := "SELECT id,uuid,name,allowed_methods,allowed_scopes,revoked_at FROM callers WHERE api_key_hash=? AND revoked_at IS NULL"
+
+	row := r.dao.Conn.QueryRow(sqlStatement, apiKeyHash)
+	var c Caller
+	err := row.Scan(&c.id, &c.uuid, &c.name, (*stringList)(&c.allowedMethods), (*stringList)(&c.allowedScopes), &c.revokedAt)
+	if err == sql.ErrNoRows {
+		return nil, domain_errors.InvalidToken(domain_errors.ReasonInvalidAPIKey, "api key not recognized")
+	}
+	if err != nil {
+		return nil, domain_errors.Internal(err)
+	}
+
+	return &c, nil
+}
+
+func (r *SQLiteStore) RevokeCaller(name string) error {
+	sqlStatement := "UPDATE callers SET revoked_at=? WHERE name=? AND revoked_at IS NULL"
+	res, err := r.dao.Conn.Exec(sqlStatement, time.Now().UTC(), name)
+	if err != nil {
+		return domain_errors.Internal(err)
+	}
+
+	return requireAffected(res, domain_errors.NotFound(domain_errors.ReasonCallerNotFound, "caller not found"))
+}
+
+func (r *SQLiteStore) DeleteExpiredSessions(before time.Time, limit int, dryRun bool) (int, error) {
+	return r.cleanRows("sessions", "expiration < ?1", limit, dryRun, before)
+}
+
+func (r *SQLiteStore) DeleteExpiredScopeGroupings(before time.Time, limit int, dryRun bool) (int, error) {
+	return r.cleanRows("scope_groupings", "expiration < ?1", limit, dryRun, before)
+}
+
+func (r *SQLiteStore) DeleteStaleGuests(createdBefore time.Time, limit int, dryRun bool) (int, error) {
+	where := "is_guest AND created_at < ?1 AND NOT EXISTS (SELECT 1 FROM sessions WHERE sessions.user_id=users.id AND sessions.expiration >= ?2)"
+	return r.cleanRows("users", where, limit, dryRun, createdBefore, time.Now().UTC())
+}
+
+func (r *SQLiteStore) ExpireResetTokens(requestedBefore time.Time, limit int, dryRun bool) (int, error) {
+	where := "password_reset_requested_at < ?1"
+	if dryRun {
+		return r.countRows("users", where, requestedBefore)
+	}
+
+	tx, err := r.dao.Conn.Begin()
+	if err != nil {
+		return 0, domain_errors.Internal(err)
+	}
+	rows, err := tx.Query("SELECT id FROM users WHERE "+where+" ORDER BY id LIMIT ?2", requestedBefore, limit)
+	if err != nil {
+		tx.Rollback()
+		return 0, domain_errors.Internal(err)
+	}
+	var ids []int
+	for rows.Next() {
+		var id int
+		err = rows.Scan(&id)
+		if err != nil {
+			rows.Close()
+			tx.Rollback()
+			return 0, domain_errors.Internal(err)
+		}
+		ids = append(ids, id)
+	}
+	rows.Close()
+
+	sqlStatement := "UPDATE users SET password_reset_token=?,password_reset_requested_at=NULL WHERE id=?"
+	for _, id := range ids {
+		_, err = tx.Exec(sqlStatement, randstr.String(16), id)
+		if err != nil {
+			tx.Rollback()
+			return 0, domain_errors.Internal(err)
+		}
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		return 0, domain_errors.Internal(err)
+	}
+	return len(ids), nil
+}
+
+func (r *SQLiteStore) DeleteRevokedSessions(revokedBefore time.Time, limit int, dryRun bool) (int, error) {
+	return r.cleanRows("sessions", "id IN (SELECT session_id FROM session_revokes WHERE created_at < ?1)", limit, dryRun, revokedBefore)
+}
+
+func (r *SQLiteStore) DeleteRetiredRefreshTokens(retiredBefore time.Time, limit int, dryRun bool) (int, error) {
+	return r.cleanRows("refresh_tokens", "(used_at < ?1 OR revoked_at < ?1)", limit, dryRun, retiredBefore)
+}
+
+func (r *SQLiteStore) DeleteRevokedCallers(revokedBefore time.Time, limit int, dryRun bool) (int, error) {
+	return r.cleanRows("callers", "revoked_at < ?1", limit, dryRun, revokedBefore)
+}
+
+func (r *SQLiteStore) DeleteOldJobRuns(startedBefore time.Time, limit int, dryRun bool) (int, error) {
+	return r.cleanRows("job_runs", "started_at < ?1", limit, dryRun, startedBefore)
+}
+
+// cleanRows works like PostgresStore.cleanRows, where uses numbered placeholders so an argument can repeat
+func (r *SQLiteStore) cleanRows(table string, where string, limit int, dryRun bool, args ...interface{}) (int, error) {
+	if dryRun {
+		return r.countRows(table, where, args...)
+	}
+
+	sqlStatement := fmt.Sprintf("DELETE FROM %s WHERE id IN (SELECT id FROM %s WHERE %s ORDER BY id LIMIT ?%d)", table, table, where, len(args)+1)
+	res, err := r.dao.Conn.Exec(sqlStatement, append(args, limit)...)
+	if err != nil {
+		return 0, domain_errors.Internal(err)
+	}
+	deleted, err := res.RowsAffected()
+	if err != nil {
+		return 0, domain_errors.Internal(err)
+	}
+	return int(deleted), nil
+}
+
+// LockJob only keeps jobs apart within this process, two processes sharing the file are still kept from
+// running the same tick by the unique scheduled_at
+func (r *SQLiteStore) LockJob(name string) (func(), bool, error) {
+	return r.jobs.lock(name)
+}
+
+func (r *SQLiteStore) CreateJobRun(name string, triggeredBy string, runner string, scheduledAt time.Time) (*JobRun, error) {
+	run := newJobRun(name, triggeredBy, runner, scheduledAt)
+
+	sqlStatement := "INSERT INTO job_runs (uuid, job_name, triggered_by, runner, scheduled_at, started_at) VALUES (?, ?, ?, ?, ?, ?)"
+	res, err := r.dao.Conn.Exec(sqlStatement, run.uuid, run.jobName, run.triggeredBy, run.runner, run.scheduledAt, run.startedAt)
+	if isSQLiteUniqueViolation(err) {
+		return nil, domain_errors.AlreadyExists(domain_errors.ReasonJobRunExists, "the job has already run for that tick")
+	}
+	if err != nil {
+		return nil, domain_errors.Internal(err)
+	}
+	id, err := res.LastInsertId()
+	if err != nil {
+		return nil, domain_errors.Internal(err)
+	}
+	run.id = int(id)
+
+	return run, nil
+}
+
+func (r *SQLiteStore) FinishJobRun(run *JobRun, runErr error) error {
+	run.finish(runErr)
+	_, err := r.dao.Conn.Exec("UPDATE job_runs SET finished_at=?,error=? WHERE id=?", run.finishedAt, run.runError, run.id)
+	if err != nil {
+		return domain_errors.Internal(err)
+	}
+	return nil
+}
+
+func (r *SQLiteStore) GetJobRuns(name string, limit int) ([]*JobRun, error) {
+	sqlStatement := "SELECT id,uuid,job_name,triggered_by,runner,scheduled_at,started_at,finished_at,error FROM job_runs WHERE job_name=? ORDER BY started_at DESC, id DESC LIMIT ?"
+	rows, err := r.dao.Conn.Query(sqlStatement, name, limit)
+	if err != nil {
+		return nil, domain_errors.Internal(err)
+	}
+	return scanJobRuns(rows)
+}
+
+func (r *SQLiteStore) countRows(table string, where string, args ...interface{}) (int, error) {
+	var count int
+	err := r.dao.Conn.QueryRow("SELECT COUNT(*) FROM "+table+" WHERE "+where, args...).Scan(&count)
+	if err != nil {
+		return 0, domain_errors.Internal(err)
+	}
+	return count, nil
+}
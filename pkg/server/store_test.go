@@ -0,0 +1,184 @@
+package server
+
+import (
+	"github.com/brianvoe/gofakeit"
+	"github.com/google/uuid"
+	"github.com/willschroeder/fingerprint/pkg/config"
+	"github.com/willschroeder/fingerprint/pkg/db"
+	"github.com/willschroeder/fingerprint/pkg/domain_errors"
+	"github.com/willschroeder/fingerprint/pkg/passwords"
+	"github.com/willschroeder/fingerprint/pkg/session_representations"
+	"golang.org/x/crypto/bcrypt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+var testStore Store
+var testServer *GRPCServer
+
+// TestMain runs against the in-memory store. FINGERPRINT_TEST_STORE=postgres runs the same tests against
+// the database from the default config, FINGERPRINT_TEST_STORE=sqlite against a migrated throwaway file.
+func TestMain(m *testing.M) {
+	gofakeit.Seed(0)
+	store, closeStore := openTestStore(os.Getenv("FINGERPRINT_TEST_STORE"))
+	testStore = store
+	testServer = NewGRPCServer(testStore,passwords.DefaultPolicy(),session_representations.DevelopmentKeyring(),session_representations.NewSessionValidator(session_representations.DefaultClockSkew),SessionOptions{})
+	code := m.Run()
+	closeStore()
+	os.Exit(code)
+}
+
+func openTestStore(kind string) (Store, func()) {
+	switch kind {
+	case config.DriverPostgres:
+		dao := db.ConnectToDatabase(config.Default().Database)
+		return NewStore(dao), func() { dao.Conn.Close() }
+	case config.DriverSQLite:
+		dir, err := ioutil.TempDir("", "fingerprint")
+		if err != nil {
+			panic(err)
+		}
+		dao := db.ConnectToDatabase(config.Database{Driver: config.DriverSQLite, Path: filepath.Join(dir, "test.db")})
+		_, err = dao.MigrateUp()
+		if err != nil {
+			panic(err)
+		}
+		return NewStore(dao), func() {
+			dao.Conn.Close()
+			os.RemoveAll(dir)
+		}
+	}
+	return NewMemoryStore(), func() {}
+}
+
+func createEncryptedPassword() string {
+	pw := gofakeit.Password(true, true, true, true, true, 32)
+	hash, _ := bcrypt.GenerateFromPassword([]byte(pw), bcrypt.DefaultCost)
+	return string(hash)
+}
+
+func createTestUser(isGuest bool) *User {
+	tx, _ := testStore.Begin()
+	user, _ := testStore.CreateUser(tx, gofakeit.Email(), createEncryptedPassword(), isGuest)
+	tx.Commit()
+	return user
+}
+
+func TestRepoCreateUser(t *testing.T) {
+	email := gofakeit.Email()
+	tx, _ := testStore.Begin()
+	user, err := testStore.CreateUser(tx, email, createEncryptedPassword(), false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tx.Commit()
+	if user.email != email {
+		t.Errorf("User not created with test email")
+	}
+}
+
+func TestRepoGetUser(t *testing.T) {
+	testUser := createTestUser(false)
+	tx, _ := testStore.Begin()
+	gotUser, err := testStore.GetUserWithUUIDUsingTx(tx, testUser.uuid)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tx.Commit()
+	if gotUser == nil || gotUser.email == "" {
+		t.Errorf("Not able to get test user")
+	}
+}
+func TestRepoScopeGroupings(t *testing.T) {
+	user := createTestUser(false)
+	tx, _ := testStore.Begin()
+	session, err := testStore.CreateSession(tx, uuid.New(), user.id, gofakeit.UUID(), time.Now().Add(time.Hour), 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = testStore.CreateScopeGrouping(tx, session.id, []string{"read", "write"}, time.Now().Add(time.Hour))
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = testStore.CreateScopeGrouping(tx, session.id, []string{}, time.Now().Add(time.Minute))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	groupings, err := testStore.GetScopeGroupingsForSession(tx, session.id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tx.Commit()
+	if len(groupings) != 2 {
+		t.Fatalf("Expected 2 scope groupings, got %d", len(groupings))
+	}
+	if len(groupings[0].scopes) != 2 || groupings[0].scopes[1] != "write" || len(groupings[1].scopes) != 0 {
+		t.Errorf("Scope groupings didn't round trip, got %+v %+v", groupings[0], groupings[1])
+	}
+}
+
+func TestRepoCallers(t *testing.T) {
+	name := gofakeit.Username() + gofakeit.UUID()
+	apiKey, err := CreateCaller(testStore, name, []string{"CreateSession"}, []string{"read"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	caller, err := testStore.GetCallerWithAPIKeyHash(hashAPIKey(apiKey))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if caller.name != name || !caller.mayCall("CreateSession") || caller.mayCall("GetUser") {
+		t.Errorf("Unexpected caller %+v", caller)
+	}
+
+	_, err = CreateCaller(testStore, name, nil, nil)
+	if !domain_errors.Is(err, domain_errors.KindAlreadyExists) {
+		t.Errorf("Expected caller names to be unique, got %v", err)
+	}
+
+	err = testStore.RevokeCaller(name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = testStore.GetCallerWithAPIKeyHash(hashAPIKey(apiKey))
+	if !domain_errors.Is(err, domain_errors.KindInvalidToken) {
+		t.Errorf("Expected revoked callers to be refused, got %v", err)
+	}
+}
+
+func TestMemoryStoreForgetsRowLocks(t *testing.T) {
+	store := NewMemoryStore()
+	first, _ := store.Begin()
+	store.GetRefreshTokenWithHashForUpdate(first, "hash")
+
+	locked := make(chan struct{})
+	go func() {
+		second, _ := store.Begin()
+		store.GetRefreshTokenWithHashForUpdate(second, "hash")
+		close(locked)
+		second.Rollback()
+	}()
+	// Let the second transaction start waiting on the lock
+	time.Sleep(10 * time.Millisecond)
+	first.Commit()
+	<-locked
+
+	deadline := time.Now().Add(time.Second)
+	for {
+		store.mu.Lock()
+		remaining := len(store.rowLocks)
+		store.mu.Unlock()
+		if remaining == 0 {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("Expected row locks to be forgotten once no transaction holds them, %d left", remaining)
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
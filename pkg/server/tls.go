@@ -0,0 +1,162 @@
+package server
+
+import (
+	"context"
+	"crypto/subtle"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"github.com/willschroeder/fingerprint/pkg/certificates"
+	"github.com/willschroeder/fingerprint/pkg/domain_errors"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"net/http"
+	"strings"
+)
+
+// The REST gateway reaches the gRPC server over plaintext loopback, so it forwards the subjects of the HTTP
+// client's verified certificate in metadata along with a per process secret that vouches for them
+const (
+	gatewayMetadataPrefix  = "x-fingerprint-gateway-"
+	gatewaySecretMetadata  = gatewayMetadataPrefix + "secret"
+	gatewaySubjectMetadata = gatewayMetadataPrefix + "subject-bin"
+)
+
+// TLSOptions configure the gRPC and HTTP listeners, without a certificate they serve plaintext
+type TLSOptions struct {
+	CertFile string
+	KeyFile  string
+	// ClientCAFile verifies client certificates, clients without one are still accepted unless RequireClientCert is set
+	ClientCAFile      string
+	RequireClientCert bool
+	// AdminSubjects are the client certificate subjects allowed to call admin RPCs, matched against the
+	// common name, the full distinguished name or a URI SAN. Admin RPCs are open to everyone when it's empty.
+	AdminSubjects []string
+}
+
+// adminMethods can read or end any customer's account or sessions, or run maintenance jobs, so they are
+// kept to internal services
+var adminMethods = map[string]bool{
+	"/proto.FingerprintService/GetUser":                  true,
+	"/proto.FingerprintService/CreatePasswordResetToken": true,
+	"/proto.FingerprintService/RevokeSessions":           true,
+	"/proto.FingerprintService/DeleteSession":            true,
+	"/proto.FingerprintService/ListJobs":                 true,
+	"/proto.FingerprintService/RunJob":                   true,
+}
+
+// serverConfig builds the TLS config the gRPC and HTTP listeners share, nil when TLS isn't configured.
+// Certificates are read again from disk whenever the files change.
+func (o TLSOptions) serverConfig() (*tls.Config, error) {
+	if o.CertFile == "" {
+		if o.ClientCAFile != "" || len(o.AdminSubjects) > 0 {
+			return nil, errors.New("client certificates need tls_cert and tls_key")
+		}
+		return nil, nil
+	}
+	if len(o.AdminSubjects) > 0 && o.ClientCAFile == "" {
+		return nil, errors.New("tls_admin_subjects need a tls_client_ca to verify them against")
+	}
+
+	reloader, err := certificates.NewReloader(o.CertFile, o.KeyFile, o.ClientCAFile)
+	if err != nil {
+		return nil, err
+	}
+	return reloader.ServerConfig(o.RequireClientCert), nil
+}
+
+// adminInterceptor refuses admin RPCs from callers without a verified client certificate on the allowlist.
+// Subjects forwarded by the REST gateway only count with gatewaySecret, leave it empty on servers the
+// gateway doesn't call.
+func adminInterceptor(subjects []string, gatewaySecret string) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		if len(subjects) > 0 && adminMethods[info.FullMethod] && !callerAllowed(ctx, subjects) && !gatewayCallerAllowed(ctx, subjects, gatewaySecret) {
+			return nil, domain_errors.PermissionDenied(domain_errors.ReasonCallerNotAllowed, "caller isn't allowed to call "+info.FullMethod)
+		}
+		return handler(ctx, req)
+	}
+}
+
+// callerAllowed only trusts certificates that verified against the client CA, a certificate the
+// client merely sent doesn't count
+func callerAllowed(ctx context.Context, subjects []string) bool {
+	p, ok := peer.FromContext(ctx)
+	if !ok {
+		return false
+	}
+	info, ok := p.AuthInfo.(credentials.TLSInfo)
+	if !ok {
+		return false
+	}
+	return verifiedSubjectAllowed(info.State, subjects)
+}
+
+// gatewayCallerAllowed checks the subjects the REST gateway forwarded, as long as they came with its secret
+func gatewayCallerAllowed(ctx context.Context, subjects []string, gatewaySecret string) bool {
+	md, ok := metadata.FromIncomingContext(ctx)
+	if !ok || gatewaySecret == "" {
+		return false
+	}
+	secrets := md.Get(gatewaySecretMetadata)
+	if len(secrets) != 1 || subtle.ConstantTimeCompare([]byte(secrets[0]), []byte(gatewaySecret)) != 1 {
+		return false
+	}
+	return anyAllowed(md.Get(gatewaySubjectMetadata), subjects)
+}
+
+// gatewayMetadata forwards the subjects of the HTTP client's verified certificate to the gRPC server
+func gatewayMetadata(gatewaySecret string) func(context.Context, *http.Request) metadata.MD {
+	return func(_ context.Context, r *http.Request) metadata.MD {
+		if gatewaySecret == "" || r.TLS == nil || len(r.TLS.VerifiedChains) == 0 {
+			return nil
+		}
+		md := metadata.Pairs(gatewaySecretMetadata, gatewaySecret)
+		for _, chain := range r.TLS.VerifiedChains {
+			if len(chain) > 0 {
+				md.Append(gatewaySubjectMetadata, certificateSubjects(chain[0])...)
+			}
+		}
+		return md
+	}
+}
+
+// isGatewayMetadata spots clients trying to forward subjects themselves
+func isGatewayMetadata(key string) bool {
+	return strings.HasPrefix(strings.ToLower(key), gatewayMetadataPrefix)
+}
+
+func verifiedSubjectAllowed(state tls.ConnectionState, subjects []string) bool {
+	for _, chain := range state.VerifiedChains {
+		if len(chain) > 0 && subjectAllowed(chain[0], subjects) {
+			return true
+		}
+	}
+	return false
+}
+
+func subjectAllowed(leaf *x509.Certificate, subjects []string) bool {
+	return anyAllowed(certificateSubjects(leaf), subjects)
+}
+
+// certificateSubjects are the names a certificate can be allowed by, its common name, full distinguished
+// name and URI SANs
+func certificateSubjects(leaf *x509.Certificate) []string {
+	names := []string{leaf.Subject.CommonName, leaf.Subject.String()}
+	for _, uri := range leaf.URIs {
+		names = append(names, uri.String())
+	}
+	return names
+}
+
+func anyAllowed(names []string, subjects []string) bool {
+	for _, subject := range subjects {
+		for _, name := range names {
+			if subject == name {
+				return true
+			}
+		}
+	}
+	return false
+}
@@ -0,0 +1,149 @@
+package server
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"github.com/willschroeder/fingerprint/pkg/domain_errors"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+)
+
+func clientCertificate(commonName string, uri string) *x509.Certificate {
+	leaf := &x509.Certificate{Subject: pkix.Name{CommonName: commonName, Organization: []string{"Fingerprint"}}}
+	if uri != "" {
+		parsed, _ := url.Parse(uri)
+		leaf.URIs = []*url.URL{parsed}
+	}
+	return leaf
+}
+
+func peerContext(state tls.ConnectionState) context.Context {
+	return peer.NewContext(context.Background(), &peer.Peer{AuthInfo: credentials.TLSInfo{State: state}})
+}
+
+func TestSubjectAllowed(t *testing.T) {
+	leaf := clientCertificate("billing", "spiffe://example.com/billing")
+	for _, subject := range []string{"billing", "CN=billing,O=Fingerprint", "spiffe://example.com/billing"} {
+		if !subjectAllowed(leaf, []string{subject}) {
+			t.Errorf("Expected %s to match", subject)
+		}
+	}
+	if subjectAllowed(leaf, []string{"storefront", "spiffe://example.com/storefront"}) {
+		t.Errorf("Expected other subjects to be refused")
+	}
+}
+
+func TestAdminInterceptor(t *testing.T) {
+	interceptor := adminInterceptor([]string{"billing"}, "")
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) { return "ok", nil }
+	admin := &grpc.UnaryServerInfo{FullMethod: "/proto.FingerprintService/RevokeSessions"}
+
+	verified := tls.ConnectionState{VerifiedChains: [][]*x509.Certificate{{clientCertificate("billing", "")}}}
+	_, err := interceptor(peerContext(verified), nil, admin, handler)
+	if err != nil {
+		t.Errorf("Expected an allowed verified caller through, got %v", err)
+	}
+
+	unverified := tls.ConnectionState{PeerCertificates: []*x509.Certificate{clientCertificate("billing", "")}}
+	_, err = interceptor(peerContext(unverified), nil, admin, handler)
+	if !domain_errors.Is(err, domain_errors.KindPermissionDenied) {
+		t.Errorf("Expected an unverified certificate to be refused, got %v", err)
+	}
+
+	_, err = interceptor(context.Background(), nil, admin, handler)
+	if !domain_errors.Is(err, domain_errors.KindPermissionDenied) {
+		t.Errorf("Expected callers without a certificate to be refused, got %v", err)
+	}
+
+	_, err = interceptor(context.Background(), nil, &grpc.UnaryServerInfo{FullMethod: "/proto.FingerprintService/GetSession"}, handler)
+	if err != nil {
+		t.Errorf("Expected other RPCs to stay open, got %v", err)
+	}
+
+	_, err = adminInterceptor(nil, "")(context.Background(), nil, admin, handler)
+	if err != nil {
+		t.Errorf("Expected admin RPCs to stay open without an allowlist, got %v", err)
+	}
+}
+
+func TestAdminInterceptorTrustsTheGateway(t *testing.T) {
+	interceptor := adminInterceptor([]string{"billing"}, "gateway-secret")
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) { return "ok", nil }
+	admin := &grpc.UnaryServerInfo{FullMethod: "/proto.FingerprintService/RevokeSessions"}
+
+	forwarded := func(pairs ...string) context.Context {
+		return metadata.NewIncomingContext(context.Background(), metadata.Pairs(pairs...))
+	}
+	_, err := interceptor(forwarded(gatewaySecretMetadata, "gateway-secret", gatewaySubjectMetadata, "billing"), nil, admin, handler)
+	if err != nil {
+		t.Errorf("Expected an allowed subject forwarded by the gateway through, got %v", err)
+	}
+
+	for name, ctx := range map[string]context.Context{
+		"other subject":  forwarded(gatewaySecretMetadata, "gateway-secret", gatewaySubjectMetadata, "storefront"),
+		"wrong secret":   forwarded(gatewaySecretMetadata, "guess", gatewaySubjectMetadata, "billing"),
+		"missing secret": forwarded(gatewaySubjectMetadata, "billing"),
+	} {
+		_, err = interceptor(ctx, nil, admin, handler)
+		if !domain_errors.Is(err, domain_errors.KindPermissionDenied) {
+			t.Errorf("Expected %s to be refused, got %v", name, err)
+		}
+	}
+
+	_, err = adminInterceptor([]string{"billing"}, "")(forwarded(gatewaySecretMetadata, "", gatewaySubjectMetadata, "billing"), nil, admin, handler)
+	if !domain_errors.Is(err, domain_errors.KindPermissionDenied) {
+		t.Errorf("Expected servers without a gateway secret to ignore forwarded subjects, got %v", err)
+	}
+}
+
+func TestGatewayForwardsClientCertificate(t *testing.T) {
+	options := HTTPOptions{GatewaySecret: "gateway-secret"}
+	gatewayServer := NewServiceServer(testServer, grpc.UnaryInterceptor(adminInterceptor([]string{"billing"}, options.GatewaySecret)))
+	handler, err := NewHTTPHandler(testServer, gatewayServer, options)
+	if err != nil {
+		t.Fatal(err)
+	}
+	listJobs := func(leaf *x509.Certificate, header string) int {
+		req := httptest.NewRequest(http.MethodGet, "/v1/jobs", nil)
+		if leaf != nil {
+			req.TLS = &tls.ConnectionState{VerifiedChains: [][]*x509.Certificate{{leaf}}}
+		}
+		if header != "" {
+			req.Header.Set("Grpc-Metadata-"+gatewaySubjectMetadata, header)
+		}
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		return rec.Code
+	}
+
+	if code := listJobs(clientCertificate("billing", ""), ""); code != http.StatusOK {
+		t.Errorf("Expected an allowed client certificate to reach admin RPCs over REST, got %d", code)
+	}
+	if code := listJobs(nil, ""); code != http.StatusForbidden {
+		t.Errorf("Expected callers without a certificate to be refused, got %d", code)
+	}
+	// The subject header would be base64 decoded into the subject the gateway forwards
+	if code := listJobs(clientCertificate("storefront", ""), "YmlsbGluZw"); code != http.StatusForbidden {
+		t.Errorf("Expected clients to be unable to forward subjects themselves, got %d", code)
+	}
+}
+
+func TestTLSOptionsNeedAClientCAForSubjects(t *testing.T) {
+	_, err := TLSOptions{CertFile: "cert.pem", KeyFile: "key.pem", AdminSubjects: []string{"billing"}}.serverConfig()
+	if err == nil {
+		t.Errorf("Expected admin subjects without a client CA to be refused")
+	}
+
+	tlsConfig, err := TLSOptions{}.serverConfig()
+	if tlsConfig != nil || err != nil {
+		t.Errorf("Expected plaintext without a certificate, got %v %v", tlsConfig, err)
+	}
+}
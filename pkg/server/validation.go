@@ -0,0 +1,195 @@
+package server
+
+import (
+	"github.com/golang/protobuf/ptypes"
+	"github.com/willschroeder/fingerprint/pkg/domain_errors"
+	"github.com/willschroeder/fingerprint/pkg/proto"
+	"github.com/willschroeder/fingerprint/pkg/session_representations"
+	"time"
+)
+
+// sessionDeletedReason is reported for tokens that decode fine but whose session row is gone
+const sessionDeletedReason = "session deleted"
+
+// sessionTokenReplacedReason is reported for a token that was swapped for a new one, like after an elevation
+const sessionTokenReplacedReason = "session token replaced"
+
+// sessionState is everything known about a presented token once it has been validated
+type sessionState struct {
+	token string
+	json string
+	// session is nil when the row has been deleted
+	session *Session
+	revoked bool
+	revokedReason string
+	revokedAt time.Time
+	// idleExpiration is zero for sessions without an idle timeout
+	idleExpiration time.Time
+	validated *session_representations.ValidatedSession
+}
+
+// loadSession decodes the token, looks up its session and revocation, and checks expiration.
+// Every endpoint that validates a session goes through here so they all agree with GetSession.
+func (s *GRPCServer) loadSession(token string) (*sessionState, error) {
+	json, err := session_representations.DecodeTokenToJson(s.builder.keyring, token)
+	if err != nil {
+		return nil, domain_errors.InvalidToken(domain_errors.ReasonInvalidToken, "session token could not be decoded")
+	}
+
+	decoded, err := session_representations.DecodeSessionJson(json)
+	if err != nil {
+		return nil, domain_errors.InvalidToken(domain_errors.ReasonInvalidToken, "session token has no scope groupings")
+	}
+
+	validated, err := s.validator.Validate(decoded)
+	if err == session_representations.ErrSessionExpired {
+		return nil, domain_errors.ExpiredToken("session has expired")
+	}
+	if err == session_representations.ErrTokenExpired {
+		return nil, domain_errors.ExpiredToken("token has expired, refresh the session")
+	}
+	if err != nil {
+		return nil, domain_errors.Internal(err)
+	}
+
+	state := &sessionState{token: token, json: json, validated: validated}
+
+	session, err := s.store.GetSessionWithToken(token)
+	if domain_errors.Is(err, domain_errors.KindNotFound) {
+		state.revoked = true
+		state.revokedReason = sessionDeletedReason
+
+		_, err = s.store.GetSessionWithUUID(decoded.SessionUUID)
+		if err == nil {
+			state.revokedReason = sessionTokenReplacedReason
+		} else if !domain_errors.Is(err, domain_errors.KindNotFound) {
+			return nil, err
+		}
+		return state, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	state.session = session
+
+	err = s.validator.ValidateExpiration(session.expiration)
+	if err == session_representations.ErrSessionExpired {
+		return nil, domain_errors.ExpiredToken("session has expired")
+	}
+	revoke, err := s.store.GetSessionRevoke(session.id)
+	if domain_errors.Is(err, domain_errors.KindNotFound) {
+		// Only live sessions count as activity
+		err = s.activity.seen(session)
+		if err != nil {
+			return nil, err
+		}
+		state.idleExpiration = s.activity.idleExpiration(session)
+		return state, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	state.revoked = true
+	state.revokedReason = revoke.reason
+	state.revokedAt = revoke.createdAt
+	state.idleExpiration = s.activity.idleExpiration(session)
+	return state, nil
+}
+
+func (state *sessionState) ConvertToProtobuff() (*proto.GetSessionResponse, error) {
+	response := &proto.GetSessionResponse{
+		Session: &proto.Session{Token:state.token, Json:state.json},
+		Revoked: state.revoked,
+		RevokedReason: state.revokedReason,
+		ActiveScopes: state.validated.ActiveScopes(),
+	}
+	if state.session != nil {
+		response.Session.Uuid = state.session.uuid
+	}
+
+	var err error
+	if !state.revokedAt.IsZero() {
+		response.RevokedAt, err = ptypes.TimestampProto(state.revokedAt)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if !state.idleExpiration.IsZero() {
+		response.IdleExpiration, err = ptypes.TimestampProto(state.idleExpiration)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	response.Expiration, err = ptypes.TimestampProto(state.validated.Expiration)
+	if err != nil {
+		return nil, err
+	}
+
+	response.ActiveScopeGroupings, err = convertScopeGroupingStatusesToProtobuff(state.validated.ActiveGroupings)
+	if err != nil {
+		return nil, err
+	}
+	response.ExpiredScopeGroupings, err = convertScopeGroupingStatusesToProtobuff(state.validated.ExpiredGroupings)
+	if err != nil {
+		return nil, err
+	}
+
+	return response, nil
+}
+
+func convertScopeGroupingStatusesToProtobuff(statuses []*session_representations.ScopeGroupingStatus) ([]*proto.ScopeGrouping, error) {
+	groupings := make([]*proto.ScopeGrouping, len(statuses))
+	for i, sg := range statuses {
+		exp, err := ptypes.TimestampProto(sg.Expiration)
+		if err != nil {
+			return nil, err
+		}
+		groupings[i] = &proto.ScopeGrouping{Scopes:sg.Scopes, Expiration:exp}
+	}
+	return groupings, nil
+}
+
+// authorize validates the token like GetSession does, then checks it against the required scopes
+func (s *GRPCServer) authorize(token string, requiredScopes []string) (*sessionState, *session_representations.Authorization, error) {
+	state, err := s.loadSession(token)
+	if err != nil {
+		return nil, nil, err
+	}
+	if state.revoked {
+		return state, &session_representations.Authorization{}, nil
+	}
+	return state, state.validated.Authorize(requiredScopes), nil
+}
+
+func convertAuthorizationToProtobuff(state *sessionState, auth *session_representations.Authorization) (*proto.AuthorizeResponse, error) {
+	response := &proto.AuthorizeResponse{
+		Decision: proto.AuthorizeResponse_DENY,
+		ReauthenticationRequired: auth.ReauthenticationRequired,
+		MissingScopes: auth.MissingScopes,
+		UserUuid: state.validated.Session.CustomerUUID,
+		SessionUuid: state.validated.Session.SessionUUID,
+	}
+
+	switch {
+	case state.revoked:
+		response.DenyReason = proto.AuthorizeResponse_SESSION_REVOKED
+	case auth.Allowed:
+		response.Decision = proto.AuthorizeResponse_ALLOW
+	case auth.ReauthenticationRequired:
+		response.DenyReason = proto.AuthorizeResponse_REAUTHENTICATION_REQUIRED
+	default:
+		response.DenyReason = proto.AuthorizeResponse_MISSING_SCOPES
+	}
+
+	if auth.Grouping != nil {
+		groupings, err := convertScopeGroupingStatusesToProtobuff([]*session_representations.ScopeGroupingStatus{auth.Grouping})
+		if err != nil {
+			return nil, err
+		}
+		response.ScopeGrouping = groupings[0]
+	}
+	return response, nil
+}
@@ -0,0 +1,116 @@
+package session_representations
+
+import (
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"encoding/base64"
+	"encoding/json"
+	"strings"
+
+	"github.com/golang-jwt/jwt/v4"
+)
+
+var (
+	jwtEdDSA = jwt.SigningMethodEdDSA
+	jwtES256 = jwt.SigningMethodES256
+)
+
+// jwtCodec issues compact JWS tokens signed with EdDSA or ES256. The factory's claims are the payload
+// untouched, and the footer's kid and ver are merged into the JOSE header.
+type jwtCodec struct {
+	version int
+	method  jwt.SigningMethod
+}
+
+func (c *jwtCodec) Name() string {
+	return "jwt." + strings.ToLower(c.method.Alg())
+}
+
+func (c *jwtCodec) Version() int {
+	return c.version
+}
+
+func isJWT(token string) bool {
+	return strings.HasPrefix(token, "eyJ") && strings.Count(token, ".") == 2
+}
+
+func (c *jwtCodec) Recognizes(token string) bool {
+	if !isJWT(token) {
+		return false
+	}
+	header, err := c.header(token)
+	return err == nil && header["alg"] == c.method.Alg()
+}
+
+func (c *jwtCodec) CanIssue(key *Key) bool {
+	switch pub := key.PublicKey.(type) {
+	case ed25519.PublicKey:
+		return c.method == jwtEdDSA && key.PrivateKey != nil
+	case *ecdsa.PublicKey:
+		return c.method == jwtES256 && key.PrivateKey != nil && pub.Curve.Params().Name == "P-256"
+	}
+	return false
+}
+
+func (c *jwtCodec) Issue(key *Key, claims []byte, footer []byte) (string, error) {
+	if !c.CanIssue(key) {
+		return "", ErrKeyCantIssue
+	}
+
+	header := map[string]interface{}{}
+	if len(footer) > 0 {
+		err := json.Unmarshal(footer, &header)
+		if err != nil {
+			return "", err
+		}
+	}
+	header["alg"] = c.method.Alg()
+	header["typ"] = "JWT"
+
+	headerJSON, err := json.Marshal(header)
+	if err != nil {
+		return "", err
+	}
+
+	signingString := base64.RawURLEncoding.EncodeToString(headerJSON) + "." + base64.RawURLEncoding.EncodeToString(claims)
+	sig, err := c.method.Sign(signingString, key.PrivateKey)
+	if err != nil {
+		return "", err
+	}
+	return signingString + "." + sig, nil
+}
+
+func (c *jwtCodec) Decode(key *Key, token string) ([]byte, error) {
+	if !c.Recognizes(token) {
+		return nil, ErrMalformedToken
+	}
+	if key.PublicKey == nil {
+		return nil, ErrKeyCantDecode
+	}
+
+	i := strings.LastIndex(token, ".")
+	err := c.method.Verify(token[:i], token[i+1:], key.PublicKey)
+	if err == jwt.ErrInvalidKeyType {
+		return nil, ErrKeyCantDecode
+	}
+	if err != nil {
+		return nil, ErrInvalidSignature
+	}
+
+	parts := strings.Split(token, ".")
+	claims, err := base64.RawURLEncoding.DecodeString(parts[1])
+	if err != nil {
+		return nil, ErrMalformedToken
+	}
+	return claims, nil
+}
+
+func (c *jwtCodec) header(token string) (map[string]interface{}, error) {
+	bs, err := base64.RawURLEncoding.DecodeString(token[:strings.Index(token, ".")])
+	if err != nil {
+		return nil, err
+	}
+	header := map[string]interface{}{}
+	err = json.Unmarshal(bs, &header)
+	return header, err
+}
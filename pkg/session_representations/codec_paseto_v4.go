@@ -0,0 +1,204 @@
+package session_representations
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base64"
+	"strings"
+
+	"golang.org/x/crypto/blake2b"
+	"golang.org/x/crypto/chacha20"
+)
+
+const (
+	v4NonceSize = 32
+	v4TagSize   = 32
+)
+
+// pasetoV4Codec issues v4.local tokens (XChaCha20 with a BLAKE2b MAC) or v4.public tokens (Ed25519),
+// following the PASETO v4 specification. Implicit assertions aren't used.
+type pasetoV4Codec struct {
+	version int
+	public  bool
+}
+
+func (c *pasetoV4Codec) Name() string {
+	if c.public {
+		return "paseto.v4.public"
+	}
+	return "paseto.v4.local"
+}
+
+func (c *pasetoV4Codec) Version() int {
+	return c.version
+}
+
+func (c *pasetoV4Codec) header() string {
+	if c.public {
+		return "v4.public."
+	}
+	return "v4.local."
+}
+
+func (c *pasetoV4Codec) Recognizes(token string) bool {
+	return strings.HasPrefix(token, c.header())
+}
+
+func (c *pasetoV4Codec) CanIssue(key *Key) bool {
+	if c.public {
+		_, ok := key.PrivateKey.(ed25519.PrivateKey)
+		return ok
+	}
+	return key.Secret != nil
+}
+
+func (c *pasetoV4Codec) Issue(key *Key, claims []byte, footer []byte) (string, error) {
+	if !c.CanIssue(key) {
+		return "", ErrKeyCantIssue
+	}
+	h := []byte(c.header())
+
+	if c.public {
+		sig := ed25519.Sign(key.PrivateKey.(ed25519.PrivateKey), pae(h, claims, footer, nil))
+		return c.assemble(append(append([]byte{}, claims...), sig...), footer), nil
+	}
+
+	n := make([]byte, v4NonceSize)
+	_, err := rand.Read(n)
+	if err != nil {
+		return "", err
+	}
+
+	ek, n2, ak, err := v4SplitKey(key.Secret, n)
+	if err != nil {
+		return "", err
+	}
+
+	cipher, err := chacha20.NewUnauthenticatedCipher(ek, n2)
+	if err != nil {
+		return "", err
+	}
+	ct := make([]byte, len(claims))
+	cipher.XORKeyStream(ct, claims)
+
+	t, err := v4Tag(ak, pae(h, n, ct, footer, nil))
+	if err != nil {
+		return "", err
+	}
+
+	body := append(append(n, ct...), t...)
+	return c.assemble(body, footer), nil
+}
+
+func (c *pasetoV4Codec) Decode(key *Key, token string) ([]byte, error) {
+	body, footer, err := c.split(token)
+	if err != nil {
+		return nil, err
+	}
+	h := []byte(c.header())
+
+	if c.public {
+		pub, ok := key.PublicKey.(ed25519.PublicKey)
+		if !ok {
+			return nil, ErrKeyCantDecode
+		}
+		if len(body) < ed25519.SignatureSize {
+			return nil, ErrMalformedToken
+		}
+		claims, sig := body[:len(body)-ed25519.SignatureSize], body[len(body)-ed25519.SignatureSize:]
+		if !ed25519.Verify(pub, pae(h, claims, footer, nil), sig) {
+			return nil, ErrInvalidSignature
+		}
+		return claims, nil
+	}
+
+	if key.Secret == nil {
+		return nil, ErrKeyCantDecode
+	}
+	if len(body) < v4NonceSize+v4TagSize {
+		return nil, ErrMalformedToken
+	}
+	n, ct, t := body[:v4NonceSize], body[v4NonceSize:len(body)-v4TagSize], body[len(body)-v4TagSize:]
+
+	ek, n2, ak, err := v4SplitKey(key.Secret, n)
+	if err != nil {
+		return nil, err
+	}
+
+	expected, err := v4Tag(ak, pae(h, n, ct, footer, nil))
+	if err != nil {
+		return nil, err
+	}
+	if subtle.ConstantTimeCompare(t, expected) != 1 {
+		return nil, ErrInvalidSignature
+	}
+
+	cipher, err := chacha20.NewUnauthenticatedCipher(ek, n2)
+	if err != nil {
+		return nil, err
+	}
+	claims := make([]byte, len(ct))
+	cipher.XORKeyStream(claims, ct)
+	return claims, nil
+}
+
+func (c *pasetoV4Codec) assemble(body []byte, footer []byte) string {
+	token := c.header() + base64.RawURLEncoding.EncodeToString(body)
+	if len(footer) > 0 {
+		token += "." + base64.RawURLEncoding.EncodeToString(footer)
+	}
+	return token
+}
+
+func (c *pasetoV4Codec) split(token string) (body []byte, footer []byte, err error) {
+	if !c.Recognizes(token) {
+		return nil, nil, ErrMalformedToken
+	}
+
+	parts := strings.Split(strings.TrimPrefix(token, c.header()), ".")
+	if len(parts) > 2 {
+		return nil, nil, ErrMalformedToken
+	}
+
+	body, err = base64.RawURLEncoding.DecodeString(parts[0])
+	if err != nil {
+		return nil, nil, ErrMalformedToken
+	}
+	if len(parts) == 2 {
+		footer, err = base64.RawURLEncoding.DecodeString(parts[1])
+		if err != nil {
+			return nil, nil, ErrMalformedToken
+		}
+	}
+	return body, footer, nil
+}
+
+// v4SplitKey derives the encryption key, XChaCha20 nonce and authentication key from the secret and the token's nonce
+func v4SplitKey(secret []byte, n []byte) (ek []byte, n2 []byte, ak []byte, err error) {
+	encHash, err := blake2b.New(56, secret)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	encHash.Write([]byte("paseto-encryption-key"))
+	encHash.Write(n)
+	tmp := encHash.Sum(nil)
+
+	authHash, err := blake2b.New(32, secret)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	authHash.Write([]byte("paseto-auth-key-for-aead"))
+	authHash.Write(n)
+
+	return tmp[:32], tmp[32:], authHash.Sum(nil), nil
+}
+
+func v4Tag(ak []byte, preAuth []byte) ([]byte, error) {
+	mac, err := blake2b.New(v4TagSize, ak)
+	if err != nil {
+		return nil, err
+	}
+	mac.Write(preAuth)
+	return mac.Sum(nil), nil
+}
@@ -0,0 +1,125 @@
+package session_representations
+
+import (
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"encoding/hex"
+	"strings"
+	"testing"
+	"time"
+)
+
+func keyringFor(t *testing.T, codec TokenCodec) *Keyring {
+	keyring := NewKeyringWithCodec(codec, 0)
+	var err error
+	switch {
+	case codec == JWTES256:
+		private, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+		err = keyring.AddSigningKey("key", private)
+	case codec.CanIssue(&Key{Secret: testSecret('s')}):
+		err = keyring.Add("key", testSecret('s'))
+	default:
+		_, private, _ := ed25519.GenerateKey(rand.Reader)
+		err = keyring.AddSigningKey("key", private)
+	}
+	if err != nil {
+		t.Fatal(err)
+	}
+	return keyring
+}
+
+func TestCodecsRoundTrip(t *testing.T) {
+	for _, codec := range Codecs() {
+		keyring := keyringFor(t, codec)
+		factory := NewTokenFactory(keyring, "111", "222")
+		factory.AddScopeGrouping([]string{"read"}, time.Now())
+		session, err := factory.GenerateSession()
+		if err != nil {
+			t.Fatalf("%s failed to issue: %v", codec.Name(), err)
+		}
+		if factory.Version != codec.Version() {
+			t.Errorf("%s should set the factory version to %d", codec.Name(), codec.Version())
+		}
+
+		json, err := DecodeTokenToJson(keyring, session.Token)
+		if err != nil {
+			t.Errorf("%s failed to decode its own token: %v", codec.Name(), err)
+		}
+		if json != session.Json {
+			t.Errorf("%s decoded %s, expected %s", codec.Name(), json, session.Json)
+		}
+
+		tampered := session.Token[:len(session.Token)-6] + "AAAAAA"
+		if tampered != session.Token {
+			_, err = DecodeTokenToJson(keyring, tampered)
+			if err == nil {
+				t.Errorf("%s accepted a tampered token", codec.Name())
+			}
+		}
+	}
+}
+
+func TestCodecNamed(t *testing.T) {
+	for _, name := range []string{"paseto.v2.local", "paseto.v2.public", "paseto.v4.local", "paseto.v4.public", "jwt.eddsa", "jwt.es256"} {
+		codec, err := CodecNamed(name)
+		if err != nil || codec.Name() != name {
+			t.Errorf("Expected to find codec %s", name)
+		}
+	}
+
+	_, err := CodecNamed("jwt.none")
+	if err != ErrUnknownCodec {
+		t.Errorf("Expected ErrUnknownCodec, got %v", err)
+	}
+}
+
+func TestFooterVersionMustMatchFormat(t *testing.T) {
+	keyring := keyringFor(t, PasetoV4Local)
+	claims := []byte(`{"version":1}`)
+	// Footer claims the v2.local codec but the token is v4.local
+	token, _ := PasetoV4Local.Issue(keyring.Primary(), claims, []byte(`{"kid":"key","ver":1}`))
+
+	_, err := DecodeTokenToJson(keyring, token)
+	if err != ErrUnknownCodec {
+		t.Errorf("Expected ErrUnknownCodec, got %v", err)
+	}
+}
+
+// Test vector 4-S-1 from the PASETO specification
+func TestPasetoV4PublicVector(t *testing.T) {
+	secretKey, _ := hex.DecodeString("b4cbfb43df4ce210727d953e4a713307fa19bb7d9f85041438d9e11b942a37741eb9dbbbbc047c03fd70604e0071f0987e16b28b757225c11f00415d0e20b1a2")
+	private := ed25519.PrivateKey(secretKey)
+	key := &Key{PrivateKey: private, PublicKey: private.Public()}
+	payload := `{"data":"this is a signed message","exp":"2022-01-01T00:00:00+00:00"}`
+	expected := "v4.public.eyJkYXRhIjoidGhpcyBpcyBhIHNpZ25lZCBtZXNzYWdlIiwiZXhwIjoiMjAyMi0wMS0wMVQwMDowMDowMCswMDowMCJ9bg_XBBzds8lTZShVlwwKSgeKpLT3yukTw6JUz3W4h_ExsQV-P0V54zemZDcAxFaSeef1QlXEFtkqxT1ciiQEDA"
+
+	token, err := PasetoV4Public.Issue(key, []byte(payload), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if token != expected {
+		t.Errorf("Expected %s, got %s", expected, token)
+	}
+
+	claims, err := PasetoV4Public.Decode(key, expected)
+	if err != nil || string(claims) != payload {
+		t.Errorf("Failed to verify the specification's token: %v", err)
+	}
+}
+
+func TestJWTHeaderCarriesKid(t *testing.T) {
+	keyring := keyringFor(t, JWTEdDSA)
+	factory := NewTokenFactory(keyring, "111", "222")
+	factory.AddScopeGrouping([]string{"read"}, time.Now())
+	session, _ := factory.GenerateSession()
+
+	f, err := readFooter(session.Token)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if f.KeyID != "key" || f.Version != JWTEdDSA.Version() || strings.Count(session.Token, ".") != 2 {
+		t.Errorf("Unexpected JWT %s", session.Token)
+	}
+}
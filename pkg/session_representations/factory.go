@@ -2,7 +2,6 @@ package session_representations
 
 import (
 	"encoding/json"
-	"github.com/o1egl/paseto"
 	"time"
 	"errors"
 )
@@ -12,6 +11,9 @@ type Factory struct {
 	CustomerUUID   string `json:"customer_id"`
 	SessionUUID    string `json:"session_id"`
 	ScopeGroupings []*tokenFactoryScopeGrouping `json:"scope_groupings"`
+	// TokenExpiration cuts the token short of the session, it is refreshed rather than logged back into
+	TokenExpiration *time.Time `json:"token_expiration,omitempty"`
+	keyring        *Keyring
 }
 
 func (tf *Factory) Valid() error {
@@ -33,14 +35,22 @@ type Representations struct {
 	FurthestExpiration time.Time
 }
 
-func NewTokenFactory(userUUID string, sessionUUID string) *Factory {
-	return &Factory{Version: 1, CustomerUUID: userUUID, SessionUUID:sessionUUID}
+func NewTokenFactory(keyring *Keyring, userUUID string, sessionUUID string) *Factory {
+	return &Factory{Version: keyring.Codec.Version(), CustomerUUID: userUUID, SessionUUID:sessionUUID, keyring: keyring}
 }
 
 func (tf *Factory) AddScopeGrouping(scopes []string, expiration time.Time) {
 	tf.ScopeGroupings = append(tf.ScopeGroupings, &tokenFactoryScopeGrouping{Scopes: scopes, Expiration:expiration})
 }
 
+// ExpireTokenAt limits how long the token itself is accepted, never past the furthest scope grouping
+func (tf *Factory) ExpireTokenAt(expiration time.Time) {
+	if len(tf.ScopeGroupings) > 0 && expiration.After(tf.findFurthestExpiration()) {
+		expiration = tf.findFurthestExpiration()
+	}
+	tf.TokenExpiration = &expiration
+}
+
 func (tf *Factory) GenerateSession() (*Representations,  error) {
 	err := tf.Valid()
 	if err != nil {
@@ -63,19 +73,27 @@ func (tf *Factory) GenerateSession() (*Representations,  error) {
 }
 
 func (tf *Factory) generateToken() (string, error) {
-	v2 := paseto.NewV2()
-	token, err := v2.Encrypt(secret(), tf, "")
+	key := tf.keyring.Primary()
+	if key == nil {
+		return "", ErrNoPrimaryKey
+	}
+
+	claims, err := json.Marshal(tf)
 	if err != nil {
-		panic(err)
+		return "", err
+	}
+	f, err := json.Marshal(&footer{KeyID: key.ID, Version: tf.Version})
+	if err != nil {
+		return "", err
 	}
 
-	return token, nil
+	return tf.keyring.Codec.Issue(key, claims, f)
 }
 
 func (tf *Factory) generateJSON() (string, error) {
 	bs, err := json.Marshal(tf)
 	if err != nil {
-		panic(err)
+		return "", err
 	}
 	return string(bs), nil
 }
@@ -95,13 +113,38 @@ func secret() []byte {
 	return []byte("YELLOW SUBMARINE, BLACK WIZARDRY")
 }
 
-func DecodeTokenToJson(sessionToken string) string {
-	v2 := paseto.NewV2()
-	var token string
-	var footer string
-	err := v2.Decrypt(sessionToken, secret(), &token, &footer)
+// DecodeTokenToJson picks the codec from the footer's version, then decrypts or verifies the token
+// with the key named by the footer's kid
+func DecodeTokenToJson(keyring *Keyring, sessionToken string) (string, error) {
+	f, err := readFooter(sessionToken)
+	if err != nil {
+		return "", err
+	}
+
+	codec, err := codecFor(sessionToken, f)
 	if err != nil {
-		panic(err)
+		return "", err
 	}
-	return token
-}
\ No newline at end of file
+
+	if f.KeyID == "" {
+		// Tokens issued before key ids existed, try everything that is still usable
+		for _, key := range keyring.Usable() {
+			claims, err := codec.Decode(key, sessionToken)
+			if err == nil {
+				return string(claims), nil
+			}
+		}
+		return "", ErrKeyNotFound
+	}
+
+	key, err := keyring.Lookup(f.KeyID)
+	if err != nil {
+		return "", err
+	}
+
+	claims, err := codec.Decode(key, sessionToken)
+	if err != nil {
+		return "", err
+	}
+	return string(claims), nil
+}
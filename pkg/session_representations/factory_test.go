@@ -6,11 +6,21 @@ import (
 )
 
 func TestCreateToken(t *testing.T) {
-	factory := NewTokenFactory("111", "222")
+	factory := NewTokenFactory(DevelopmentKeyring(), "111", "222")
 	factory.AddScopeGrouping([]string{"read", "write"}, time.Now())
 	factory.AddScopeGrouping([]string{"test", "another"}, time.Now())
 	session, _ := factory.GenerateSession()
 	println(session.Json)
 	println(session.Token)
-	DecodeTokenToJson(session.Token)
-}
\ No newline at end of file
+	_, err := DecodeTokenToJson(DevelopmentKeyring(), session.Token)
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestDecodeInvalidToken(t *testing.T) {
+	_, err := DecodeTokenToJson(DevelopmentKeyring(), "v2.local.not-a-token")
+	if err == nil {
+		t.Errorf("Expected an error decoding an invalid token")
+	}
+}
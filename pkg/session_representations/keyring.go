@@ -0,0 +1,287 @@
+package session_representations
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"errors"
+	"sync"
+	"time"
+)
+
+var (
+	ErrKeyNotFound    = errors.New("no key with that id")
+	ErrKeyRetired     = errors.New("key has been retired")
+	ErrKeyNotYetValid = errors.New("key isn't valid yet")
+	ErrNoPrimaryKey   = errors.New("keyring has no primary key")
+	ErrInvalidKeySize = errors.New("key secret must be 32 bytes")
+	ErrInvalidKeyType = errors.New("public keys must be Ed25519 or ECDSA P-256")
+	ErrKeyCantIssue   = errors.New("key can't issue tokens with the keyring's codec")
+	ErrKeyCantDecode  = errors.New("key can't decode tokens of that format")
+)
+
+// DevelopmentKeyID names the built in key used when no keys are configured
+const DevelopmentKeyID = "development"
+
+// Key is identified by the kid written into token footers. Local keys carry a Secret, public keys
+// carry a PublicKey and, on the issuing side, a PrivateKey. Public keys are either Ed25519 or ECDSA P-256.
+type Key struct {
+	ID         string
+	Secret     []byte
+	PrivateKey crypto.Signer
+	PublicKey  crypto.PublicKey
+	AddedAt    time.Time
+	RetiredAt  time.Time
+}
+
+func (k *Key) retired() bool {
+	return !k.RetiredAt.IsZero()
+}
+
+
+
+// Keyring holds every key tokens may have been issued with. New tokens are always issued with
+// the primary key using Codec, retired keys keep decoding until their grace window has passed
+// so rotating a key doesn't log everyone out.
+type Keyring struct {
+	mu      sync.RWMutex
+	keys    map[string]*Key
+	primary string
+	Codec   TokenCodec
+	Grace   time.Duration
+	// Skew lets a key decode this long before it becomes valid, for clocks that don't quite agree
+	Skew time.Duration
+	now  func() time.Time
+}
+
+// NewKeyring issues v2.local tokens
+func NewKeyring(grace time.Duration) *Keyring {
+	return NewKeyringWithCodec(PasetoV2Local, grace)
+}
+
+// NewPublicKeyring issues v2.public tokens, a verifier only needs to add the verification keys
+func NewPublicKeyring(grace time.Duration) *Keyring {
+	return NewKeyringWithCodec(PasetoV2Public, grace)
+}
+
+func NewKeyringWithCodec(codec TokenCodec, grace time.Duration) *Keyring {
+	return &Keyring{keys: map[string]*Key{}, Codec: codec, Grace: grace, Skew: DefaultClockSkew, now: time.Now}
+}
+
+// DevelopmentKeyring only holds the built in development secret, never use it in production
+func DevelopmentKeyring() *Keyring {
+	k := NewKeyring(0)
+	k.Add(DevelopmentKeyID, secret())
+	k.SetPrimary(DevelopmentKeyID)
+	return k
+}
+
+// Add registers an active symmetric key for local tokens
+func (k *Keyring) Add(id string, secret []byte) error {
+	if len(secret) != 32 {
+		return ErrInvalidKeySize
+	}
+	return k.add(&Key{ID: id, Secret: secret})
+}
+
+// AddSigningKey registers an Ed25519 or ECDSA P-256 private key for issuing public tokens
+func (k *Keyring) AddSigningKey(id string, privateKey crypto.Signer) error {
+	err := validatePublicKey(privateKey.Public())
+	if err != nil {
+		return err
+	}
+	return k.add(&Key{ID: id, PrivateKey: privateKey, PublicKey: privateKey.Public()})
+}
+
+// AddVerificationKey registers a public key that can verify public tokens but never issue them
+func (k *Keyring) AddVerificationKey(id string, publicKey crypto.PublicKey) error {
+	err := validatePublicKey(publicKey)
+	if err != nil {
+		return err
+	}
+	return k.add(&Key{ID: id, PublicKey: publicKey})
+}
+
+func validatePublicKey(publicKey crypto.PublicKey) error {
+	switch pub := publicKey.(type) {
+	case ed25519.PublicKey:
+		if len(pub) == ed25519.PublicKeySize {
+			return nil
+		}
+	case *ecdsa.PublicKey:
+		if pub.Curve == elliptic.P256() {
+			return nil
+		}
+	}
+	return ErrInvalidKeyType
+}
+
+// add registers key, the first key able to issue tokens becomes the primary
+func (k *Keyring) add(key *Key) error {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	key.AddedAt = k.now()
+	k.keys[key.ID] = key
+	if k.primary == "" && k.Codec.CanIssue(key) {
+		k.primary = key.ID
+	}
+	return nil
+}
+
+// SetPrimary makes id the key new tokens are issued with
+func (k *Keyring) SetPrimary(id string) error {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	key, ok := k.keys[id]
+	if !ok {
+		return ErrKeyNotFound
+	}
+	if key.retired() {
+		return ErrKeyRetired
+	}
+	if !k.Codec.CanIssue(key) {
+		return ErrKeyCantIssue
+	}
+	k.primary = id
+	return nil
+}
+
+// Retire stops a key from issuing new tokens, it still decodes until the grace window passes
+func (k *Keyring) Retire(id string) error {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	key, ok := k.keys[id]
+	if !ok {
+		return ErrKeyNotFound
+	}
+	if id == k.primary {
+		k.primary = ""
+	}
+	if !key.retired() {
+		key.RetiredAt = k.now()
+	}
+	return nil
+}
+
+// Schedule sets when a key became or becomes valid and when it was or will be retired, for keys whose
+// lifetime is configured rather than rotated in process. Zero times are left as they were.
+func (k *Keyring) Schedule(id string, notBefore time.Time, retiredAt time.Time) error {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	key, ok := k.keys[id]
+	if !ok {
+		return ErrKeyNotFound
+	}
+	if !notBefore.IsZero() {
+		key.AddedAt = notBefore
+	}
+	if !retiredAt.IsZero() {
+		key.RetiredAt = retiredAt
+		if id == k.primary {
+			k.primary = ""
+		}
+	}
+	return nil
+}
+
+// Rotate adds a new local key, makes it the primary, and retires the old primary
+func (k *Keyring) Rotate(id string, secret []byte) error {
+	old := k.Primary()
+	err := k.Add(id, secret)
+	if err != nil {
+		return err
+	}
+	return k.promote(id, old)
+}
+
+// RotateSigningKey adds a new signing key, makes it the primary, and retires the old primary
+func (k *Keyring) RotateSigningKey(id string, privateKey crypto.Signer) error {
+	old := k.Primary()
+	err := k.AddSigningKey(id, privateKey)
+	if err != nil {
+		return err
+	}
+	return k.promote(id, old)
+}
+
+func (k *Keyring) promote(id string, old *Key) error {
+	err := k.SetPrimary(id)
+	if err != nil {
+		return err
+	}
+	if old != nil && old.ID != id {
+		return k.Retire(old.ID)
+	}
+	return nil
+}
+
+func (k *Keyring) Primary() *Key {
+	k.mu.RLock()
+	defer k.mu.RUnlock()
+	return k.keys[k.primary]
+}
+
+// Lookup returns the key a token was issued with, as long as it has become valid and is active or
+// still inside its grace window
+func (k *Keyring) Lookup(id string) (*Key, error) {
+	return k.lookup(id, false)
+}
+
+// lookup is Lookup, also returning keys that aren't valid yet when pending is set
+func (k *Keyring) lookup(id string, pending bool) (*Key, error) {
+	k.mu.RLock()
+	defer k.mu.RUnlock()
+	key, ok := k.keys[id]
+	if !ok {
+		return nil, ErrKeyNotFound
+	}
+	if key.retired() && k.now().After(key.RetiredAt.Add(k.Grace)) {
+		return nil, ErrKeyRetired
+	}
+	if !pending && k.now().Add(k.Skew).Before(key.AddedAt) {
+		return nil, ErrKeyNotYetValid
+	}
+	return key, nil
+}
+
+// Usable returns every key that can decode now, primary first
+func (k *Keyring) Usable() []*Key {
+	return k.usable(false)
+}
+
+// usable is Usable, also returning keys that aren't valid yet when pending is set
+func (k *Keyring) usable(pending bool) []*Key {
+	k.mu.RLock()
+	primary := k.primary
+	ids := make([]string, 0, len(k.keys))
+	for id := range k.keys {
+		ids = append(ids, id)
+	}
+	k.mu.RUnlock()
+
+	var keys []*Key
+	if key, err := k.lookup(primary, pending); err == nil {
+		keys = append(keys, key)
+	}
+	for _, id := range ids {
+		if id == primary {
+			continue
+		}
+		if key, err := k.lookup(id, pending); err == nil {
+			keys = append(keys, key)
+		}
+	}
+	return keys
+}
+
+// Prune forgets retired keys whose grace window has passed
+func (k *Keyring) Prune() {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	for id, key := range k.keys {
+		if key.retired() && k.now().After(key.RetiredAt.Add(k.Grace)) {
+			delete(k.keys, id)
+		}
+	}
+}
@@ -0,0 +1,193 @@
+package session_representations
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"strings"
+	"testing"
+	"time"
+)
+
+func testSecret(fill byte) []byte {
+	s := make([]byte, 32)
+	for i := range s {
+		s[i] = fill
+	}
+	return s
+}
+
+func generateTestToken(t *testing.T, keyring *Keyring) string {
+	factory := NewTokenFactory(keyring, "111", "222")
+	factory.AddScopeGrouping([]string{"read"}, time.Now())
+	session, err := factory.GenerateSession()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return session.Token
+}
+
+func TestRotatedKeyDecryptsDuringGrace(t *testing.T) {
+	now := time.Now()
+	keyring := NewKeyring(time.Hour)
+	keyring.now = func() time.Time { return now }
+	keyring.Add("a", testSecret('a'))
+
+	oldToken := generateTestToken(t, keyring)
+	err := keyring.Rotate("b", testSecret('b'))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if keyring.Primary().ID != "b" {
+		t.Errorf("Expected rotated key to become primary")
+	}
+
+	_, err = DecodeTokenToJson(keyring, oldToken)
+	if err != nil {
+		t.Errorf("Token from retired key should decrypt during grace: %v", err)
+	}
+
+	now = now.Add(2 * time.Hour)
+	_, err = DecodeTokenToJson(keyring, oldToken)
+	if err != ErrKeyRetired {
+		t.Errorf("Expected ErrKeyRetired after grace, got %v", err)
+	}
+
+	_, err = DecodeTokenToJson(keyring, generateTestToken(t, keyring))
+	if err != nil {
+		t.Errorf("Token from new primary should decrypt: %v", err)
+	}
+}
+
+func TestDecodeSelectsKeyByFooter(t *testing.T) {
+	keyring := NewKeyring(0)
+	keyring.Add("a", testSecret('a'))
+	keyring.Add("b", testSecret('b'))
+
+	tokenA := generateTestToken(t, keyring)
+	keyring.SetPrimary("b")
+	tokenB := generateTestToken(t, keyring)
+
+	for _, token := range []string{tokenA, tokenB} {
+		_, err := DecodeTokenToJson(keyring, token)
+		if err != nil {
+			t.Errorf("Both active keys should decrypt: %v", err)
+		}
+	}
+
+	_, err := DecodeTokenToJson(DevelopmentKeyring(), tokenA)
+	if err != ErrKeyNotFound {
+		t.Errorf("Expected ErrKeyNotFound for an unknown kid, got %v", err)
+	}
+}
+
+func TestAddRejectsShortSecrets(t *testing.T) {
+	err := NewKeyring(0).Add("short", []byte("too short"))
+	if err != ErrInvalidKeySize {
+		t.Errorf("Expected ErrInvalidKeySize, got %v", err)
+	}
+}
+
+func TestPublicTokensVerifyWithOnlyThePublicKey(t *testing.T) {
+	_, private, _ := ed25519.GenerateKey(rand.Reader)
+	issuer := NewPublicKeyring(0)
+	issuer.AddSigningKey("signing", private)
+
+	token := generateTestToken(t, issuer)
+	if !strings.HasPrefix(token, "v2.public.") {
+		t.Fatalf("Expected a v2.public token, got %s", token)
+	}
+
+	verifier := NewPublicKeyring(0)
+	verifier.AddVerificationKey("signing", private.Public().(ed25519.PublicKey))
+	if verifier.Primary() != nil {
+		t.Errorf("A verification key should never become the primary")
+	}
+
+	_, err := DecodeTokenToJson(verifier, token)
+	if err != nil {
+		t.Errorf("Verifier should accept a token signed by the matching private key: %v", err)
+	}
+
+	_, other, _ := ed25519.GenerateKey(rand.Reader)
+	impostor := NewPublicKeyring(0)
+	impostor.AddVerificationKey("signing", other.Public().(ed25519.PublicKey))
+	_, err = DecodeTokenToJson(impostor, token)
+	if err == nil {
+		t.Errorf("Token should not verify against a different public key")
+	}
+}
+
+func TestVerificationKeysRoundTrip(t *testing.T) {
+	_, current, _ := ed25519.GenerateKey(rand.Reader)
+	_, upcoming, _ := ed25519.GenerateKey(rand.Reader)
+	issuer := NewPublicKeyring(time.Hour)
+	issuer.AddSigningKey("current", current)
+	issuer.AddSigningKey("upcoming", upcoming)
+
+	vks := issuer.VerificationKeys()
+	if len(vks) != 2 {
+		t.Fatalf("Expected current and upcoming keys, got %d", len(vks))
+	}
+
+	token := generateTestToken(t, issuer)
+	verifier := KeyringFromVerificationKeys(vks)
+	_, err := DecodeTokenToJson(verifier, token)
+	if err != nil {
+		t.Errorf("Published keys should verify issued tokens: %v", err)
+	}
+
+	issuer.SetPrimary("upcoming")
+	_, err = DecodeTokenToJson(verifier, generateTestToken(t, issuer))
+	if err != nil {
+		t.Errorf("Keys published ahead of rotation should verify after it: %v", err)
+	}
+}
+
+func TestVerificationKeysPastNotAfter(t *testing.T) {
+	_, current, _ := ed25519.GenerateKey(rand.Reader)
+	issuer := NewPublicKeyring(0)
+	issuer.AddSigningKey("current", current)
+	token := generateTestToken(t, issuer)
+
+	vks := issuer.VerificationKeys()
+	vks[0].NotAfter = time.Now().Add(-time.Minute)
+	_, err := DecodeTokenToJson(KeyringFromVerificationKeys(vks), token)
+	if err == nil {
+		t.Errorf("A key past its not after should no longer verify")
+	}
+
+	vks[0].NotAfter = time.Now().Add(time.Hour)
+	_, err = DecodeTokenToJson(KeyringFromVerificationKeys(vks), token)
+	if err != nil {
+		t.Errorf("A key inside its validity window should verify: %v", err)
+	}
+}
+
+func TestKeysDontVerifyBeforeNotBefore(t *testing.T) {
+	_, next, _ := ed25519.GenerateKey(rand.Reader)
+	issuer := NewPublicKeyring(0)
+	issuer.AddSigningKey("next", next)
+	token := generateTestToken(t, issuer)
+	issuer.Schedule("next", time.Now().Add(time.Hour), time.Time{})
+
+	_, err := DecodeTokenToJson(issuer, token)
+	if err != ErrKeyNotYetValid {
+		t.Errorf("Expected a key scheduled for later not to verify yet, got %v", err)
+	}
+
+	vks := issuer.VerificationKeys()
+	if len(vks) != 1 {
+		t.Fatalf("Expected keys to be published before they become valid, got %d", len(vks))
+	}
+	verifier := KeyringFromVerificationKeys(vks)
+	_, err = DecodeTokenToJson(verifier, token)
+	if err != ErrKeyNotYetValid {
+		t.Errorf("Expected a pre-published key not to verify before its not before, got %v", err)
+	}
+
+	verifier.now = func() time.Time { return time.Now().Add(time.Hour - DefaultClockSkew/2) }
+	_, err = DecodeTokenToJson(verifier, token)
+	if err != nil {
+		t.Errorf("Expected the key to verify once its not before is within the clock skew: %v", err)
+	}
+}
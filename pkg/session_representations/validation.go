@@ -0,0 +1,161 @@
+package session_representations
+
+import (
+	"encoding/json"
+	"errors"
+	"time"
+)
+
+var ErrSessionExpired = errors.New("session has expired")
+
+// ErrTokenExpired means the session is still live but this token isn't, refreshing gets a new one
+var ErrTokenExpired = errors.New("token has expired")
+
+const DefaultClockSkew = 30 * time.Second
+
+// Clock lets callers control what "now" means while validating
+type Clock func() time.Time
+
+// SessionValidator decides which of a session's scope groupings are still live. Skew is the tolerance
+// given to clocks that disagree between the issuer and whoever presents the token.
+type SessionValidator struct {
+	Clock Clock
+	Skew  time.Duration
+}
+
+func NewSessionValidator(skew time.Duration) *SessionValidator {
+	return &SessionValidator{Clock: time.Now, Skew: skew}
+}
+
+type ScopeGroupingStatus struct {
+	Scopes     []string
+	Expiration time.Time
+	Expired    bool
+}
+
+type ValidatedSession struct {
+	Session          *Factory
+	ActiveGroupings  []*ScopeGroupingStatus
+	ExpiredGroupings []*ScopeGroupingStatus
+	// Expiration is the furthest expiration of any grouping
+	Expiration time.Time
+}
+
+// ActiveScopes is every scope granted by a live grouping, without duplicates
+func (vs *ValidatedSession) ActiveScopes() []string {
+	seen := map[string]bool{}
+	var scopes []string
+	for _, sg := range vs.ActiveGroupings {
+		for _, scope := range sg.Scopes {
+			if !seen[scope] {
+				seen[scope] = true
+				scopes = append(scopes, scope)
+			}
+		}
+	}
+	return scopes
+}
+
+// DecodeSessionJson reads the json held in a token back into its session
+func DecodeSessionJson(sessionJson string) (*Factory, error) {
+	var f Factory
+	err := json.Unmarshal([]byte(sessionJson), &f)
+	if err != nil {
+		return nil, err
+	}
+	err = f.Valid()
+	if err != nil {
+		return nil, err
+	}
+	return &f, nil
+}
+
+func (v *SessionValidator) expired(expiration time.Time) bool {
+	return v.Clock().After(expiration.Add(v.Skew))
+}
+
+// Validate splits the session's groupings into live and expired ones, and fails with ErrSessionExpired
+// once every grouping has expired, or ErrTokenExpired once a token cut short of its session has
+func (v *SessionValidator) Validate(session *Factory) (*ValidatedSession, error) {
+	vs := &ValidatedSession{Session: session, Expiration: session.findFurthestExpiration()}
+	for _, sg := range session.ScopeGroupings {
+		status := &ScopeGroupingStatus{Scopes: sg.Scopes, Expiration: sg.Expiration, Expired: v.expired(sg.Expiration)}
+		if status.Expired {
+			vs.ExpiredGroupings = append(vs.ExpiredGroupings, status)
+		} else {
+			vs.ActiveGroupings = append(vs.ActiveGroupings, status)
+		}
+	}
+
+	if v.expired(vs.Expiration) {
+		return vs, ErrSessionExpired
+	}
+	if session.TokenExpiration != nil && v.expired(*session.TokenExpiration) {
+		return vs, ErrTokenExpired
+	}
+	return vs, nil
+}
+
+// ValidateExpiration checks an expiration stored outside the token, like the session row's
+func (v *SessionValidator) ValidateExpiration(expiration time.Time) error {
+	if v.expired(expiration) {
+		return ErrSessionExpired
+	}
+	return nil
+}
+
+// Authorization is the outcome of checking a session against a set of required scopes
+type Authorization struct {
+	Allowed bool
+	// Grouping is the live grouping holding every required scope, or when denied, the expired one that did
+	Grouping *ScopeGroupingStatus
+	// ReauthenticationRequired is set when only an expired grouping held the scopes, so logging in again would allow it
+	ReauthenticationRequired bool
+	MissingScopes            []string
+}
+
+func (sg *ScopeGroupingStatus) holds(scopes []string) bool {
+	for _, required := range scopes {
+		found := false
+		for _, scope := range sg.Scopes {
+			if scope == required {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	return true
+}
+
+// Authorize looks for a single live grouping holding every required scope. Scopes aren't combined across
+// groupings since each grouping was granted, and expires, as a unit.
+func (vs *ValidatedSession) Authorize(required []string) *Authorization {
+	for _, sg := range vs.ActiveGroupings {
+		if sg.holds(required) {
+			return &Authorization{Allowed: true, Grouping: sg}
+		}
+	}
+
+	auth := &Authorization{}
+	active := map[string]bool{}
+	for _, scope := range vs.ActiveScopes() {
+		active[scope] = true
+	}
+	for _, scope := range required {
+		if !active[scope] {
+			auth.MissingScopes = append(auth.MissingScopes, scope)
+		}
+	}
+
+	for _, sg := range vs.ExpiredGroupings {
+		if sg.holds(required) {
+			auth.Grouping = sg
+			auth.ReauthenticationRequired = true
+			break
+		}
+	}
+	return auth
+}
@@ -0,0 +1,115 @@
+package session_representations
+
+import (
+	"testing"
+	"time"
+)
+
+func TestValidateSplitsGroupings(t *testing.T) {
+	now := time.Now()
+	factory := NewTokenFactory(DevelopmentKeyring(), "111", "222")
+	factory.AddScopeGrouping([]string{"read", "comment"}, now.Add(time.Hour))
+	factory.AddScopeGrouping([]string{"write"}, now.Add(-time.Hour))
+
+	validator := NewSessionValidator(0)
+	validator.Clock = func() time.Time { return now }
+	vs, err := validator.Validate(factory)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(vs.ActiveGroupings) != 1 || len(vs.ExpiredGroupings) != 1 {
+		t.Errorf("Expected one active and one expired grouping, got %d and %d", len(vs.ActiveGroupings), len(vs.ExpiredGroupings))
+	}
+	if scopes := vs.ActiveScopes(); len(scopes) != 2 || scopes[0] != "read" {
+		t.Errorf("Unexpected active scopes %v", scopes)
+	}
+}
+
+func TestValidateRejectsExpiredSessions(t *testing.T) {
+	now := time.Now()
+	factory := NewTokenFactory(DevelopmentKeyring(), "111", "222")
+	factory.AddScopeGrouping([]string{"read"}, now.Add(-time.Minute))
+
+	validator := NewSessionValidator(0)
+	validator.Clock = func() time.Time { return now }
+	_, err := validator.Validate(factory)
+	if err != ErrSessionExpired {
+		t.Errorf("Expected ErrSessionExpired, got %v", err)
+	}
+
+	validator.Skew = 2 * time.Minute
+	_, err = validator.Validate(factory)
+	if err != nil {
+		t.Errorf("Expiration inside the skew tolerance should still validate: %v", err)
+	}
+}
+
+func TestDecodeSessionJson(t *testing.T) {
+	factory := NewTokenFactory(DevelopmentKeyring(), "111", "222")
+	factory.AddScopeGrouping([]string{"read"}, time.Now())
+	session, _ := factory.GenerateSession()
+
+	decoded, err := DecodeSessionJson(session.Json)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if decoded.SessionUUID != "222" || len(decoded.ScopeGroupings) != 1 {
+		t.Errorf("Unexpected session %+v", decoded)
+	}
+}
+
+func TestAuthorize(t *testing.T) {
+	now := time.Now()
+	factory := NewTokenFactory(DevelopmentKeyring(), "111", "222")
+	factory.AddScopeGrouping([]string{"read", "comment"}, now.Add(time.Hour))
+	factory.AddScopeGrouping([]string{"read", "write"}, now.Add(-time.Hour))
+
+	validator := NewSessionValidator(0)
+	validator.Clock = func() time.Time { return now }
+	vs, err := validator.Validate(factory)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	auth := vs.Authorize([]string{"read", "comment"})
+	if !auth.Allowed || auth.Grouping == nil || auth.Grouping.Expired {
+		t.Errorf("Expected read and comment to be allowed by the live grouping, got %+v", auth)
+	}
+
+	auth = vs.Authorize([]string{"write"})
+	if auth.Allowed || !auth.ReauthenticationRequired || auth.Grouping == nil || !auth.Grouping.Expired {
+		t.Errorf("Expected write to require reauthentication, got %+v", auth)
+	}
+	if len(auth.MissingScopes) != 1 || auth.MissingScopes[0] != "write" {
+		t.Errorf("Unexpected missing scopes %v", auth.MissingScopes)
+	}
+
+	auth = vs.Authorize([]string{"comment", "write"})
+	if auth.Allowed || auth.ReauthenticationRequired {
+		t.Errorf("Scopes split across groupings should be denied without a reauthentication hint, got %+v", auth)
+	}
+
+	auth = vs.Authorize([]string{"admin"})
+	if auth.Allowed || auth.ReauthenticationRequired || auth.Grouping != nil {
+		t.Errorf("Expected admin to be denied outright, got %+v", auth)
+	}
+}
+
+func TestValidateRejectsExpiredTokens(t *testing.T) {
+	now := time.Now()
+	factory := NewTokenFactory(DevelopmentKeyring(), "111", "222")
+	factory.AddScopeGrouping([]string{"read"}, now.Add(time.Hour))
+	factory.ExpireTokenAt(now.Add(-time.Minute))
+
+	validator := NewSessionValidator(0)
+	validator.Clock = func() time.Time { return now }
+	_, err := validator.Validate(factory)
+	if err != ErrTokenExpired {
+		t.Errorf("Expected ErrTokenExpired, got %v", err)
+	}
+
+	factory.ExpireTokenAt(now.Add(2 * time.Hour))
+	if !factory.TokenExpiration.Equal(now.Add(time.Hour)) {
+		t.Errorf("Token expiration should be capped at the furthest grouping, got %v", factory.TokenExpiration)
+	}
+}
@@ -0,0 +1,138 @@
+package session_representations
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"encoding/base64"
+	"errors"
+	"math/big"
+	"sort"
+	"strings"
+	"time"
+)
+
+const (
+	AlgorithmEdDSA = "EdDSA"
+	AlgorithmES256 = "ES256"
+)
+
+// VerificationKey is the public half of a signing key, safe to hand to any service verifying tokens
+type VerificationKey struct {
+	ID        string
+	PublicKey crypto.PublicKey
+	NotBefore time.Time
+	// NotAfter is zero while the key is active
+	NotAfter time.Time
+	Primary  bool
+	// paserkHeader is k2.public or k4.public depending on the PASETO version the keyring issues
+	paserkHeader string
+}
+
+func (vk *VerificationKey) Algorithm() string {
+	if _, ok := vk.PublicKey.(*ecdsa.PublicKey); ok {
+		return AlgorithmES256
+	}
+	return AlgorithmEdDSA
+}
+
+// EncodedPublicKey is base64url without padding of the raw Ed25519 key, or the uncompressed P-256 point
+func (vk *VerificationKey) EncodedPublicKey() string {
+	switch pub := vk.PublicKey.(type) {
+	case ed25519.PublicKey:
+		return base64.RawURLEncoding.EncodeToString(pub)
+	case *ecdsa.PublicKey:
+		point, err := pub.ECDH()
+		if err != nil {
+			return ""
+		}
+		return base64.RawURLEncoding.EncodeToString(point.Bytes())
+	}
+	return ""
+}
+
+// PASERK is the key serialized as a k2.public or k4.public PASERK, only Ed25519 keys have one
+func (vk *VerificationKey) PASERK() string {
+	pub, ok := vk.PublicKey.(ed25519.PublicKey)
+	if !ok {
+		return ""
+	}
+	header := vk.paserkHeader
+	if header == "" {
+		header = "k2.public."
+	}
+	return header + base64.RawURLEncoding.EncodeToString(pub)
+}
+
+// ParsePublicKey reverses EncodedPublicKey
+func ParsePublicKey(algorithm string, encoded string) (crypto.PublicKey, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(encoded)
+	if err != nil {
+		return nil, err
+	}
+
+	switch algorithm {
+	case AlgorithmEdDSA:
+		if len(raw) != ed25519.PublicKeySize {
+			return nil, ErrInvalidKeyType
+		}
+		return ed25519.PublicKey(raw), nil
+	case AlgorithmES256:
+		if len(raw) != 65 || raw[0] != 4 {
+			return nil, ErrInvalidKeyType
+		}
+		pub := &ecdsa.PublicKey{Curve: elliptic.P256(), X: new(big.Int).SetBytes(raw[1:33]), Y: new(big.Int).SetBytes(raw[33:])}
+		if !pub.Curve.IsOnCurve(pub.X, pub.Y) {
+			return nil, ErrInvalidKeyType
+		}
+		return pub, nil
+	}
+	return nil, errors.New("unknown key algorithm " + algorithm)
+}
+
+// VerificationKeys lists every usable public key, including keys published ahead of becoming valid.
+// Local keyrings never publish anything since their secrets can't be shared.
+func (k *Keyring) VerificationKeys() []*VerificationKey {
+	primary := k.Primary()
+	paserkHeader := "k2.public."
+	if strings.HasPrefix(k.Codec.Name(), "paseto.v4") {
+		paserkHeader = "k4.public."
+	}
+
+	var vks []*VerificationKey
+	for _, key := range k.usable(true) {
+		if key.PublicKey == nil {
+			continue
+		}
+
+		vk := &VerificationKey{ID: key.ID, PublicKey: key.PublicKey, NotBefore: key.AddedAt, Primary: primary != nil && primary.ID == key.ID, paserkHeader: paserkHeader}
+		if key.retired() {
+			vk.NotAfter = key.RetiredAt.Add(k.Grace)
+		}
+		vks = append(vks, vk)
+	}
+
+	sort.Slice(vks, func(i, j int) bool { return vks[i].NotBefore.Before(vks[j].NotBefore) })
+	return vks
+}
+
+// KeyringFromVerificationKeys builds a verify only keyring, used by services that fetched the published keys.
+// Keys only verify between their NotBefore and NotAfter.
+func KeyringFromVerificationKeys(vks []*VerificationKey) *Keyring {
+	keyring := NewPublicKeyring(0)
+	for _, vk := range vks {
+		err := keyring.AddVerificationKey(vk.ID, vk.PublicKey)
+		if err != nil {
+			continue
+		}
+		if !vk.NotBefore.IsZero() {
+			keyring.keys[vk.ID].AddedAt = vk.NotBefore
+		}
+		// With no grace window a key retired at NotAfter stops verifying then
+		if !vk.NotAfter.IsZero() {
+			keyring.keys[vk.ID].RetiredAt = vk.NotAfter
+		}
+	}
+	return keyring
+}